@@ -3,13 +3,37 @@ package main
 import (
 	// Import the local BGP package - this will be used to access the BGPService type
 	"bgp_dashboard/pkg"
+	"context"
+	"io"
 	// Import for logging - log package functions use pointers to output streams internally
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// configPath is the configuration file read at startup
+const configPath = "cmd/config.yaml"
+
+// otlpShutdownTimeout bounds the final OTLP push on exit, so an unreachable
+// collector does not hold up the shutdown
+const otlpShutdownTimeout = 5 * time.Second
+
 func main() {
+	// "bgpdash tap" prints updates for debugging instead of running the service
+	if len(os.Args) > 1 && os.Args[1] == "tap" {
+		if err := runTap(os.Args[2:]); err != nil {
+			log.Fatalf("tap: %v", err)
+		}
+		return
+	}
+
+	build := pkg.GetBuildInfo()
+	log.Printf("Starting bgpdash %s (commit %s, built %s, %s)", build.Version, build.Commit, build.Date, build.GoVersion)
+
 	// Load configuration from YAML file
-	config, err := pkg.LoadConfig("cmd/config.yaml")
+	config, err := pkg.LoadConfig(configPath)
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
@@ -20,7 +44,8 @@ func main() {
 	// 1. The service maintains state that needs to be modified
 	// 2. We want to avoid copying the service structure
 	// 3. Multiple methods need to work with the same instance
-	bgpService := pkg.NewBGPService()
+	// The config is handed over so the service can resolve peer groups
+	bgpService := pkg.NewBGPServiceWithConfig(config)
 
 	// Start the BGP server
 	// Using localRouterId as string (passed by value since strings are immutable)
@@ -34,22 +59,116 @@ func main() {
 	}
 
 	// Configure a BGP peer/neighbor
-	// remotePeerIP is passed by value (strings are immutable)
-	// uint32(remoteASN) is passed by value (basic type)
+	// The config struct is passed by value; AddNeighborConfig only reads it
 	// Method called on bgpService pointer to modify internal state
-	err = bgpService.AddNeighbor(config.BGP.Remote.PeerIP, uint32(config.BGP.Remote.ASN))
+	err = bgpService.AddNeighborConfig(config.BGP.Remote)
 	if err != nil {
 		// err is an interface (containing a pointer) passed to Fatalf
 		log.Fatalf("Failed to add neighbor: %v", err)
 	}
 
+	// Configure any additional neighbors listed under bgp.neighbors
+	for _, neighbor := range config.BGP.Neighbors {
+		if err := bgpService.AddNeighborConfig(neighbor); err != nil {
+			log.Fatalf("Failed to add neighbor %s: %v", neighbor.PeerIP, err)
+		}
+	}
+
+	// Sinks are closed on shutdown so updates still queued are written out
+	var sinks []io.Closer
+
+	// Publish updates to Kafka when brokers are configured
+	// The sink is registered before monitoring starts so no update is missed
+	if len(config.Kafka.Brokers) > 0 {
+		sink, err := pkg.NewKafkaSink(config.Kafka)
+		if err != nil {
+			log.Fatalf("Failed to create Kafka sink: %v", err)
+		}
+		bgpService.AddUpdateHandler(sink)
+		sinks = append(sinks, sink)
+	}
+
+	// Keep updates on disk when a file path is configured
+	if config.File.Path != "" {
+		sink, err := pkg.NewFileSink(config.File)
+		if err != nil {
+			log.Fatalf("Failed to create file sink: %v", err)
+		}
+		bgpService.AddUpdateHandler(sink)
+		sinks = append(sinks, sink)
+	}
+
+	// Forward updates to a syslog collector, e.g. a SIEM, when an address is configured
+	if config.Syslog.Address != "" {
+		sink, err := pkg.NewSyslogSink(config.Syslog)
+		if err != nil {
+			log.Fatalf("Failed to create syslog sink: %v", err)
+		}
+		bgpService.AddUpdateHandler(sink)
+		sinks = append(sinks, sink)
+	}
+
 	// Start monitoring BGP prefix updates in a goroutine
 	// Using a goroutine requires the bgpService pointer to be shared
 	// This is safe because GoBGP handles concurrent access internally
 	go bgpService.MonitorPrefixes()
 
-	// Empty select{} blocks forever
-	// No pointers/references needed as this is just a blocking statement
-	// This prevents the program from exiting and garbage collecting our BGP service
-	select {}
+	// Serve the REST API when a listen address is configured
+	// The handler only holds the bgpService pointer, so it sees live state
+	if config.HTTP.Listen != "" {
+		go func() {
+			if err := pkg.NewHTTPServer(bgpService).ListenAndServe(config.HTTP.Listen); err != nil {
+				log.Fatalf("HTTP server failed: %v", err)
+			}
+		}()
+	}
+
+	// Push the same metrics to an OpenTelemetry collector when an endpoint is configured
+	// It is shut down on exit so the final values are pushed
+	var exporter *pkg.OTLPExporter
+	if config.Metrics.OTLP.Endpoint != "" {
+		if exporter, err = pkg.NewOTLPExporter(bgpService, config.Metrics.OTLP); err != nil {
+			log.Fatalf("Failed to start OTLP metrics export: %v", err)
+		}
+	}
+
+	// Stream updates over gRPC when a listen address is configured
+	if config.GRPC.Listen != "" {
+		go func() {
+			if err := pkg.NewGRPCServer(bgpService).ListenAndServe(config.GRPC.Listen); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+	}
+
+	// Re-read the static routes file on SIGHUP, adding and withdrawing routes
+	// to match; a bad file is logged and the current routes are kept
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := bgpService.LoadStaticRoutes(); err != nil {
+				log.Printf("Failed to reload static routes: %v", err)
+			}
+		}
+	}()
+
+	// Run until SIGINT or SIGTERM, then stop GoBGP so no further updates
+	// arrive, flush the sinks and push the metrics one last time
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	log.Printf("Received %v, shutting down", <-stop)
+	bgpService.Stop()
+	for _, sink := range sinks {
+		if err := sink.Close(); err != nil {
+			log.Printf("Failed to close sink: %v", err)
+		}
+	}
+	if exporter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+		defer cancel()
+		if err := exporter.Shutdown(ctx); err != nil {
+			log.Printf("Failed to shut down OTLP metrics export: %v", err)
+		}
+	}
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bgp_dashboard/pkg"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// runTap implements "bgpdash tap": it brings up the configured sessions,
+// prints updates to stdout as JSON lines and exits after --count of them,
+// or on SIGINT or SIGTERM when the count is 0
+// No sinks, HTTP or gRPC servers are started, so it suits ad hoc debugging
+func runTap(args []string) error {
+	fs := flag.NewFlagSet("tap", flag.ContinueOnError)
+	count := fs.Int("count", 0, "exit after printing this many updates, 0 to run until interrupted")
+	configFile := fs.String("config", configPath, "configuration file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *count < 0 {
+		return fmt.Errorf("--count must not be negative, got %d", *count)
+	}
+
+	config, err := pkg.LoadConfig(*configFile)
+	if err != nil {
+		return fmt.Errorf("loading configuration: %w", err)
+	}
+	bgpService := pkg.NewBGPServiceWithConfig(config)
+	if err := bgpService.Start(config.BGP.Local.RouterID, uint32(config.BGP.Local.ASN)); err != nil {
+		return fmt.Errorf("starting BGP server: %w", err)
+	}
+	defer bgpService.Stop()
+
+	// Subscribed before the peers are added so no update is missed
+	updates, unsubscribe := bgpService.Subscribe()
+	defer unsubscribe()
+
+	for _, neighbor := range append([]pkg.NeighborConfig{config.BGP.Remote}, config.BGP.Neighbors...) {
+		if err := bgpService.AddNeighborConfig(neighbor); err != nil {
+			return fmt.Errorf("adding neighbor %s: %w", neighbor.PeerIP, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return tap(ctx, updates, os.Stdout, *count)
+}
+
+// tap writes updates to w as JSON lines until count have been written,
+// ctx is done or the stream ends; a count of 0 means no limit
+// A stream that ends before the count is reached is an error
+func tap(ctx context.Context, updates <-chan pkg.BGPUpdateMessage, w io.Writer, count int) error {
+	enc := json.NewEncoder(w)
+	for n := 0; count == 0 || n < count; n++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case update, ok := <-updates:
+			if !ok {
+				if count == 0 {
+					return nil
+				}
+				return fmt.Errorf("update stream ended after %d of %d updates", n, count)
+			}
+			if err := enc.Encode(update); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
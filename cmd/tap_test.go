@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bgp_dashboard/pkg"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTapCount verifies tap prints the first N updates as JSON and stops
+// reading the stream once it has them
+func TestTapCount(t *testing.T) {
+	updates := make(chan pkg.BGPUpdateMessage)
+	go func() {
+		// Keeps sending, so tap must stop on its own count
+		for i := 0; ; i++ {
+			update := pkg.BGPUpdateMessage{FromPeer: "192.0.2.1", Sequence: uint64(i + 1)}
+			select {
+			case updates <- update:
+			case <-time.After(time.Second):
+				return
+			}
+		}
+	}()
+
+	var out bytes.Buffer
+	done := make(chan error, 1)
+	go func() { done <- tap(context.Background(), updates, &out, 3) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("tap() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("tap() did not exit after 3 updates")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("tap() printed %d lines, want 3:\n%s", len(lines), out.String())
+	}
+	for i, line := range lines {
+		var got pkg.BGPUpdateMessage
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %q is not an update: %v", line, err)
+		}
+		if got.FromPeer != "192.0.2.1" || got.Sequence != uint64(i+1) {
+			t.Errorf("line %d = peer %q sequence %d, want 192.0.2.1 and %d", i, got.FromPeer, got.Sequence, i+1)
+		}
+	}
+}
+
+// TestTapStreamEnded verifies a stream closing before the count is an error
+func TestTapStreamEnded(t *testing.T) {
+	updates := make(chan pkg.BGPUpdateMessage, 1)
+	updates <- pkg.BGPUpdateMessage{}
+	close(updates)
+
+	var out bytes.Buffer
+	if err := tap(context.Background(), updates, &out, 2); err == nil {
+		t.Error("tap() error = nil, want the short stream reported")
+	}
+	if n := strings.Count(out.String(), "\n"); n != 1 {
+		t.Errorf("tap() printed %d lines, want 1", n)
+	}
+}
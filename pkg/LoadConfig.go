@@ -1,8 +1,13 @@
 package pkg
 
 import (
+	"fmt"
 	"gopkg.in/yaml.v3"
+	"io"
+	"net"
 	"os"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -10,16 +15,192 @@ type Config struct {
 		Local struct {
 			RouterID string `yaml:"routerId"`
 			ASN      int    `yaml:"asn"`
+
+			// DefaultNextHop is advertised for IPv4 routes added without a next hop
+			DefaultNextHop string `yaml:"defaultNextHop"`
 		} `yaml:"local"`
-		Remote struct {
-			PeerIP string `yaml:"peerIP"`
-			ASN    int    `yaml:"asn"`
-		} `yaml:"remote"`
+		Remote     NeighborConfig    `yaml:"remote"`
+		Neighbors  []NeighborConfig  `yaml:"neighbors"`
+		PeerGroups []PeerGroupConfig `yaml:"peerGroups"`
+
+		// DynamicNeighbors accepts sessions from any address in a range using
+		// a peer group's settings, e.g. for an IX route server
+		DynamicNeighbors []DynamicNeighborConfig `yaml:"dynamicNeighbors"`
+
+		// MaxNeighbors caps the number of configured peers, 0 means unlimited
+		MaxNeighbors int `yaml:"maxNeighbors"`
+
+		// AllowedPeers, when set, lists the only address and ASN pairs a
+		// neighbor may be configured with, guarding automated provisioning
+		// against peering with the wrong AS
+		// DynamicNeighbors are exempt, their address and ASN are only
+		// known once a session comes up
+		AllowedPeers []AllowedPeerConfig `yaml:"allowedPeers"`
+
+		// MaxASPathLength flags updates whose AS path is longer, 0 disables the check
+		// With DropLongASPaths set they are dropped instead of being delivered
+		MaxASPathLength int  `yaml:"maxASPathLength"`
+		DropLongASPaths bool `yaml:"dropLongASPaths"`
+
+		// StateDebounce is how long a session must stay in one state before the
+		// change is reported to peer state handlers, 0 reports every transition
+		StateDebounce time.Duration `yaml:"stateDebounce"`
+
+		// MonitorOnly makes the service a passive collector: an export policy
+		// rejects everything towards every neighbor and AddPath is refused
+		MonitorOnly bool `yaml:"monitorOnly"`
+
+		// DefaultPolicy is the action taken on routes that no policy accepts
+		// or rejects: accept, GoBGP's default, or reject for a collector that
+		// only takes what it explicitly permits
+		DefaultPolicy struct {
+			Import string `yaml:"import"`
+			Export string `yaml:"export"`
+		} `yaml:"defaultPolicy"`
+
+		// StaticRoutesFile lists routes to originate, one "CIDR [next-hop]" per line
+		// It is read on Start and again by LoadStaticRoutes, e.g. on SIGHUP
+		StaticRoutesFile string `yaml:"staticRoutesFile"`
+
+		// AdvertisementDelay holds the static routes back after Start until a
+		// neighbor is established or this long has passed, whichever comes
+		// first, so a restart does not advertise before the RIB converges
+		AdvertisementDelay time.Duration `yaml:"advertisementDelay"`
+
+		// PrimaryNeighbor, when set, is the only peer whose session decides readiness
+		PrimaryNeighbor string `yaml:"primaryNeighbor"`
 	} `yaml:"bgp"`
+	HTTP struct {
+		Listen string `yaml:"listen"` // e.g. ":8080"; the HTTP API is disabled when empty
+
+		// Connection timeouts, 0 picks the default and a negative value disables one
+		ReadTimeout  time.Duration `yaml:"readTimeout"`  // Reading a whole request, 30s when 0
+		WriteTimeout time.Duration `yaml:"writeTimeout"` // Writing a response, 60s when 0
+		IdleTimeout  time.Duration `yaml:"idleTimeout"`  // Keeping an idle keep-alive connection, 120s when 0
+	} `yaml:"http"`
+	GRPC struct {
+		Listen    string              `yaml:"listen"`    // e.g. ":50051"; the gRPC stream is disabled when empty
+		Keepalive GRPCKeepaliveConfig `yaml:"keepalive"` // Pings that keep quiet streams open through proxies
+	} `yaml:"grpc"`
+	Kafka KafkaConfig `yaml:"kafka"` // Optional sink publishing every update to Kafka
+	Watch struct {
+		Retry  BackoffConfig `yaml:"retry"`  // Backoff between attempts to re-establish the watch
+		Filter WatchFilter   `yaml:"filter"` // adj-in (default), best or post-policy
+	} `yaml:"watch"`
+	Subscribers struct {
+		QueueSize  int        `yaml:"queueSize"`  // Updates buffered per subscriber, 1024 when 0
+		DropPolicy DropPolicy `yaml:"dropPolicy"` // drop-newest (default) or drop-oldest when a queue is full
+
+		// PauseMode is what PauseUpdates does with updates until ResumeUpdates:
+		// drop (default) or buffer up to PauseBuffer updates, 10000 when 0
+		PauseMode   PauseMode `yaml:"pauseMode"`
+		PauseBuffer int       `yaml:"pauseBuffer"`
+	} `yaml:"subscribers"`
+	Output struct {
+		Fields        []string `yaml:"fields"`        // Update fields to emit, e.g. [prefix, peer, as_path]; all when empty
+		RecentUpdates int      `yaml:"recentUpdates"` // Updates kept for RecentUpdates and GET /updates/recent, 100 when 0
+		LogRate       int      `yaml:"logRate"`       // Updates logged per second, 100 when 0, negative logs all
+		Debug         bool     `yaml:"debug"`         // Also log debug messages, e.g. subscribers coming and going
+	} `yaml:"output"`
+	Metrics struct {
+		Prometheus *bool      `yaml:"prometheus"` // Serve GET /metrics, enabled unless explicitly false
+		OTLP       OTLPConfig `yaml:"otlp"`       // Optional push of the same metrics to an OTLP collector
+	} `yaml:"metrics"`
+	File     FileSinkConfig `yaml:"file"`     // Optional sink appending every update to rotating NDJSON files
+	Syslog   SyslogConfig   `yaml:"syslog"`   // Optional sink sending every update as an RFC 5424 syslog message
+	Snapshot SnapshotConfig `yaml:"snapshot"` // Optional periodic RIB dumps to disk
+
+	// BMP lists BMP stations that GoBGP streams its RIBs to, e.g. a central collector
+	BMP []BMPStationConfig `yaml:"bmp"`
+
+	// Liveness probes GoBGP's API, reporting the service degraded on /healthz
+	// when it stops answering
+	Liveness LivenessConfig `yaml:"liveness"`
+
+	// RPKI fetches ROAs for origin validation and can drop invalid routes
+	RPKI RPKIConfig `yaml:"rpki"`
+}
+
+// NeighborConfig describes a single BGP peer
+// The single "remote" entry and every item under "neighbors" share this shape
+// Settings left unset are inherited from the referenced peer group, if any
+type NeighborConfig struct {
+	PeerIP           string `yaml:"peerIP"`
+	ASN              int    `yaml:"asn"`
+	LocalAS          int    `yaml:"localAS"`  // ASN presented to this peer instead of the global one
+	PeerPort         uint16 `yaml:"peerPort"` // TCP port the peer listens on, 179 when 0
+	PeerGroup        string `yaml:"peerGroup"`
+	NeighborTemplate `yaml:",inline"`
+}
+
+// PeerGroupConfig is a named set of neighbor settings shared by its members
+type PeerGroupConfig struct {
+	Name             string `yaml:"name"`
+	NeighborTemplate `yaml:",inline"`
+}
+
+// NeighborTemplate holds the neighbor settings that a peer group can provide
+// Zero values mean "not set", so a neighbor only overrides what it specifies
+type NeighborTemplate struct {
+	Families          []string       `yaml:"families"`          // e.g. ipv4-unicast, ipv6-unicast; ipv4-unicast when empty
+	HoldTime          uint64         `yaml:"holdTime"`          // Seconds, GoBGP default when 0
+	KeepaliveInterval uint64         `yaml:"keepaliveInterval"` // Seconds, GoBGP default when 0
+	GracefulRestart   *bool          `yaml:"gracefulRestart"`   // Enabled unless explicitly false
+	RestartTime       uint32         `yaml:"restartTime"`       // Seconds, 90 when 0
+	MaxPrefixes       uint32         `yaml:"maxPrefixes"`       // Per family, unlimited when 0
+	AddPaths          AddPathsConfig `yaml:"addPaths"`
+
+	// SoftReconfigInbound lets RouteRefresh re-run import policy for a peer
+	// that lacks the route refresh capability, from the routes it already sent
+	SoftReconfigInbound *bool `yaml:"softReconfigInbound"`
+
+	// NextHopSelf rewrites the next hop of routes advertised to the peer to
+	// our local address, as iBGP peers usually need for routes learned over eBGP
+	NextHopSelf *bool `yaml:"nextHopSelf"`
+
+	// IgnoreMED treats routes from the peer as carrying no MED, i.e. MED 0,
+	// and OverrideMED replaces their MED with a fixed value; only one may be set
+	IgnoreMED   *bool   `yaml:"ignoreMED"`
+	OverrideMED *uint32 `yaml:"overrideMED"`
+
+	// SendCommunity limits the community types advertised to the peer:
+	// none, standard, extended, large or all, the default
+	SendCommunity string `yaml:"sendCommunity"`
+
+	// AllowASIn accepts routes from the peer whose AS path contains our ASN
+	// up to this many times, as hub-and-spoke VPN designs need; 0 rejects them
+	AllowASIn int `yaml:"allowASIn"`
+
+	// Role is our BGP role towards the peer (RFC 9234): provider, customer,
+	// peer, rs or rs-client
+	Role string `yaml:"role"`
+
+	// TCP MD5 password (RFC 2385); prefer AuthPasswordFile over inline secrets
+	AuthPassword     string `yaml:"authPassword"`
+	AuthPasswordFile string `yaml:"authPasswordFile"` // Read into AuthPassword by LoadConfig
 }
 
+// AddPathsConfig controls the ADD-PATH capability (RFC 7911) for a neighbor
+// Leaving both fields at their zero value keeps the capability disabled
+type AddPathsConfig struct {
+	Receive bool   `yaml:"receive"` // Accept multiple paths per prefix from the peer
+	SendMax uint32 `yaml:"sendMax"` // Maximum paths per prefix advertised to the peer
+}
+
+// LoadConfig reads and validates the YAML config file at filename
 func LoadConfig(filename string) (*Config, error) {
-	data, err := os.ReadFile(filename)
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadConfigReader(f)
+}
+
+// LoadConfigReader parses and validates YAML config from r, e.g. a config
+// embedded with go:embed; secret files it references are still read from disk
+func LoadConfigReader(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
@@ -28,6 +209,66 @@ func LoadConfig(filename string) (*Config, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
+	if err := config.readSecretFiles(); err != nil {
+		return nil, err
+	}
+	if nh := config.BGP.Local.DefaultNextHop; nh != "" {
+		if ip := net.ParseIP(nh); ip == nil || ip.To4() == nil {
+			return nil, fmt.Errorf("bgp.local.defaultNextHop: %q is not an IPv4 address", nh)
+		}
+	}
+	if err := validateAllowedPeers(config.BGP.AllowedPeers); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
+
+// readSecretFiles replaces every *File secret reference with the file's contents
+func (c *Config) readSecretFiles() error {
+	if err := c.BGP.Remote.readSecretFiles("bgp.remote"); err != nil {
+		return err
+	}
+	for i := range c.BGP.Neighbors {
+		if err := c.BGP.Neighbors[i].readSecretFiles(fmt.Sprintf("bgp.neighbors[%d]", i)); err != nil {
+			return err
+		}
+	}
+	for i := range c.BGP.PeerGroups {
+		if err := c.BGP.PeerGroups[i].readSecretFiles(fmt.Sprintf("bgp.peerGroups[%d]", i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSecretFiles loads AuthPasswordFile into AuthPassword
+// where names the config entry in error messages
+func (t *NeighborTemplate) readSecretFiles(where string) error {
+	if t.AuthPasswordFile == "" {
+		return nil
+	}
+	if t.AuthPassword != "" {
+		return fmt.Errorf("%s: authPassword and authPasswordFile are mutually exclusive", where)
+	}
+	password, err := readSecretFile(t.AuthPasswordFile)
+	if err != nil {
+		return fmt.Errorf("%s: authPasswordFile: %w", where, err)
+	}
+	t.AuthPassword = password
+	return nil
+}
+
+// readSecretFile returns the contents of a secret file without the
+// trailing newline most editors and "echo" add
+func readSecretFile(name string) (string, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(string(data), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	return secret, nil
+}
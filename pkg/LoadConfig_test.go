@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates name under dir with the given contents and returns its path
+func writeFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadConfigAuthPasswordFile verifies password files are read at load time
+func TestLoadConfigAuthPasswordFile(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := writeFile(t, dir, "peer.secret", "s3cret\n")
+	configFile := writeFile(t, dir, "config.yaml", `
+bgp:
+  remote:
+    peerIP: 192.0.2.1
+    asn: 65002
+    authPasswordFile: `+passwordFile+`
+`)
+
+	config, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig error = %v", err)
+	}
+	if got := config.BGP.Remote.AuthPassword; got != "s3cret" {
+		t.Errorf("AuthPassword = %q, want %q", got, "s3cret")
+	}
+}
+
+// TestLoadConfigMissingPasswordFile verifies a missing password file is a load error
+func TestLoadConfigMissingPasswordFile(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeFile(t, dir, "config.yaml", `
+bgp:
+  neighbors:
+    - peerIP: 192.0.2.1
+      asn: 65002
+      authPasswordFile: `+filepath.Join(dir, "missing.secret")+`
+`)
+
+	_, err := LoadConfig(configFile)
+	if err == nil {
+		t.Fatal("LoadConfig succeeded with a missing password file")
+	}
+	if !strings.Contains(err.Error(), "bgp.neighbors[0]") || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadConfig error = %v, want a not-exist error naming bgp.neighbors[0]", err)
+	}
+}
+
+// TestLoadConfigDefaultNextHop verifies defaultNextHop must be an IPv4 address
+func TestLoadConfigDefaultNextHop(t *testing.T) {
+	for _, nh := range []string{"loopback0", "2001:db8::1"} {
+		configFile := writeFile(t, t.TempDir(), "config.yaml", "bgp:\n  local:\n    defaultNextHop: "+nh+"\n")
+		if _, err := LoadConfig(configFile); err == nil || !strings.Contains(err.Error(), "defaultNextHop") {
+			t.Errorf("LoadConfig(defaultNextHop: %s) error = %v, want a defaultNextHop error", nh, err)
+		}
+	}
+}
+
+// TestLoadConfigAllowedPeers verifies allowlist entries are checked at load
+func TestLoadConfigAllowedPeers(t *testing.T) {
+	for _, entry := range []string{"{peerIP: peer1, asn: 65002}", "{peerIP: 192.0.2.1, asn: 0}", "{peerIP: 192.0.2.1, asn: 4294967296}"} {
+		configFile := writeFile(t, t.TempDir(), "config.yaml", "bgp:\n  allowedPeers:\n    - "+entry+"\n")
+		if _, err := LoadConfig(configFile); err == nil || !strings.Contains(err.Error(), "bgp.allowedPeers[0]") {
+			t.Errorf("LoadConfig(allowedPeers: %s) error = %v, want a bgp.allowedPeers[0] error", entry, err)
+		}
+	}
+}
+
+// TestLoadConfigReader verifies config is parsed from an in-memory reader
+func TestLoadConfigReader(t *testing.T) {
+	config, err := LoadConfigReader(strings.NewReader(`
+bgp:
+  local:
+    routerId: 192.0.2.254
+    asn: 65001
+  neighbors:
+    - peerIP: 192.0.2.1
+      asn: 65002
+      families: [ipv4-unicast, ipv6-unicast]
+http:
+  listen: ":8080"
+`))
+	if err != nil {
+		t.Fatalf("LoadConfigReader error = %v", err)
+	}
+	if config.BGP.Local.RouterID != "192.0.2.254" || config.BGP.Local.ASN != 65001 {
+		t.Errorf("local = %+v, want 192.0.2.254 AS65001", config.BGP.Local)
+	}
+	if len(config.BGP.Neighbors) != 1 || config.BGP.Neighbors[0].PeerIP != "192.0.2.1" || len(config.BGP.Neighbors[0].Families) != 2 {
+		t.Errorf("neighbors = %+v, want 192.0.2.1 with two families", config.BGP.Neighbors)
+	}
+	if config.HTTP.Listen != ":8080" {
+		t.Errorf("http.listen = %q, want :8080", config.HTTP.Listen)
+	}
+
+	// Validation applies to readers too
+	if _, err := LoadConfigReader(strings.NewReader("bgp:\n  local:\n    defaultNextHop: nope\n")); err == nil {
+		t.Error("LoadConfigReader accepted an invalid defaultNextHop")
+	}
+}
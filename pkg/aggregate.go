@@ -0,0 +1,280 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/proto"
+	"log"
+	"net"
+	"net/netip"
+)
+
+// ErrAggregateNotFound is returned when removing a summary never added
+var ErrAggregateNotFound = errors.New("aggregate not found")
+
+// aggregate is a summary added with AddAggregate
+type aggregate struct {
+	path        *api.Path // The summary route, as originated
+	bits        uint32    // Address length of the family
+	summaryOnly bool
+	originated  bool // The summary is in the global RIB
+}
+
+// aggregatePolicy names the export policy suppressing the more-specifics of supernet
+func aggregatePolicy(supernet string) string {
+	return "aggregate-" + supernet
+}
+
+// parseAggregate returns the supernet prefix/prefixLen in CIDR notation
+// with the address length and unspecified next hop of its family
+func parseAggregate(prefix string, prefixLen uint32) (supernet string, bits int, nextHop string, err error) {
+	ip := net.ParseIP(prefix)
+	if ip == nil {
+		return "", 0, "", fmt.Errorf("%w %q", ErrInvalidPrefix, prefix)
+	}
+	bits, nextHop = net.IPv6len*8, "::"
+	if ip.To4() != nil {
+		bits, nextHop = net.IPv4len*8, "0.0.0.0"
+	}
+	if prefixLen > uint32(bits) {
+		return "", 0, "", fmt.Errorf("%w: length %d for %s", ErrInvalidPrefix, prefixLen, prefix)
+	}
+	mask := net.CIDRMask(int(prefixLen), bits)
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String(), bits, nextHop, nil
+}
+
+// AddAggregate originates the summary prefix/prefixLen, e.g. 10.0.0.0 and 8,
+// carrying ATOMIC_AGGREGATE and an AGGREGATOR of our ASN and router ID
+// GoBGP has no aggregation of its own, so the summary is originated as a
+// local route, with an unspecified next hop so GoBGP advertises our own
+// address, for as long as the global RIB holds a more-specific within it
+// With summaryOnly, an export policy rejects every longer prefix within the
+// summary towards all neighbors, though more-specifics a peer already has
+// stay with it until its session is reset; adding it again with summaryOnly
+// false lifts the suppression and RemoveAggregate withdraws the summary
+// In monitor-only mode it fails with ErrMonitorOnly
+func (s *BGPService) AddAggregate(prefix string, prefixLen uint32, summaryOnly bool) error {
+	if s.config.BGP.MonitorOnly {
+		return ErrMonitorOnly
+	}
+	supernet, bits, nextHop, err := parseAggregate(prefix, prefixLen)
+	if err != nil {
+		return err
+	}
+
+	path, err := buildPath(PathSpec{Prefix: supernet, NextHop: nextHop})
+	if err != nil {
+		return err
+	}
+	s.mu.RLock()
+	aggregator := &api.AggregatorAttribute{Asn: s.localASN, Address: s.routerID}
+	s.mu.RUnlock()
+	extra, err := marshalAttrs([]proto.Message{&api.AtomicAggregateAttribute{}, aggregator})
+	if err != nil {
+		return err
+	}
+	path.Pattrs = append(path.Pattrs, extra...)
+
+	s.aggregateMu.Lock()
+	defer s.aggregateMu.Unlock()
+	// Suppression is set up before the summary goes out, so peers never see
+	// the more-specifics withdrawn after the summary arrives
+	s.neighborMu.Lock()
+	err = s.setAggregateSuppression(supernet, uint32(bits), summaryOnly)
+	s.neighborMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("aggregate %s: %w", supernet, err)
+	}
+	agg := &aggregate{path: path, bits: uint32(bits), summaryOnly: summaryOnly}
+	if old, ok := s.aggregates[supernet]; ok {
+		agg.originated = old.originated
+	}
+	s.aggregates[supernet] = agg
+	s.storeAggregateNets()
+	return s.refreshAggregatesLocked()
+}
+
+// RemoveAggregate withdraws the summary prefix/prefixLen added with
+// AddAggregate and lifts the suppression of its more-specifics
+// It fails with ErrAggregateNotFound if no such summary was added
+func (s *BGPService) RemoveAggregate(prefix string, prefixLen uint32) error {
+	supernet, _, _, err := parseAggregate(prefix, prefixLen)
+	if err != nil {
+		return err
+	}
+	s.aggregateMu.Lock()
+	defer s.aggregateMu.Unlock()
+	if _, ok := s.aggregates[supernet]; !ok {
+		return fmt.Errorf("%w: %s", ErrAggregateNotFound, supernet)
+	}
+	return s.removeAggregateLocked(supernet)
+}
+
+// removeAggregateLocked withdraws and forgets the summary supernet;
+// s.aggregateMu must be held
+func (s *BGPService) removeAggregateLocked(supernet string) error {
+	agg := s.aggregates[supernet]
+	// The more-specifics go out again before the summary is withdrawn, so
+	// peers always have a route to the whole range
+	s.neighborMu.Lock()
+	err := s.setAggregateSuppression(supernet, agg.bits, false)
+	s.neighborMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("aggregate %s: %w", supernet, err)
+	}
+	if agg.originated {
+		if err := s.withdrawAggregate(agg); err != nil {
+			return fmt.Errorf("aggregate %s: %w", supernet, err)
+		}
+	}
+	delete(s.aggregates, supernet)
+	s.storeAggregateNets()
+	return nil
+}
+
+// storeAggregateNets publishes the summaries for dispatch to check updates
+// against without taking s.aggregateMu; s.aggregateMu must be held
+func (s *BGPService) storeAggregateNets() {
+	nets := make([]netip.Prefix, 0, len(s.aggregates))
+	for supernet := range s.aggregates {
+		nets = append(nets, netip.MustParsePrefix(supernet))
+	}
+	s.aggregateNets.Store(&nets)
+}
+
+// withdrawAggregate removes the summary of agg from the global RIB
+func (s *BGPService) withdrawAggregate(agg *aggregate) error {
+	return s.server.DeletePath(s.context, &api.DeletePathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    agg.path.Family,
+		Path:      &api.Path{Family: agg.path.Family, Nlri: agg.path.Nlri, IsWithdraw: true},
+	})
+}
+
+// refreshAggregates originates the summaries that gained a contributor and
+// withdraws those that lost their last one
+func (s *BGPService) refreshAggregates() error {
+	s.aggregateMu.Lock()
+	defer s.aggregateMu.Unlock()
+	return s.refreshAggregatesLocked()
+}
+
+// refreshAggregatesLocked is refreshAggregates with s.aggregateMu held
+func (s *BGPService) refreshAggregatesLocked() error {
+	for supernet, agg := range s.aggregates {
+		active, err := s.hasContributor(supernet, agg.path.Family)
+		if err != nil {
+			return fmt.Errorf("aggregate %s: %w", supernet, err)
+		}
+		if active == agg.originated {
+			continue
+		}
+		if active {
+			_, err = s.server.AddPath(s.context, &api.AddPathRequest{TableType: api.TableType_GLOBAL, Path: agg.path})
+		} else {
+			err = s.withdrawAggregate(agg)
+		}
+		if err != nil {
+			return fmt.Errorf("aggregate %s: %w", supernet, err)
+		}
+		agg.originated = active
+	}
+	return nil
+}
+
+// hasContributor reports whether the global RIB holds a route, local or
+// learned, more specific than supernet and within it
+func (s *BGPService) hasContributor(supernet string, family *api.Family) (bool, error) {
+	found := false
+	err := s.server.ListPath(s.context, &api.ListPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    family,
+		Prefixes:  []*api.TableLookupPrefix{{Prefix: supernet, Type: api.TableLookupPrefix_LONGER}},
+	}, func(d *api.Destination) {
+		if d.Prefix != supernet && len(d.Paths) > 0 {
+			found = true
+		}
+	})
+	return found, err
+}
+
+// refreshAggregatesFor schedules a refresh when a received update falls
+// within a summary, without holding up dispatch
+// Refreshes requested while one is pending are coalesced into it
+func (s *BGPService) refreshAggregatesFor(update BGPUpdateMessage) {
+	nets := s.aggregateNets.Load()
+	if nets == nil || len(*nets) == 0 || !withinAggregate(*nets, updatePrefixes(update)) {
+		return
+	}
+	if s.aggregateDirty.Swap(true) {
+		return
+	}
+	go func() {
+		s.aggregateMu.Lock()
+		defer s.aggregateMu.Unlock()
+		// Cleared under the lock so updates arriving during this refresh
+		// schedule another
+		s.aggregateDirty.Store(false)
+		if err := s.refreshAggregatesLocked(); err != nil {
+			log.Printf("Error refreshing aggregates: %v", err)
+		}
+	}()
+}
+
+// withinAggregate reports whether any of prefixes is more specific than,
+// and within, one of nets
+func withinAggregate(nets, prefixes []netip.Prefix) bool {
+	for _, p := range prefixes {
+		for _, n := range nets {
+			if p.Bits() > n.Bits() && n.Contains(p.Addr()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setAggregateSuppression installs or removes the export policy rejecting
+// the more-specifics of supernet; s.neighborMu must be held
+// GoBGP applies export policy changes only to routes it sends afterwards,
+// so lifting the suppression soft resets every peer outbound to advertise
+// the more-specifics held back; GoBGP has no way to withdraw routes a new
+// policy rejects, so more-specifics sent before the suppression stay with
+// a peer until its session is reset
+func (s *BGPService) setAggregateSuppression(supernet string, bits uint32, summaryOnly bool) error {
+	name := aggregatePolicy(supernet)
+	if !summaryOnly {
+		if err := s.removeNeighborPolicy(api.PolicyDirection_EXPORT, name); err != nil {
+			return err
+		}
+		return s.server.ResetPeer(s.context, &api.ResetPeerRequest{
+			Address:   "all",
+			Soft:      true,
+			Direction: api.ResetPeerRequest_OUT,
+		})
+	}
+	_, ipNet, _ := net.ParseCIDR(supernet)
+	ones, _ := ipNet.Mask.Size()
+	if uint32(ones) == bits {
+		return nil // A host route has no more-specifics
+	}
+	set := &api.DefinedSet{
+		DefinedType: api.DefinedType_PREFIX,
+		Name:        name + "-more-specifics",
+		Prefixes: []*api.Prefix{{
+			IpPrefix:      supernet,
+			MaskLengthMin: uint32(ones) + 1,
+			MaskLengthMax: bits,
+		}},
+	}
+	policy := &api.Policy{
+		Name: name,
+		Statements: []*api.Statement{{
+			Name:       name + "-suppress",
+			Conditions: &api.Conditions{PrefixSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: set.Name}},
+			Actions:    &api.Actions{RouteAction: api.RouteAction_REJECT},
+		}},
+	}
+	return s.applyNeighborPolicy(api.PolicyDirection_EXPORT, policy, []*api.DefinedSet{set})
+}
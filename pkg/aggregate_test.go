@@ -0,0 +1,204 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+	"net"
+	"testing"
+	"time"
+)
+
+// newRemotePeer starts a GoBGP speaker in AS 65002 listening on a free
+// loopback port for a session from the service, returning it and the port
+func newRemotePeer(t *testing.T) (*server.BgpServer, uint16) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	remote := server.NewBgpServer()
+	go remote.Serve()
+	ctx := context.Background()
+	if err := remote.StartBgp(ctx, &api.StartBgpRequest{Global: &api.Global{
+		Asn:             65002,
+		RouterId:        "192.0.2.2",
+		ListenPort:      int32(port),
+		ListenAddresses: []string{"127.0.0.1"},
+	}}); err != nil {
+		t.Fatalf("StartBgp() error = %v", err)
+	}
+	t.Cleanup(func() { remote.StopBgp(ctx, &api.StopBgpRequest{}) })
+	if err := remote.AddPeer(ctx, &api.AddPeerRequest{Peer: &api.Peer{
+		Conf:      &api.PeerConf{NeighborAddress: "127.0.0.1", PeerAsn: 65001},
+		Transport: &api.Transport{PassiveMode: true},
+	}}); err != nil {
+		t.Fatalf("AddPeer() error = %v", err)
+	}
+	return remote, uint16(port)
+}
+
+// waitReceived waits until the IPv4 prefixes remote has received satisfy ok
+func waitReceived(t *testing.T, remote *server.BgpServer, ok func(map[string]bool) bool, what string) {
+	t.Helper()
+	family, _ := parseFamily("ipv4-unicast")
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		received := make(map[string]bool)
+		err := remote.ListPath(context.Background(), &api.ListPathRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    family,
+		}, func(d *api.Destination) { received[d.Prefix] = true })
+		if err != nil {
+			t.Fatalf("ListPath() error = %v", err)
+		}
+		if ok(received) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("peer received %v, want %s", received, what)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// TestAggregateExport verifies what a peer receives: the summary only while
+// a contributor exists, without the suppressed more-specifics, and the
+// more-specifics again once the aggregate is removed
+func TestAggregateExport(t *testing.T) {
+	remote, port := newRemotePeer(t)
+	bgpService := newTestService(t, &Config{})
+	if err := bgpService.AddNeighborConfig(NeighborConfig{PeerIP: "127.0.0.1", ASN: 65002, PeerPort: port}); err != nil {
+		t.Fatalf("AddNeighborConfig() error = %v", err)
+	}
+	if err := bgpService.AddPath(PathSpec{Prefix: "192.0.2.0/24", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if err := bgpService.AddAggregate("10.0.0.0", 8, true); err != nil {
+		t.Fatalf("AddAggregate() error = %v", err)
+	}
+	// 192.0.2.0/24 marks the session as up, so the summary's absence is meaningful
+	waitReceived(t, remote, func(r map[string]bool) bool { return r["192.0.2.0/24"] }, "192.0.2.0/24")
+	waitReceived(t, remote, func(r map[string]bool) bool { return !r["10.0.0.0/8"] }, "no summary without contributors")
+
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.1.0.0/16", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	waitReceived(t, remote, func(r map[string]bool) bool { return r["10.0.0.0/8"] }, "the summary")
+	waitReceived(t, remote, func(r map[string]bool) bool { return !r["10.1.0.0/16"] }, "10.1.0.0/16 suppressed")
+
+	if err := bgpService.DeletePath("10.1.0.0/16"); err != nil {
+		t.Fatalf("DeletePath() error = %v", err)
+	}
+	waitReceived(t, remote, func(r map[string]bool) bool { return !r["10.0.0.0/8"] }, "the summary withdrawn with its last contributor")
+
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.1.0.0/16", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	waitReceived(t, remote, func(r map[string]bool) bool { return r["10.0.0.0/8"] }, "the summary")
+	if err := bgpService.RemoveAggregate("10.0.0.0", 8); err != nil {
+		t.Fatalf("RemoveAggregate() error = %v", err)
+	}
+	waitReceived(t, remote, func(r map[string]bool) bool { return r["10.1.0.0/16"] && !r["10.0.0.0/8"] }, "10.1.0.0/16 without the summary")
+	if listPolicy(t, bgpService, aggregatePolicy("10.0.0.0/8")) != nil {
+		t.Errorf("policy %s still installed after RemoveAggregate", aggregatePolicy("10.0.0.0/8"))
+	}
+	if err := bgpService.RemoveAggregate("10.0.0.0", 8); !errors.Is(err, ErrAggregateNotFound) {
+		t.Errorf("RemoveAggregate() again error = %v, want ErrAggregateNotFound", err)
+	}
+}
+
+// TestAddAggregate verifies the summary is originated with the aggregate
+// attributes and summary-only installs, then lifts, the suppression policy
+func TestAddAggregate(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.1.0.0/16", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if err := bgpService.AddAggregate("10.1.2.3", 8, true); err != nil {
+		t.Fatalf("AddAggregate() error = %v", err)
+	}
+
+	local, err := bgpService.ListLocalPaths()
+	if err != nil {
+		t.Fatalf("ListLocalPaths() error = %v", err)
+	}
+	var summary *BGPUpdateMessage
+	for i, u := range local {
+		if len(u.NLRI) == 1 && u.NLRI[0].Prefix.String() == "10.0.0.0" && u.NLRI[0].PrefixLength == 8 {
+			summary = &local[i]
+		}
+	}
+	if summary == nil {
+		t.Fatalf("ListLocalPaths() = %d paths without 10.0.0.0/8", len(local))
+	}
+	if !summary.AtomicAggregate || summary.AggregatorAS == nil || *summary.AggregatorAS != 65001 {
+		t.Errorf("summary AtomicAggregate = %v, AggregatorAS = %v, want true and 65001", summary.AtomicAggregate, summary.AggregatorAS)
+	}
+
+	name := aggregatePolicy("10.0.0.0/8")
+	if !contains(assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT), name) {
+		t.Fatalf("export policies do not include %s", name)
+	}
+	policy := listPolicy(t, bgpService, name)
+	if policy == nil || len(policy.Statements) != 1 || policy.Statements[0].Actions.RouteAction != api.RouteAction_REJECT {
+		t.Fatalf("policy %s = %v, want a single reject statement", name, policy)
+	}
+	var prefixes []*api.Prefix
+	err = bgpService.server.ListDefinedSet(bgpService.context, &api.ListDefinedSetRequest{
+		DefinedType: api.DefinedType_PREFIX,
+		Name:        policy.Statements[0].Conditions.PrefixSet.Name,
+	}, func(set *api.DefinedSet) { prefixes = append(prefixes, set.Prefixes...) })
+	if err != nil {
+		t.Fatalf("ListDefinedSet() error = %v", err)
+	}
+	if len(prefixes) != 1 || prefixes[0].IpPrefix != "10.0.0.0/8" || prefixes[0].MaskLengthMin != 9 || prefixes[0].MaskLengthMax != 32 {
+		t.Errorf("suppressed prefixes = %v, want 10.0.0.0/8 le 9..32", prefixes)
+	}
+
+	// Re-adding without summary-only keeps the summary but advertises the more-specifics again
+	if err := bgpService.AddAggregate("10.0.0.0", 8, false); err != nil {
+		t.Fatalf("AddAggregate() error = %v", err)
+	}
+	if listPolicy(t, bgpService, name) != nil {
+		t.Errorf("policy %s still installed without summary-only", name)
+	}
+	if local, _ = bgpService.ListLocalPaths(); len(local) != 2 {
+		t.Errorf("ListLocalPaths() returned %d paths, want the summary and its contributor", len(local))
+	}
+
+	// Deleting the summary removes the aggregate, leaving no policy behind
+	if err := bgpService.AddAggregate("10.0.0.0", 8, true); err != nil {
+		t.Fatalf("AddAggregate() error = %v", err)
+	}
+	if err := bgpService.DeletePath("10.0.0.0/8"); err != nil {
+		t.Fatalf("DeletePath() error = %v", err)
+	}
+	if listPolicy(t, bgpService, name) != nil {
+		t.Errorf("policy %s still installed after deleting the summary", name)
+	}
+	if local, _ = bgpService.ListLocalPaths(); len(local) != 1 {
+		t.Errorf("ListLocalPaths() returned %d paths, want only the contributor", len(local))
+	}
+}
+
+// TestAddAggregateInvalid verifies bad prefixes and lengths are rejected
+func TestAddAggregateInvalid(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	for _, tt := range []struct {
+		prefix string
+		length uint32
+	}{
+		{"10.0.0.0/8", 8},
+		{"10.0.0.0", 33},
+		{"2001:db8::", 129},
+	} {
+		if err := bgpService.AddAggregate(tt.prefix, tt.length, false); !errors.Is(err, ErrInvalidPrefix) {
+			t.Errorf("AddAggregate(%q, %d) error = %v, want ErrInvalidPrefix", tt.prefix, tt.length, err)
+		}
+	}
+}
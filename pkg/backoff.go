@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Defaults applied to any BackoffConfig field left at its zero value
+const (
+	defaultBackoffInitial    = time.Second
+	defaultBackoffMax        = 30 * time.Second
+	defaultBackoffMultiplier = 2.0
+	defaultBackoffJitter     = 0.1
+)
+
+// BackoffConfig tunes how quickly a failed operation is retried
+type BackoffConfig struct {
+	Initial    time.Duration `yaml:"initial"`    // Delay before the first retry, 1s when 0
+	Max        time.Duration `yaml:"max"`        // Upper bound for any delay, 30s when 0
+	Multiplier float64       `yaml:"multiplier"` // Growth factor per failure, 2 when 0
+	Jitter     float64       `yaml:"jitter"`     // Random +/- fraction of each delay, 0.1 when 0, negative disables
+}
+
+// backoff computes exponentially growing retry delays
+// Not safe for concurrent use; each retry loop owns its own instance
+type backoff struct {
+	cfg      BackoffConfig
+	failures int
+	random   func() float64 // Returns [0.0, 1.0), replaceable in tests
+}
+
+// newBackoff returns a backoff calculator with defaults filled in
+func newBackoff(cfg BackoffConfig) *backoff {
+	if cfg.Initial <= 0 {
+		cfg.Initial = defaultBackoffInitial
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = defaultBackoffMax
+	}
+	if cfg.Max < cfg.Initial {
+		cfg.Max = cfg.Initial
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = defaultBackoffMultiplier
+	}
+	if cfg.Jitter == 0 {
+		cfg.Jitter = defaultBackoffJitter
+	}
+	if cfg.Jitter < 0 {
+		cfg.Jitter = 0
+	}
+	return &backoff{cfg: cfg, random: rand.Float64}
+}
+
+// Next records a failure and returns how long to wait before retrying
+func (b *backoff) Next() time.Duration {
+	delay := float64(b.cfg.Initial) * math.Pow(b.cfg.Multiplier, float64(b.failures))
+	b.failures++
+
+	if b.cfg.Jitter > 0 {
+		delay *= 1 + b.cfg.Jitter*(2*b.random()-1)
+	}
+	if delay > float64(b.cfg.Max) {
+		return b.cfg.Max
+	}
+	return time.Duration(delay)
+}
+
+// Reset starts the sequence over after a successful attempt
+func (b *backoff) Reset() {
+	b.failures = 0
+}
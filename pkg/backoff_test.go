@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBackoffGrowsAndCaps drives the calculator across several failures
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	b := newBackoff(BackoffConfig{
+		Initial:    time.Second,
+		Max:        10 * time.Second,
+		Multiplier: 2,
+		Jitter:     -1,
+	})
+
+	want := []time.Duration{1, 2, 4, 8, 10, 10}
+	for i, w := range want {
+		if got := b.Next(); got != w*time.Second {
+			t.Errorf("failure %d: delay = %v, want %v", i+1, got, w*time.Second)
+		}
+	}
+
+	b.Reset()
+	if got := b.Next(); got != time.Second {
+		t.Errorf("delay after Reset = %v, want 1s", got)
+	}
+}
+
+// TestBackoffJitter verifies jitter stays within the configured fraction and the cap
+func TestBackoffJitter(t *testing.T) {
+	b := newBackoff(BackoffConfig{Initial: time.Second, Max: 2500 * time.Millisecond, Jitter: 0.5})
+
+	// Highest possible random value pushes every delay up by the full jitter
+	b.random = func() float64 { return 0.999999 }
+	if got := b.Next(); got < 1400*time.Millisecond || got > 1500*time.Millisecond {
+		t.Errorf("first delay = %v, want about 1.5s", got)
+	}
+	if got := b.Next(); got != 2500*time.Millisecond {
+		t.Errorf("second delay = %v, want capped at 2.5s", got)
+	}
+}
+
+// TestBackoffDefaults verifies zero config yields the documented defaults
+func TestBackoffDefaults(t *testing.T) {
+	b := newBackoff(BackoffConfig{Jitter: -1})
+
+	if got := b.Next(); got != defaultBackoffInitial {
+		t.Errorf("first delay = %v, want %v", got, defaultBackoffInitial)
+	}
+	for i := 0; i < 10; i++ {
+		b.Next()
+	}
+	if got := b.Next(); got != defaultBackoffMax {
+		t.Errorf("delay after many failures = %v, want %v", got, defaultBackoffMax)
+	}
+}
@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+)
+
+// defaultLocalPref is the LOCAL_PREF GoBGP assumes for paths without one
+const defaultLocalPref = 100
+
+// originNames spells out ORIGIN values in best-path reasons
+var originNames = map[uint8]string{0: "IGP", 1: "EGP", 2: "incomplete"}
+
+// bestPathAttrs are the attributes compared in best-path selection, with
+// missing ones at the values GoBGP compares them as
+type bestPathAttrs struct {
+	localPref    uint32
+	local        bool // Originated by this speaker
+	asPathLength int
+	origin       uint8
+	neighborAS   uint32 // First AS of the path, MEDs are only compared within one
+	med          uint32
+}
+
+// newBestPathAttrs collects the compared attributes of p, parsed as u
+func newBestPathAttrs(p *api.Path, u BGPUpdateMessage) bestPathAttrs {
+	a := bestPathAttrs{localPref: defaultLocalPref, local: isLocalPath(p)}
+	if u.LocalPref != nil {
+		a.localPref = *u.LocalPref
+	}
+	if u.Origin != nil {
+		a.origin = *u.Origin
+	}
+	if u.MED != nil {
+		a.med = *u.MED
+	}
+	if len(u.ASPath) > 0 && len(u.ASPath[0]) > 0 {
+		a.neighborAS = u.ASPath[0][0]
+	}
+	for _, attr := range p.GetPattrs() {
+		if m, err := attr.UnmarshalNew(); err == nil {
+			if asPath, ok := m.(*api.AsPathAttribute); ok {
+				a.asPathLength = asPathLength(asPath.Segments)
+			}
+		}
+	}
+	return a
+}
+
+// markBestPath sets Best on the update of the path GoBGP selected among
+// paths, all for one prefix, and explains in BestPathReason how it beat
+// the runner-up, the path that went furthest through the decision process
+// Nothing is marked when GoBGP selected none, e.g. all are filtered
+func markBestPath(paths []*api.Path, updates []BGPUpdateMessage) {
+	best := -1
+	for i, p := range paths {
+		if p.GetBest() {
+			best = i
+			break
+		}
+	}
+	if best < 0 {
+		return
+	}
+	updates[best].Best = true
+	if len(paths) == 1 {
+		updates[best].BestPathReason = "only path"
+		return
+	}
+
+	winner := newBestPathAttrs(paths[best], updates[best])
+	furthest := -1
+	for i, p := range paths {
+		if i == best {
+			continue
+		}
+		if step, reason := decidingStep(winner, newBestPathAttrs(p, updates[i])); step > furthest {
+			furthest, updates[best].BestPathReason = step, reason
+		}
+	}
+}
+
+// decidingStep returns the first step of the decision process at which best
+// and other differ, numbered in order, with how it went for best
+// Steps past MED, such as eBGP over iBGP or the lowest router ID, are not
+// told apart
+func decidingStep(best, other bestPathAttrs) (int, string) {
+	if best.localPref != other.localPref {
+		return 0, fmt.Sprintf("%s local-pref (%d vs %d)", pick(best.localPref > other.localPref, "higher", "lower"), best.localPref, other.localPref)
+	}
+	if best.local != other.local {
+		return 1, pick(best.local, "locally originated", "learned from a peer")
+	}
+	if best.asPathLength != other.asPathLength {
+		return 2, fmt.Sprintf("%s AS path (%d vs %d)", pick(best.asPathLength < other.asPathLength, "shorter", "longer"), best.asPathLength, other.asPathLength)
+	}
+	if best.origin != other.origin {
+		return 3, fmt.Sprintf("%s origin (%s vs %s)", pick(best.origin < other.origin, "lower", "higher"), originNames[best.origin], originNames[other.origin])
+	}
+	if best.neighborAS == other.neighborAS && best.med != other.med {
+		return 4, fmt.Sprintf("%s MED (%d vs %d)", pick(best.med < other.med, "lower", "higher"), best.med, other.med)
+	}
+	return 5, "tie-break after MED, e.g. eBGP over iBGP or lower router ID"
+}
+
+// pick words a comparison: yes when it went the way the decision process
+// prefers, no when GoBGP still chose best, e.g. with a lower local-pref
+// because the path was otherwise ineligible
+func pick(ok bool, yes, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
@@ -2,18 +2,22 @@ package pkg
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	api "github.com/osrg/gobgp/v3/api"
 	"github.com/osrg/gobgp/v3/pkg/server"
+	"google.golang.org/protobuf/types/known/anypb"
 	"log"
+	"maps"
 	"net"
-)
-
-const (
-	RpkiValid    = 0
-	RpkiNotFound = 1
-	RpkiInvalid  = 2
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // BGPService represents a BGP service instance with a server and context
@@ -22,8 +26,71 @@ const (
 // 2. Methods need to modify its state
 // 3. It's shared between goroutines
 type BGPService struct {
-	server  *server.BgpServer // Pointer to server instance - required by GoBGP API
-	context context.Context   // Interface type, internally may contain pointers
+	server  BgpServer       // GoBGP instance, or a fake in tests
+	context context.Context // Interface type, internally may contain pointers
+	config  *Config         // Loaded configuration, never nil
+	metrics *serviceMetrics // Prometheus instruments, served on /metrics
+	routes  *routeCache     // Received routes by prefix, fed by dispatch
+	recent  *updateRing     // Latest dispatched updates, served by RecentUpdates
+	logRate *logThrottle    // Limits the update lines dispatch logs
+
+	sequence atomic.Uint64 // Last Sequence handed out by dispatch
+
+	updateRate *rateMeter      // Service-wide update rate, drives subscriber sampling
+	peerStates *stateDebouncer // Settles session state changes before they are emitted
+
+	interfaces func() ([]hostInterface, error) // Lists host interfaces for router ID selection
+	listenPort int32                           // Port Start listens on, -1 in tests to not listen
+
+	neighborMu    sync.Mutex        // Serializes neighbor changes so admission checks hold
+	softReconfig  map[string]bool   // Peers configured with softReconfigInbound, guarded by neighborMu
+	nextHopSelf   map[string]bool   // Peers with a next-hop-self export policy, guarded by neighborMu
+	inboundMED    map[string]uint32 // MED forced onto routes from each peer by import policy, guarded by neighborMu
+	sendCommunity map[string]string // sendCommunity of peers with a community-stripping export policy, guarded by neighborMu
+	roles         map[string]string // BGP role configured per peer, guarded by neighborMu
+	prepends      map[string]int    // Prepend count of each originated prefix with an export policy, guarded by neighborMu
+
+	// AS settings of each configured session, replaced under neighborMu
+	// and read without it, so parsing never waits on neighbor changes
+	peerASNs atomic.Pointer[map[string]peerASN]
+
+	alertMu      sync.Mutex                // Guards prefixAlerts and their state
+	prefixAlerts map[string][]*prefixAlert // OnPrefixThreshold handlers by neighbor
+
+	pauseMu     sync.Mutex         // Guards the fields below, never held while delivering
+	paused      bool               // Set by PauseUpdates
+	pauseBuffer []BGPUpdateMessage // Updates held while paused in buffer mode
+	pending     []BGPUpdateMessage // Updates waiting to be fanned out, in order
+	delivering  bool               // A goroutine is draining pending
+
+	livenessMu sync.Mutex // Guards degraded
+	degraded   error      // Why the liveness probe gave up on GoBGP's API, nil while healthy
+
+	aggregateMu    sync.Mutex                     // Serializes aggregate changes and refreshes
+	aggregates     map[string]*aggregate          // Summaries added with AddAggregate, by supernet, guarded by aggregateMu
+	aggregateNets  atomic.Pointer[[]netip.Prefix] // The supernets of aggregates, read by dispatch
+	aggregateDirty atomic.Bool                    // A refresh requested by dispatch is pending
+
+	staticMu     sync.Mutex          // Serializes LoadStaticRoutes, guards the fields below
+	staticRoutes map[string]PathSpec // Routes originated from the static routes file, by prefix
+	staticHeld   bool                // Static routes wait for bgp.advertisementDelay
+	staticHold   context.CancelFunc  // Abandons the advertisement delay, nil when none is pending
+
+	mu             sync.RWMutex       // Guards the fields below
+	handlers       []UpdateHandler    // Consumers of parsed updates, live or replayed
+	stateHandlers  []PeerStateHandler // Consumers of settled session state changes
+	localASN       uint32             // Set by Start, used for AS loop detection
+	routerID       string             // Set by Start
+	globalASN      uint32             // Set by UpdateGlobal, overrides Start's arguments when non-zero
+	globalRouterID string             // Set by UpdateGlobal along with globalASN
+	runCtx         context.Context    // Lives from Start until Stop
+	runCancel      context.CancelFunc // Cancels runCtx
+	replaySpeedup  float64            // Divisor applied to recorded gaps in ReplayUpdates
+
+	subscribers map[*subscriber]struct{} // Channel consumers fed by publish
+	watchRefs   int                      // MonitorPrefixes calls plus subscribers
+	watchCancel context.CancelFunc       // Stops the shared watch, nil when not running
+	watchStarts int                      // Number of times the shared watch was started
 }
 
 // NewBGPService creates and initializes a new BGP service
@@ -32,225 +99,1125 @@ type BGPService struct {
 // 2. Multiple goroutines share this instance
 // 3. Avoid copying the server pointer
 func NewBGPService() *BGPService {
-	return &BGPService{
-		server:  server.NewBgpServer(), // Returns *BgpServer (pointer) as required by GoBGP
-		context: context.Background(),  // Returns interface (may contain pointers internally)
+	return NewBGPServiceWithConfig(&Config{})
+}
+
+// NewBGPServiceWithConfig creates a BGP service that consults config for
+// settings beyond the router ID and ASN passed to Start, such as peer groups
+// The config pointer is retained, so it must not be modified afterwards
+func NewBGPServiceWithConfig(config *Config) *BGPService {
+	return NewBGPServiceWithServer(config, server.NewBgpServer()) // *BgpServer satisfies BgpServer
+}
+
+// NewBGPServiceWithServer creates a BGP service backed by srv instead of a
+// new GoBGP instance, typically a fake in tests
+func NewBGPServiceWithServer(config *Config, srv BgpServer) *BGPService {
+	s := &BGPService{
+		server:  srv,
+		context: context.Background(), // Returns interface (may contain pointers internally)
+		config:  config,
+		routes:  newRouteCache(config.Watch.Filter == WatchBest),
+		recent:  newUpdateRing(config.Output.RecentUpdates),
+		logRate: newLogThrottle(config.Output.LogRate),
+
+		interfaces: listInterfaces,
+		listenPort: bgpPort,
+		updateRate: newRateMeter(),
+
+		staticRoutes:  make(map[string]PathSpec),
+		aggregates:    make(map[string]*aggregate),
+		prefixAlerts:  make(map[string][]*prefixAlert),
+		softReconfig:  make(map[string]bool),
+		nextHopSelf:   make(map[string]bool),
+		inboundMED:    make(map[string]uint32),
+		sendCommunity: make(map[string]string),
+		roles:         make(map[string]string),
+		prepends:      make(map[string]int),
+
+		subscribers: make(map[*subscriber]struct{}),
+
+		replaySpeedup: 1,
 	}
+	s.metrics = newServiceMetrics(s.routeCounts)
+	s.peerStates = newStateDebouncer(config.BGP.StateDebounce, s.emitPeerState, func() {
+		s.metrics.stateChanges.Add(1)
+	})
+	return s
+}
+
+// ErrServiceRunning is returned by operations that need the service stopped
+var ErrServiceRunning = errors.New("service is running")
+
+// errZeroASN rejects a local ASN of 0, which GoBGP happily starts with but
+// which can never form a valid session
+var errZeroASN = errors.New("local ASN must not be 0")
+
+// UpdateGlobal changes the router ID and ASN used by the next Start, e.g.
+// after a renumbering, overriding the arguments passed to Start
+// GoBGP cannot change either on a running server, so the service must be
+// stopped first; an empty router ID is picked from the host's interfaces
+func (s *BGPService) UpdateGlobal(routerID string, asn uint32) error {
+	if asn == 0 {
+		return errZeroASN
+	}
+	if ip := net.ParseIP(routerID); routerID != "" && (ip == nil || ip.To4() == nil) {
+		return fmt.Errorf("router ID %q is not an IPv4 address", routerID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.runCtx != nil && s.runCtx.Err() == nil {
+		return fmt.Errorf("%w: stop it before changing the router ID or ASN", ErrServiceRunning)
+	}
+	s.globalRouterID, s.globalASN = routerID, asn
+	return nil
 }
 
 // Start initializes and starts the BGP server with the given router ID and ASN
+// An empty router ID is replaced by the highest IPv4 address on the host,
+// taken from a loopback interface when one has such an address
+// A router ID and ASN set by UpdateGlobal take precedence over the arguments
 // Uses pointer receiver (*BGPService) to modify server state
 // Parameters are passed by value as they're small and immutable
-func (s *BGPService) Start(routerId string, asn uint32) error {
+// When a step after GoBGP has started fails, the service is stopped again
+func (s *BGPService) Start(routerId string, asn uint32) (err error) {
+	s.mu.RLock()
+	if s.globalASN != 0 {
+		routerId, asn = s.globalRouterID, s.globalASN
+	}
+	s.mu.RUnlock()
+
+	if asn == 0 {
+		return errZeroASN
+	}
+	if err := validateFields(s.config.Output.Fields); err != nil {
+		return err
+	}
+	if err := validateDropPolicy(s.config.Subscribers.DropPolicy); err != nil {
+		return err
+	}
+	if err := validatePauseMode(s.config.Subscribers.PauseMode); err != nil {
+		return err
+	}
+	if err := validateWatchFilter(s.config.Watch.Filter); err != nil {
+		return err
+	}
+	if err := validateBMPStations(s.config.BMP); err != nil {
+		return err
+	}
+	if err := validateRPKI(s.config); err != nil {
+		return err
+	}
+	for _, action := range []string{s.config.BGP.DefaultPolicy.Import, s.config.BGP.DefaultPolicy.Export} {
+		if _, ok := defaultRouteActions[action]; !ok {
+			return fmt.Errorf("invalid default policy %q, expected accept or reject", action)
+		}
+	}
+	// The static routes file is checked before anything is running, so a
+	// typo in it does not leave a half-started speaker behind
+	staticRoutes, err := s.readStaticRoutes()
+	if err != nil {
+		return err
+	}
+	if routerId == "" {
+		// Fall back to an interface address, as routers do without a configured ID
+		ifaces, err := s.interfaces()
+		if err != nil {
+			return fmt.Errorf("selecting router ID: %w", err)
+		}
+		if routerId, err = selectRouterID(ifaces); err != nil {
+			return err
+		}
+		log.Printf("No router ID configured, using %s from the host's interfaces", routerId)
+	}
+
 	go s.server.Serve() // server pointer is safe to use across goroutines
 
 	// StartBgp takes pointer to api.StartBgpRequest containing configuration
 	// Global config is also a pointer as required by protobuf
 	if err := s.server.StartBgp(s.context, &api.StartBgpRequest{
 		Global: &api.Global{ // Pointer to protobuf message
-			Asn:        asn,      // Value type (uint32)
-			RouterId:   routerId, // Value type (string)
-			ListenPort: 179,      // Value type (int)
+			Asn:        asn,          // Value type (uint32)
+			RouterId:   routerId,     // Value type (string)
+			ListenPort: s.listenPort, // Value type (int32)
 		},
 	}); err != nil {
 		return err // error interface (contains pointer)
 	}
+	defer func() {
+		if err != nil {
+			s.Stop()
+		}
+	}()
 
-	return nil
+	if err := s.addDynamicNeighbors(); err != nil {
+		return err
+	}
+	if err := s.addBMPStations(); err != nil {
+		return err
+	}
+	if err := s.addRPKIServers(); err != nil {
+		return err
+	}
+	if err := s.applyOriginValidation(); err != nil {
+		return fmt.Errorf("origin validation: %w", err)
+	}
+
+	// Applied before monitor-only, whose reject default must win
+	if err := s.applyDefaultPolicies(); err != nil {
+		return fmt.Errorf("default policy: %w", err)
+	}
+	if s.config.BGP.MonitorOnly {
+		if err := s.applyMonitorOnly(); err != nil {
+			return fmt.Errorf("monitor-only: %w", err)
+		}
+		log.Printf("Monitor-only mode, no routes will be advertised")
+	}
+
+	s.mu.Lock()
+	s.localASN = asn
+	s.routerID = routerId
+	s.runCtx, s.runCancel = context.WithCancel(s.context)
+	if s.watchRefs > 0 {
+		// Subscribers registered before Start are waiting for the watch
+		s.startWatchLocked()
+	}
+	runCtx := s.runCtx
+	s.mu.Unlock()
+
+	// Made for every run, so a restarted service holds its static routes again
+	established := make(chan struct{})
+	go s.watchPeerState(runCtx, established)
+	if live := s.config.Liveness; live.Interval >= 0 {
+		live.Interval = timeoutOrDefault(live.Interval, defaultLivenessInterval)
+		go s.runLivenessProbe(runCtx, live)
+	}
+	if snap := s.config.Snapshot; snap.Interval > 0 && snap.Dir != "" {
+		go s.runSnapshots(runCtx, snap)
+	}
+
+	if delay := s.config.BGP.AdvertisementDelay; delay > 0 && s.config.BGP.StaticRoutesFile != "" {
+		// The file was validated above and is read again when the delay ends
+		holdCtx, cancel := context.WithCancel(runCtx)
+		s.staticMu.Lock()
+		s.staticHeld, s.staticHold = true, cancel
+		s.staticMu.Unlock()
+		go s.holdStaticRoutes(holdCtx, delay, established)
+		return nil
+	}
+	if staticRoutes == nil {
+		return nil
+	}
+	s.staticMu.Lock()
+	defer s.staticMu.Unlock()
+	return s.applyStaticRoutes(staticRoutes)
 }
 
 // AddNeighbor configures a new BGP peer with the specified address and ASN
 // Uses pointer receiver to modify server state
 // Parameters are passed by value (small, immutable types)
 func (s *BGPService) AddNeighbor(neighborAddress string, neighborAsn uint32) error {
+	return s.AddNeighborConfig(NeighborConfig{
+		PeerIP: neighborAddress,
+		ASN:    int(neighborAsn),
+	})
+}
+
+// AddNeighborConfig configures a new BGP peer from a full neighbor configuration
+// Use this instead of AddNeighbor when per-neighbor options such as ADD-PATH are needed
+// Settings the neighbor leaves unset are inherited from its peer group
+func (s *BGPService) AddNeighborConfig(cfg NeighborConfig) error {
+	return s.addNeighbor(cfg, false)
+}
+
+// UpsertNeighbor adds the peer, or updates an existing peer with the same
+// address in place as UpdateNeighbor does
+func (s *BGPService) UpsertNeighbor(cfg NeighborConfig) error {
+	return s.addNeighbor(cfg, true)
+}
+
+// UpdateNeighbor applies cfg to an existing peer in place
+// GoBGP only restarts the session when a setting carried in the OPEN message
+// changes, such as the peer ASN or address families; changed policies are
+// applied to an established session with a soft reset instead
+func (s *BGPService) UpdateNeighbor(cfg NeighborConfig) error {
+	cfg, peer, err := s.prepareNeighbor(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	return s.updateNeighbor(cfg, peer)
+}
+
+// updateNeighbor applies the prepared cfg and peer to an existing peer;
+// s.neighborMu must be held
+func (s *BGPService) updateNeighbor(cfg NeighborConfig, peer *api.Peer) error {
+	current, err := s.getPeer(cfg.PeerIP)
+	if err != nil {
+		return err
+	}
+	before := s.neighborPolicies(cfg.PeerIP)
+	rsp, err := s.server.UpdatePeer(s.context, &api.UpdatePeerRequest{Peer: peer})
+	if err != nil {
+		return err
+	}
+	if err := s.applyNeighborSettings(cfg); err != nil {
+		return err
+	}
+	after := s.neighborPolicies(cfg.PeerIP)
+
+	if current.GetState().GetSessionState() != api.PeerState_ESTABLISHED {
+		return nil // Policies are applied as the session comes up
+	}
+	resetIn := rsp.GetNeedsSoftResetIn() || before.med != after.med || before.medSet != after.medSet
+	resetOut := before.nextHopSelf != after.nextHopSelf || before.sendCommunity != after.sendCommunity
+	req := &api.ResetPeerRequest{Address: cfg.PeerIP, Soft: true}
+	switch {
+	case resetIn && resetOut:
+		req.Direction = api.ResetPeerRequest_BOTH
+	case resetIn:
+		req.Direction = api.ResetPeerRequest_IN
+	case resetOut:
+		req.Direction = api.ResetPeerRequest_OUT
+	default:
+		return nil
+	}
+	return s.server.ResetPeer(s.context, req)
+}
+
+// neighborPolicySettings is the policy state applyNeighborSettings keeps for a peer
+type neighborPolicySettings struct {
+	med           uint32
+	medSet        bool // Whether med replaces the MED of routes from the peer
+	nextHopSelf   bool
+	sendCommunity string
+}
+
+// neighborPolicies returns the policy settings of neighbor; s.neighborMu must be held
+func (s *BGPService) neighborPolicies(neighbor string) neighborPolicySettings {
+	med, medSet := s.inboundMED[neighbor]
+	return neighborPolicySettings{
+		med:           med,
+		medSet:        medSet,
+		nextHopSelf:   s.nextHopSelf[neighbor],
+		sendCommunity: s.sendCommunity[neighbor],
+	}
+}
+
+// addNeighbor configures a peer, failing with ErrNeighborExists for a
+// duplicate address unless upsert is set, in which case it is updated
+func (s *BGPService) addNeighbor(cfg NeighborConfig, upsert bool) error {
+	cfg, peer, err := s.prepareNeighbor(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+
+	count, exists := 0, false
+	err = s.server.ListPeer(s.context, &api.ListPeerRequest{}, func(p *api.Peer) {
+		count++
+		exists = exists || p.GetConf().GetNeighborAddress() == cfg.PeerIP
+	})
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		if !upsert {
+			return fmt.Errorf("%w: %s", ErrNeighborExists, cfg.PeerIP)
+		}
+		return s.updateNeighbor(cfg, peer)
+	}
+	if limit := s.config.BGP.MaxNeighbors; limit > 0 && count >= limit {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyNeighbors, limit)
+	}
+
+	// Export policies go in before the peer, so the session never comes up
+	// advertising routes they would have filtered or rewritten
+	if err := s.applyNeighborSettings(cfg); err != nil {
+		s.clearNeighborSettings(cfg.PeerIP)
+		return err
+	}
+	// AddPeer takes pointer to request containing pointer to peer config
+	if err := s.server.AddPeer(s.context, &api.AddPeerRequest{
+		Peer: peer, // Pointer to peer configuration
+	}); err != nil {
+		s.clearNeighborSettings(cfg.PeerIP)
+		return err
+	}
+	return nil
+}
+
+// prepareNeighbor resolves cfg against its peer group, validates the
+// settings GoBGP does not check itself and builds the GoBGP peer
+func (s *BGPService) prepareNeighbor(cfg NeighborConfig) (NeighborConfig, *api.Peer, error) {
+	cfg, err := s.resolveNeighbor(cfg)
+	if err != nil {
+		return cfg, nil, err
+	}
+	if _, ok := sendCommunityStrip[cfg.SendCommunity]; !ok {
+		return cfg, nil, fmt.Errorf("%w %s: invalid sendCommunity %q, expected none, standard, extended, large or all", ErrInvalidNeighbor, cfg.PeerIP, cfg.SendCommunity)
+	}
+	if cfg.IgnoreMED != nil && *cfg.IgnoreMED && cfg.OverrideMED != nil {
+		return cfg, nil, fmt.Errorf("%w %s: ignoreMED and overrideMED are mutually exclusive", ErrInvalidNeighbor, cfg.PeerIP)
+	}
+	if cfg.AllowASIn < 0 || cfg.AllowASIn > 255 {
+		return cfg, nil, fmt.Errorf("%w %s: allowASIn %d out of range 0-255", ErrInvalidNeighbor, cfg.PeerIP, cfg.AllowASIn)
+	}
+	if cfg.Role != "" && !bgpRoles[cfg.Role] {
+		return cfg, nil, fmt.Errorf("%w %s: invalid role %q, expected provider, customer, peer, rs or rs-client", ErrInvalidNeighbor, cfg.PeerIP, cfg.Role)
+	}
+	if err := s.checkAllowedPeer(cfg); err != nil {
+		return cfg, nil, err
+	}
+	if err := checkRPKIFamilies(s.config.RPKI, cfg.Families); err != nil {
+		return cfg, nil, fmt.Errorf("%w %s: %w", ErrInvalidNeighbor, cfg.PeerIP, err)
+	}
+
+	peer, err := buildPeer(cfg)
+	return cfg, peer, err
+}
+
+// applyNeighborSettings records the service-side settings of cfg and
+// installs or removes the per-neighbor policies it implies
+// s.neighborMu must be held
+func (s *BGPService) applyNeighborSettings(cfg NeighborConfig) error {
+	asns := peerASN{remote: uint32(cfg.ASN), local: uint32(cfg.LocalAS), allowOwn: uint32(cfg.AllowASIn)}
+	if previous, ok := s.loadPeerASNs()[cfg.PeerIP]; !ok || previous != asns {
+		s.updatePeerASNs(func(m map[string]peerASN) { m[cfg.PeerIP] = asns })
+		if err := s.refreshPrepends(); err != nil {
+			return err
+		}
+	}
+	// GoBGP always retains the Adj-RIB-In, so there is nothing to set on the
+	// peer; the service only needs to know it may rely on it
+	if cfg.SoftReconfigInbound != nil && *cfg.SoftReconfigInbound {
+		s.softReconfig[cfg.PeerIP] = true
+	} else {
+		delete(s.softReconfig, cfg.PeerIP)
+	}
+	if cfg.Role != "" {
+		// GoBGP has no Role capability, so the role is kept for reporting
+		// but not negotiated with the peer
+		log.Printf("BGP role %s for %s is not advertised, GoBGP does not support the Role capability", cfg.Role, cfg.PeerIP)
+		s.roles[cfg.PeerIP] = cfg.Role
+	} else {
+		delete(s.roles, cfg.PeerIP)
+	}
+	if err := s.setNextHopSelf(cfg.PeerIP, cfg.NextHopSelf != nil && *cfg.NextHopSelf); err != nil {
+		return err
+	}
+	med := cfg.OverrideMED
+	if cfg.IgnoreMED != nil && *cfg.IgnoreMED {
+		med = new(uint32)
+	}
+	if err := s.setInboundMED(cfg.PeerIP, med); err != nil {
+		return err
+	}
+	return s.setSendCommunity(cfg.PeerIP, cfg.SendCommunity)
+}
+
+// clearNeighborSettings forgets the service-side settings of a peer and
+// removes its per-neighbor policies, logging what cannot be removed, e.g.
+// to undo applyNeighborSettings for a peer that could not be added
+// s.neighborMu must be held
+func (s *BGPService) clearNeighborSettings(address string) {
+	if err := s.removeNeighborSettings(address); err != nil {
+		log.Printf("Error removing the policies of neighbor %s: %v", address, err)
+	}
+}
+
+// removeNeighborSettings forgets the service-side settings of a peer and
+// removes its per-neighbor policies; s.neighborMu must be held
+func (s *BGPService) removeNeighborSettings(address string) error {
+	delete(s.softReconfig, address)
+	delete(s.roles, address)
+	if _, ok := s.loadPeerASNs()[address]; ok {
+		s.updatePeerASNs(func(m map[string]peerASN) { delete(m, address) })
+		if err := s.refreshPrepends(); err != nil {
+			return err
+		}
+	}
+	if err := s.setNextHopSelf(address, false); err != nil {
+		return err
+	}
+	if err := s.setInboundMED(address, nil); err != nil {
+		return err
+	}
+	return s.setSendCommunity(address, "")
+}
+
+// bgpRoles lists the roles of RFC 9234 by their config names
+var bgpRoles = map[string]bool{
+	"provider":  true,
+	"customer":  true,
+	"peer":      true,
+	"rs":        true,
+	"rs-client": true,
+}
+
+// neighborRole returns the BGP role configured for the peer, if any
+func (s *BGPService) neighborRole(address string) string {
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	return s.roles[address]
+}
+
+// peerASN holds the AS settings of one session
+type peerASN struct {
+	remote   uint32
+	local    uint32 // LocalAS presented to the peer, 0 for the global ASN
+	allowOwn uint32 // Times our ASN may appear in paths from the peer, see AllowASIn
+}
+
+// loadPeerASNs returns the AS settings of every configured session
+// The map is shared and must not be modified
+func (s *BGPService) loadPeerASNs() map[string]peerASN {
+	if m := s.peerASNs.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// updatePeerASNs applies change to a copy of the AS settings and publishes
+// it, so readers keep a consistent map; s.neighborMu must be held
+func (s *BGPService) updatePeerASNs(change func(map[string]peerASN)) {
+	m := maps.Clone(s.loadPeerASNs())
+	if m == nil {
+		m = make(map[string]peerASN)
+	}
+	change(m)
+	s.peerASNs.Store(&m)
+}
+
+// sessionASNs returns the AS settings of the session path was learned over
+// Dynamic neighbors have no configured ASN, so the path's source ASN is used
+func (s *BGPService) sessionASNs(path *api.Path) peerASN {
+	asns, ok := s.loadPeerASNs()[path.GetNeighborIp()]
+	if !ok {
+		asns.remote = path.GetSourceAsn()
+	}
+	return asns
+}
+
+// isEBGP reports whether the session is eBGP, comparing the peer's ASN
+// with the one we present to it
+func (a peerASN) isEBGP(localASN uint32) bool {
+	if a.local == 0 {
+		a.local = localASN
+	}
+	return a.remote != 0 && a.remote != a.local
+}
+
+// softReconfigInbound reports whether the peer was configured with softReconfigInbound
+func (s *BGPService) softReconfigInbound(address string) bool {
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	return s.softReconfig[address]
+}
+
+// bgpPort is the well-known BGP TCP port
+const bgpPort = 179
+
+// buildPeer translates an already resolved NeighborConfig into the GoBGP peer definition
+// Kept separate from AddNeighborConfig so the result can be inspected in tests
+func buildPeer(cfg NeighborConfig) (*api.Peer, error) {
+	families := cfg.Families
+	if len(families) == 0 {
+		families = []string{"ipv4-unicast"}
+	}
+	gracefulRestart := cfg.GracefulRestart == nil || *cfg.GracefulRestart
+	restartTime := cfg.RestartTime
+	if restartTime == 0 {
+		restartTime = 90
+	}
+	peerPort := uint32(cfg.PeerPort)
+	if peerPort == 0 {
+		peerPort = bgpPort
+	}
+
 	// Create neighbor configuration
 	// Uses pointers for protobuf messages as required by gRPC
 	n := &api.Peer{
 		Conf: &api.PeerConf{ // Nested pointer to protobuf message
-			NeighborAddress: neighborAddress, // Value type (string)
-			PeerAsn:         neighborAsn,     // Value type (uint32)
-		},
-		AfiSafis: []*api.AfiSafi{
-			{
-				Config: &api.AfiSafiConfig{
-					Family: &api.Family{
-						Afi:  api.Family_AFI_IP,
-						Safi: api.Family_SAFI_UNICAST,
-					},
-					Enabled: true,
-				},
-				MpGracefulRestart: &api.MpGracefulRestart{
-					Config: &api.MpGracefulRestartConfig{
-						Enabled: true,
-					},
-				},
-			},
+			NeighborAddress: cfg.PeerIP,          // Value type (string)
+			PeerAsn:         uint32(cfg.ASN),     // Value type (uint32)
+			LocalAsn:        uint32(cfg.LocalAS), // 0 falls back to the global ASN
+			AllowOwnAsn:     uint32(cfg.AllowASIn),
+			AuthPassword:    cfg.AuthPassword,
 		},
 		Transport: &api.Transport{
 			PassiveMode: false,
+			RemotePort:  peerPort, // Lab and containerized peers may listen elsewhere
 		},
 		GracefulRestart: &api.GracefulRestart{
-			Enabled:     true,
-			RestartTime: 90,
+			Enabled:     gracefulRestart,
+			RestartTime: restartTime,
 			//LongLivedEnabled:    true,
-			NotificationEnabled: true,
+			NotificationEnabled: gracefulRestart,
 		},
 	}
 
-	// AddPeer takes pointer to request containing pointer to peer config
-	return s.server.AddPeer(s.context, &api.AddPeerRequest{
-		Peer: n, // Pointer to peer configuration
-	})
+	// One AfiSafi per negotiated family, each carrying its own GR and prefix limit
+	for _, name := range families {
+		family, err := parseFamily(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %w", ErrInvalidNeighbor, cfg.PeerIP, err)
+		}
+		afiSafi := &api.AfiSafi{
+			Config: &api.AfiSafiConfig{
+				Family:  family,
+				Enabled: true,
+			},
+			MpGracefulRestart: &api.MpGracefulRestart{
+				Config: &api.MpGracefulRestartConfig{
+					Enabled: gracefulRestart,
+				},
+			},
+		}
+		if cfg.MaxPrefixes > 0 {
+			afiSafi.PrefixLimits = &api.PrefixLimit{
+				Family:      &api.Family{Afi: family.Afi, Safi: family.Safi},
+				MaxPrefixes: cfg.MaxPrefixes,
+			}
+		}
+		n.AfiSafis = append(n.AfiSafis, afiSafi)
+	}
+
+	if cfg.HoldTime > 0 || cfg.KeepaliveInterval > 0 {
+		n.Timers = &api.Timers{
+			Config: &api.TimersConfig{
+				HoldTime:          cfg.HoldTime,
+				KeepaliveInterval: cfg.KeepaliveInterval,
+			},
+		}
+	}
+
+	// ADD-PATH is negotiated per address family, so apply it to every AfiSafi
+	if cfg.AddPaths.Receive || cfg.AddPaths.SendMax > 0 {
+		for _, afiSafi := range n.AfiSafis {
+			afiSafi.AddPaths = &api.AddPaths{
+				Config: &api.AddPathsConfig{
+					Receive: cfg.AddPaths.Receive,
+					SendMax: cfg.AddPaths.SendMax,
+				},
+			}
+		}
+	}
+
+	return n, nil
 }
 
 // MonitorPrefixes establishes a real-time monitor for BGP route updates
 // Uses pointer receiver to access server state
 // Safe for concurrent use as server handles synchronization
+// Blocks until Stop is called; the underlying watch is shared with any
+// channel subscribers so updates are only parsed once
 func (s *BGPService) MonitorPrefixes() {
-	err := s.server.WatchEvent(s.context, &api.WatchEventRequest{
-		Table: &api.WatchEventRequest_Table{
-			Filters: []*api.WatchEventRequest_Table_Filter{
-				{
-					Type: api.WatchEventRequest_Table_Filter_ADJIN,
+	s.mu.RLock()
+	ctx := s.runCtx
+	s.mu.RUnlock()
+	if ctx == nil {
+		log.Printf("Error watching events: BGP service is not started\n")
+		return
+	}
+
+	s.acquireWatch()
+	defer s.releaseWatch()
+	<-ctx.Done()
+}
+
+// WatchFilter chooses which GoBGP table the route watch reports
+type WatchFilter string
+
+const (
+	WatchAdjIn      WatchFilter = "adj-in"      // Routes as received from peers, before import policy
+	WatchBest       WatchFilter = "best"        // Best path changes in the global RIB
+	WatchPostPolicy WatchFilter = "post-policy" // Routes received from peers, after import policy
+)
+
+// watchFilterTypes maps each WatchFilter to its GoBGP table filter
+var watchFilterTypes = map[WatchFilter]api.WatchEventRequest_Table_Filter_Type{
+	"":              api.WatchEventRequest_Table_Filter_ADJIN,
+	WatchAdjIn:      api.WatchEventRequest_Table_Filter_ADJIN,
+	WatchBest:       api.WatchEventRequest_Table_Filter_BEST,
+	WatchPostPolicy: api.WatchEventRequest_Table_Filter_POST_POLICY,
+}
+
+// validateWatchFilter rejects filters other than the known ones or empty
+func validateWatchFilter(filter WatchFilter) error {
+	if _, ok := watchFilterTypes[filter]; !ok {
+		return fmt.Errorf("unknown watch filter %q, want %s, %s or %s", filter, WatchAdjIn, WatchBest, WatchPostPolicy)
+	}
+	return nil
+}
+
+// runWatch feeds every path from the GoBGP watch through dispatch until ctx is done
+// If the watch cannot be established it is retried using the backoff
+// configured under watch.retry; once established it runs until ctx is
+// done, as GoBGP never ends the stream on its own
+// watch.filter picks the table watched
+func (s *BGPService) runWatch(ctx context.Context) {
+	retry := newBackoff(s.config.Watch.Retry)
+	for {
+		err := s.server.WatchEvent(ctx, &api.WatchEventRequest{
+			Table: &api.WatchEventRequest_Table{
+				Filters: []*api.WatchEventRequest_Table_Filter{
+					{
+						Type: watchFilterTypes[s.config.Watch.Filter],
+					},
 				},
 			},
-		},
-	}, func(r *api.WatchEventResponse) {
-		if table := r.GetTable(); table != nil {
-			for _, path := range table.Paths {
-				var update BGPUpdateMessage
-				update.FromPeer = path.GetNeighborIp()
-				update.Timestamp = path.GetAge().GetSeconds()
-				update.IsWithdraw = path.IsWithdraw
-
-				// Zero/empty initializations
-				update.NextHop = net.IP{}
-				update.Origin = nil
-				update.MED = nil
-				update.LocalPref = nil
-				update.AggregatorAS = nil
-				update.AggregatorAddress = nil
-				update.Communities = []uint32{}
-				update.CommunityStrings = []string{}
-				update.ExtendedCommunities = [][]byte{}
-				update.LargeCommunities = [][3]uint32{}
-				update.ASPath = [][]uint32{}
-				update.WithdrawnRoutes = []struct {
-					PrefixLength uint8
-					Prefix       net.IP
-				}{}
-				update.NLRI = []struct {
-					PrefixLength uint8
-					Prefix       net.IP
-				}{}
-				update.MPReachNLRI = struct {
-					AFI     uint16
-					SAFI    uint8
-					NextHop net.IP
-					NLRIs   []struct {
-						PrefixLength uint8
-						Prefix       net.IP
-					}
-				}{}
-				update.MPUnreachNLRI = struct {
-					AFI   uint16
-					SAFI  uint8
-					NLRIs []struct {
-						PrefixLength uint8
-						Prefix       net.IP
-					}
-				}{}
-
-				// Extract attributes
-				for _, attr := range path.GetPattrs() {
-					if nh := new(api.NextHopAttribute); attr.UnmarshalTo(nh) == nil {
-						update.NextHop = net.ParseIP(nh.NextHop)
-					}
-					if origin := new(api.OriginAttribute); attr.UnmarshalTo(origin) == nil {
-						u8 := uint8(origin.Origin)
-						update.Origin = &u8
-					}
-					if med := new(api.MultiExitDiscAttribute); attr.UnmarshalTo(med) == nil {
-						m := med.Med
-						update.MED = &m
-					}
-					if lp := new(api.LocalPrefAttribute); attr.UnmarshalTo(lp) == nil {
-						l := lp.LocalPref
-						update.LocalPref = &l
-					}
-					if agg := new(api.AggregatorAttribute); attr.UnmarshalTo(agg) == nil {
-						update.AggregatorAS = &agg.Asn
-						update.AggregatorAddress = net.ParseIP(agg.Address)
-					}
-					if comm := new(api.CommunitiesAttribute); attr.UnmarshalTo(comm) == nil {
-						update.Communities = comm.Communities
-						for _, c := range comm.Communities {
-							asn := c >> 16
-							local := c & 0xFFFF
-							update.CommunityStrings = append(update.CommunityStrings, fmt.Sprintf("%d:%d", asn, local))
-						}
-					}
-					if extComm := new(api.ExtendedCommunitiesAttribute); attr.UnmarshalTo(extComm) == nil {
-						for _, c := range extComm.Communities {
-							if c != nil {
-								update.ExtendedCommunities = append(update.ExtendedCommunities, c.Value)
-							}
-						}
-					}
-					if largeComm := new(api.LargeCommunitiesAttribute); attr.UnmarshalTo(largeComm) == nil {
-						for _, c := range largeComm.Communities {
-							update.LargeCommunities = append(update.LargeCommunities, [3]uint32{c.GlobalAdmin, c.LocalData1, c.LocalData2})
-						}
-					}
-					// Handle AS_PATH attribute
-					if asPath := new(api.AsPathAttribute); attr.UnmarshalTo(asPath) == nil {
-						for _, segment := range asPath.Segments {
-							update.ASPath = append(update.ASPath, segment.Numbers)
-						}
-					}
+		}, func(r *api.WatchEventResponse) {
+			if table := r.GetTable(); table != nil {
+				for _, path := range table.Paths {
+					s.dispatch(s.parsePath(path))
 				}
+			}
+		})
+		if err == nil {
+			// GoBGP keeps the watch running until ctx is cancelled
+			<-ctx.Done()
+			return
+		}
 
-				// Extract NLRI
-				var nlri api.IPAddressPrefix
-				if err := path.GetNlri().UnmarshalTo(&nlri); err == nil {
-					update.NLRI = append(update.NLRI, struct {
-						PrefixLength uint8
-						Prefix       net.IP
-					}{
-						PrefixLength: uint8(nlri.PrefixLen),
-						Prefix:       net.ParseIP(nlri.Prefix),
-					})
-				}
+		delay := retry.Next()
+		log.Printf("Error watching events: %v, retrying in %v\n", err, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
 
-				// RPKI validation state
-				switch path.GetValidation().GetState() {
-				case RpkiValid:
-					state := "valid"
-					update.RPKIValidationState = &state
-				case RpkiInvalid:
-					state := "invalid"
-					update.RPKIValidationState = &state
-				case RpkiNotFound:
-					state := "not-found"
-					update.RPKIValidationState = &state
-				}
+// AddUpdateHandler registers a handler that receives every parsed update
+// Handlers are invoked synchronously in registration order, so slow work
+// should be handed off to another goroutine by the handler itself
+func (s *BGPService) AddUpdateHandler(h UpdateHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, h)
+}
+
+// debugf logs a debug message when output.debug is set
+func (s *BGPService) debugf(format string, args ...any) {
+	if s.config.Output.Debug {
+		log.Printf("DEBUG "+format, args...)
+	}
+}
+
+// dispatch logs an update and hands it to every registered handler and subscriber
+// Both MonitorPrefixes and ReplayUpdates funnel through here
+func (s *BGPService) dispatch(update BGPUpdateMessage) {
+	if update.ASPathTooLong && s.config.BGP.DropLongASPaths {
+		// Logged on the first drop and then every 1000th, as a peer
+		// sending such paths usually sends many
+		if n := s.metrics.longASPaths.Add(1); n%1000 == 1 {
+			log.Printf("Dropping update from %s: AS path longer than %d, %d dropped so far", update.FromPeer, s.config.BGP.MaxASPathLength, n)
+		}
+		// A route the peer had sent with a shorter path is gone as far as
+		// the cache is concerned
+		withdrawn := update
+		withdrawn.IsWithdraw = true
+		s.checkPrefixThresholds(update.FromPeer, s.routes.apply(withdrawn))
+		return
+	}
+	// Numbered here rather than in parsePath so RIB listings, which also
+	// parse paths, leave no gaps in the emitted sequence
+	update.Sequence = s.sequence.Add(1)
+	s.metrics.updates.Add(1)
+	s.checkPrefixThresholds(update.FromPeer, s.routes.apply(update))
+	s.refreshAggregatesFor(update)
+
+	if update.ASLoop {
+		log.Printf("Warning: AS path received from %s contains our own ASN: %v", update.FromPeer, update.ASPath)
+	}
+	// Logging is throttled, handlers and subscribers still get every update
+	// Fields are selected on delivery, after subscriber filters have seen
+	// the whole update
+	if s.logRate.allow() {
+		if jsonBytes, err := json.MarshalIndent(selectFields(update, s.config.Output.Fields), "", "  "); err == nil {
+			log.Printf("BGP Update JSON:\n%s", string(jsonBytes))
+		} else {
+			log.Printf("Error marshalling update to JSON: %v", err)
+		}
+	}
+	s.deliver(update)
+}
+
+// attrTypeOTC is the Only to Customer path attribute type (RFC 9234)
+const attrTypeOTC = 35
+
+// parsePath converts a single GoBGP path into a BGPUpdateMessage
+// Only reads service state, so it can be exercised without a running server
+func (s *BGPService) parsePath(path *api.Path) BGPUpdateMessage {
+	s.mu.RLock()
+	localASN := s.localASN
+	s.mu.RUnlock()
+
+	var update BGPUpdateMessage
+	update.FromPeer = path.GetNeighborIp()
+	update.PathID = path.GetIdentifier()
+	session := s.sessionASNs(path)
+	update.EBGP = !isLocalPath(path) && session.isEBGP(localASN)
+	update.Timestamp = path.GetAge().GetSeconds()
+	update.ReceivedAt = time.Now()
+	update.IsWithdraw = path.IsWithdraw
 
-				if jsonBytes, err := json.MarshalIndent(update, "", "  "); err == nil {
-					log.Printf("BGP Update JSON:\n%s", string(jsonBytes))
+	// Zero/empty initializations
+	update.NextHop = net.IP{}
+	update.Origin = nil
+	update.MED = nil
+	update.LocalPref = nil
+	update.AggregatorAS = nil
+	update.AggregatorAddress = nil
+	update.Communities = []uint32{}
+	update.CommunityStrings = []string{}
+	update.ExtendedCommunities = [][]byte{}
+	update.LargeCommunities = [][3]uint32{}
+	update.ASPath = [][]uint32{}
+	update.WithdrawnRoutes = []struct {
+		PrefixLength uint8
+		Prefix       net.IP
+	}{}
+	update.NLRI = []struct {
+		PrefixLength uint8
+		Prefix       net.IP
+	}{}
+	update.MPReachNLRI = struct {
+		AFI              uint16
+		SAFI             uint8
+		NextHop          net.IP
+		NextHopGlobal    net.IP
+		NextHopLinkLocal net.IP
+		NLRIs            []struct {
+			PrefixLength uint8
+			Prefix       net.IP
+		}
+	}{}
+	update.MPUnreachNLRI = struct {
+		AFI   uint16
+		SAFI  uint8
+		NLRIs []struct {
+			PrefixLength uint8
+			Prefix       net.IP
+		}
+	}{}
+
+	// Extract attributes
+	// Each attribute is decoded once into its registered type and dispatched
+	// on that, rather than trial-unmarshalled into every type it might be
+	for _, attr := range path.GetPattrs() {
+		msg, err := attr.UnmarshalNew()
+		if err != nil {
+			update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("attribute %s: %v", attr.GetTypeUrl(), err))
+			continue
+		}
+		switch a := msg.(type) {
+		case *api.NextHopAttribute:
+			update.NextHop = parseIP(a.NextHop)
+		case *api.MpReachNLRIAttribute:
+			update.MPReachNLRI.AFI = uint16(a.GetFamily().GetAfi())
+			update.MPReachNLRI.SAFI = uint8(a.GetFamily().GetSafi())
+			// RFC 2545: an IPv6 next hop may be a global address followed by a link-local one
+			if len(a.NextHops) > 0 {
+				update.MPReachNLRI.NextHop = parseNextHop(a.NextHops[0])
+				update.MPReachNLRI.NextHopGlobal = update.MPReachNLRI.NextHop
+			}
+			if len(a.NextHops) > 1 {
+				update.MPReachNLRI.NextHopLinkLocal = parseNextHop(a.NextHops[1])
+			}
+			var nlri api.IPAddressPrefix
+			for _, nlriAny := range a.Nlris {
+				if !nlriAny.MessageIs(&nlri) {
+					continue // Other NLRI types are decoded from the path itself
+				}
+				if err := nlriAny.UnmarshalTo(&nlri); err != nil {
+					update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("mp-reach nlri: %v", err))
+					continue
+				}
+				prefix := parseIP(nlri.Prefix)
+				if prefix == nil {
+					update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("mp-reach nlri: invalid prefix %q", nlri.Prefix))
+					continue
+				}
+				update.MPReachNLRI.NLRIs = append(update.MPReachNLRI.NLRIs, struct {
+					PrefixLength uint8
+					Prefix       net.IP
+				}{PrefixLength: uint8(nlri.PrefixLen), Prefix: prefix})
+			}
+		case *api.OriginAttribute:
+			u8 := uint8(a.Origin)
+			update.Origin = &u8
+		case *api.MultiExitDiscAttribute:
+			update.MED = &a.Med // a is freshly decoded, so its fields can be shared
+		case *api.LocalPrefAttribute:
+			update.LocalPref = &a.LocalPref
+		case *api.AtomicAggregateAttribute:
+			update.AtomicAggregate = true
+		case *api.AggregatorAttribute:
+			update.AggregatorAS = &a.Asn
+			update.AggregatorAddress = parseIP(a.Address)
+		case *api.AigpAttribute:
+			var metric api.AigpTLVIGPMetric
+			for _, tlv := range a.Tlvs {
+				if tlv.MessageIs(&metric) && tlv.UnmarshalTo(&metric) == nil {
+					m := metric.Metric
+					update.AIGP = &m
+				}
+			}
+		case *api.OriginatorIdAttribute:
+			if id := parseIP(a.Id); id != nil {
+				update.OriginatorID = id
+			} else {
+				update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("originator-id: invalid address %q", a.Id))
+			}
+		case *api.ClusterListAttribute:
+			if len(a.Ids) > 0 {
+				update.ClusterList = make([]net.IP, 0, len(a.Ids))
+			}
+			for _, id := range a.Ids {
+				if ip := parseIP(id); ip != nil {
+					update.ClusterList = append(update.ClusterList, ip)
 				} else {
-					log.Printf("Error marshalling update to JSON: %v", err)
+					update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("cluster-list: invalid id %q", id))
 				}
 			}
+		case *api.CommunitiesAttribute:
+			update.Communities = a.Communities
+			update.CommunityStrings = make([]string, 0, len(a.Communities))
+			var buf []byte
+			for _, c := range a.Communities {
+				// ASN:local, built without fmt to spare an allocation per community
+				buf = strconv.AppendUint(buf[:0], uint64(c>>16), 10)
+				buf = append(buf, ':')
+				buf = strconv.AppendUint(buf, uint64(c&0xFFFF), 10)
+				update.CommunityStrings = append(update.CommunityStrings, string(buf))
+			}
+		case *api.ExtendedCommunitiesAttribute:
+			for _, c := range a.Communities {
+				if c != nil {
+					update.ExtendedCommunities = append(update.ExtendedCommunities, c.Value)
+				}
+			}
+		case *api.LargeCommunitiesAttribute:
+			for _, c := range a.Communities {
+				update.LargeCommunities = append(update.LargeCommunities, [3]uint32{c.GlobalAdmin, c.LocalData1, c.LocalData2})
+			}
+		case *api.PmsiTunnelAttribute:
+			update.PMSITunnel = parsePMSITunnel(a)
+		case *api.PrefixSID:
+			if psid, err := parsePrefixSID(a); err != nil {
+				update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("prefix-sid: %v", err))
+			} else {
+				update.PrefixSID = psid
+			}
+		case *api.TunnelEncapAttribute:
+			if tunnels, err := parseTunnelEncap(a); err != nil {
+				update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("tunnel-encap: %v", err))
+			} else {
+				update.TunnelEncap = tunnels
+			}
+		case *api.UnknownAttribute:
+			// GoBGP passes attributes it does not know through raw, OTC among them
+			if a.Type != attrTypeOTC {
+				update.UnknownAttributes = append(update.UnknownAttributes, struct {
+					Type  uint8
+					Flags uint8
+					Value []byte
+				}{Type: uint8(a.Type), Flags: uint8(a.Flags), Value: a.Value})
+				continue
+			}
+			if len(a.Value) != 4 {
+				update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("otc: length %d, want 4", len(a.Value)))
+				continue
+			}
+			otc := binary.BigEndian.Uint32(a.Value)
+			update.OnlyToCustomer = &otc
+		case *api.AsPathAttribute:
+			if limit := s.config.BGP.MaxASPathLength; limit > 0 && asPathLength(a.Segments) > limit {
+				update.ASPathTooLong = true
+			}
+			update.OriginAS = originAS(a.Segments)
+			update.ASPath = make([][]uint32, 0, len(a.Segments))
+			var own uint32
+			for _, segment := range a.Segments {
+				update.ASPath = append(update.ASPath, segment.Numbers)
+				for _, asn := range segment.Numbers {
+					if localASN != 0 && asn == localASN {
+						own++
+					}
+				}
+			}
+			// A path already carrying our ASN points at a loop or a leak,
+			// unless the peer is allowed to send it back, see AllowASIn
+			update.ASLoop = own > session.allowOwn
+		}
+	}
+
+	// Extract NLRI
+	// Anything that cannot be decoded is recorded in ParseErrors rather than
+	// appended as an empty entry, so consumers can tell data was dropped
+	if nlriAny := path.GetNlri(); nlriAny.MessageIs(&api.LabeledVPNIPAddressPrefix{}) {
+		// L3VPN routes carry a route distinguisher and label stack before the prefix
+		var vpn api.LabeledVPNIPAddressPrefix
+		if err := nlriAny.UnmarshalTo(&vpn); err != nil {
+			update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: %v", err))
+		} else {
+			rd, err := routeDistinguisher(vpn.Rd)
+			if err != nil {
+				update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: %v", err))
+			}
+			update.RouteDistinguisher = rd
+			update.Labels = vpn.Labels
+			update.appendNLRI(vpn.Prefix, vpn.PrefixLen)
+		}
+	} else if strings.HasPrefix(string(nlriAny.MessageName().Name()), "EVPN") {
+		// EVPN NLRI carries MACs and overlay prefixes rather than a plain
+		// prefix, so NLRI stays empty
+		if route, err := parseEVPN(nlriAny); err != nil {
+			update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: %v", err))
+		} else {
+			update.EVPN = route
+		}
+	} else if nlriAny.MessageIs(&api.FlowSpecNLRI{}) {
+		// Flowspec NLRI is a match rule rather than a prefix, so NLRI stays empty
+		var fs api.FlowSpecNLRI
+		if err := nlriAny.UnmarshalTo(&fs); err != nil {
+			update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: %v", err))
+		} else if rule, err := parseFlowSpec(&fs); err != nil {
+			update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: %v", err))
+		} else {
+			update.FlowSpec = rule
+		}
+	} else {
+		var nlri api.IPAddressPrefix
+		if err := nlriAny.UnmarshalTo(&nlri); err != nil {
+			update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: %v", err))
+		} else {
+			update.appendNLRI(nlri.Prefix, nlri.PrefixLen)
+		}
+	}
+
+	// RPKI validation state
+	// STATE_NONE means no validation was performed and leaves the field nil
+	switch path.GetValidation().GetState() {
+	case api.Validation_STATE_VALID:
+		state := "valid"
+		update.RPKIValidationState = &state
+	case api.Validation_STATE_INVALID:
+		state := "invalid"
+		update.RPKIValidationState = &state
+	case api.Validation_STATE_NOT_FOUND:
+		state := "not-found"
+		update.RPKIValidationState = &state
+	}
+
+	return update
+}
+
+// AS_PATH segment types (RFC 4271, RFC 5065)
+const (
+	asSet      = 1
+	asSequence = 2
+)
+
+// asPathLength is the path length used in best-path selection: every
+// AS_SEQUENCE member counts, an AS_SET counts once and confederation
+// segments do not count at all
+func asPathLength(segments []*api.AsSegment) int {
+	length := 0
+	for _, segment := range segments {
+		switch segment.Type {
+		case asSequence:
+			length += len(segment.Numbers)
+		case asSet:
+			length++
 		}
+	}
+	return length
+}
+
+// originAS returns the AS that originated a path, the last one in its final
+// AS_SEQUENCE; an AS_SET there, left by aggregation, has no single origin
+func originAS(segments []*api.AsSegment) uint32 {
+	if len(segments) == 0 {
+		return 0
+	}
+	last := segments[len(segments)-1]
+	if last.Type != asSequence || len(last.Numbers) == 0 {
+		return 0
+	}
+	return last.Numbers[len(last.Numbers)-1]
+}
+
+// parseIP parses an address into its canonical form: 4 bytes for IPv4,
+// including IPv4-mapped IPv6 input, and 16 bytes for IPv6
+// net.ParseIP always returns 16 bytes, so without this the same IPv4 address
+// could be held in two forms and compare unequal with bytes.Equal
+func parseIP(addr string) net.IP {
+	ip := net.ParseIP(addr)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// parseNextHop parses a next hop address, ignoring any "%zone" suffix that a
+// link-local address may carry, since net.IP cannot hold it
+func parseNextHop(addr string) net.IP {
+	addr, _, _ = strings.Cut(addr, "%")
+	return parseIP(addr)
+}
+
+// appendNLRI adds prefix/length to the update, or a parse error if prefix is not an address
+func (update *BGPUpdateMessage) appendNLRI(prefix string, length uint32) {
+	ip := parseIP(prefix)
+	if ip == nil {
+		update.ParseErrors = append(update.ParseErrors, fmt.Sprintf("nlri: invalid prefix %q", prefix))
+		return
+	}
+	update.NLRI = append(update.NLRI, struct {
+		PrefixLength uint8
+		Prefix       net.IP
+	}{
+		PrefixLength: uint8(length),
+		Prefix:       ip,
 	})
+}
 
+// routeDistinguisher formats a GoBGP route distinguisher as "admin:assigned"
+func routeDistinguisher(rd *anypb.Any) (string, error) {
+	msg, err := rd.UnmarshalNew()
 	if err != nil {
-		log.Printf("Error watching events: %v\n", err)
+		return "", fmt.Errorf("route distinguisher: %w", err)
+	}
+	switch v := msg.(type) {
+	case *api.RouteDistinguisherTwoOctetASN:
+		return fmt.Sprintf("%d:%d", v.Admin, v.Assigned), nil
+	case *api.RouteDistinguisherIPAddress:
+		return fmt.Sprintf("%s:%d", v.Admin, v.Assigned), nil
+	case *api.RouteDistinguisherFourOctetASN:
+		return fmt.Sprintf("%d:%d", v.Admin, v.Assigned), nil
 	}
+	return "", fmt.Errorf("route distinguisher: unsupported type %T", msg)
 }
 
 // Stop gracefully shuts down the BGP server
 // Uses pointer receiver to modify server state
 func (s *BGPService) Stop() {
+	s.mu.Lock()
+	if s.runCancel != nil {
+		s.runCancel() // Releases MonitorPrefixes and the watch goroutine
+	}
+	s.watchCancel = nil
+	s.mu.Unlock()
+	s.peerStates.stop()
+
 	s.server.Stop() // Calls Stop on the server pointer
 }
@@ -0,0 +1,989 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBuildPeerAddPaths verifies ADD-PATH settings reach every AfiSafi of the built peer
+func TestBuildPeerAddPaths(t *testing.T) {
+	peer, err := buildPeer(NeighborConfig{
+		PeerIP: "192.0.2.1",
+		ASN:    65002,
+		NeighborTemplate: NeighborTemplate{
+			AddPaths: AddPathsConfig{Receive: true, SendMax: 4},
+		},
+	})
+	if err != nil {
+		t.Fatalf("buildPeer() error = %v", err)
+	}
+
+	for _, afiSafi := range peer.AfiSafis {
+		addPaths := afiSafi.GetAddPaths().GetConfig()
+		if addPaths == nil {
+			t.Fatalf("AfiSafi %v has no add-paths config", afiSafi.GetConfig().GetFamily())
+		}
+		if !addPaths.Receive || addPaths.SendMax != 4 {
+			t.Errorf("add-paths config = %+v, want receive=true sendMax=4", addPaths)
+		}
+	}
+}
+
+// TestBuildPeerAddPathsDisabled verifies ADD-PATH is left out unless configured
+func TestBuildPeerAddPathsDisabled(t *testing.T) {
+	peer, err := buildPeer(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002})
+	if err != nil {
+		t.Fatalf("buildPeer() error = %v", err)
+	}
+
+	for _, afiSafi := range peer.AfiSafis {
+		if afiSafi.GetAddPaths() != nil {
+			t.Errorf("AfiSafi %v unexpectedly has add-paths config", afiSafi.GetConfig().GetFamily())
+		}
+	}
+}
+
+// mustAny wraps a GoBGP API message in an Any as found on api.Path
+func mustAny(t testing.TB, m proto.Message) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatalf("anypb.New(%T) error = %v", m, err)
+	}
+	return a
+}
+
+// TestParsePathMalformedNLRI verifies unparseable NLRIs are flagged instead of appended
+func TestParsePathMalformedNLRI(t *testing.T) {
+	tests := []struct {
+		name string
+		nlri proto.Message
+	}{
+		{
+			name: "Wrong NLRI type",
+			nlri: &api.OriginAttribute{Origin: 0},
+		},
+		{
+			name: "Empty prefix",
+			nlri: &api.IPAddressPrefix{PrefixLen: 24, Prefix: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := NewBGPService().parsePath(&api.Path{Nlri: mustAny(t, tt.nlri)})
+			if len(update.NLRI) != 0 {
+				t.Errorf("NLRI = %v, want no entries", update.NLRI)
+			}
+			if len(update.ParseErrors) != 1 {
+				t.Errorf("ParseErrors = %v, want exactly one error", update.ParseErrors)
+			}
+		})
+	}
+}
+
+// TestParsePathUndecodableAttribute verifies an attribute that cannot be
+// decoded is recorded in ParseErrors while the rest of the path is parsed
+func TestParsePathUndecodableAttribute(t *testing.T) {
+	update := NewBGPService().parsePath(&api.Path{
+		Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{
+			{TypeUrl: "type.googleapis.com/apipb.NoSuchAttribute"},
+			mustAny(t, &api.OriginAttribute{Origin: 2}),
+		},
+	})
+	if len(update.ParseErrors) != 1 || !strings.Contains(update.ParseErrors[0], "apipb.NoSuchAttribute") {
+		t.Errorf("ParseErrors = %v, want one error naming the attribute", update.ParseErrors)
+	}
+	if update.Origin == nil || *update.Origin != 2 {
+		t.Errorf("Origin = %v, want the following attribute still parsed", update.Origin)
+	}
+}
+
+// TestParsePathValidNLRI verifies a well-formed path produces no parse errors
+func TestParsePathValidNLRI(t *testing.T) {
+	update := NewBGPService().parsePath(&api.Path{Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"})})
+	if len(update.ParseErrors) != 0 {
+		t.Errorf("ParseErrors = %v, want none", update.ParseErrors)
+	}
+	if len(update.NLRI) != 1 || update.NLRI[0].PrefixLength != 24 {
+		t.Errorf("NLRI = %v, want 10.0.0.0/24", update.NLRI)
+	}
+}
+
+// newTestService starts a BGP service that does not listen on TCP 179,
+// so tests can run alongside each other and without privileges
+func newTestService(t *testing.T, config *Config) *BGPService {
+	t.Helper()
+	bgpService := NewBGPServiceWithConfig(config)
+	bgpService.listenPort = -1
+	if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Failed to start BGP service: %v", err)
+	}
+	t.Cleanup(bgpService.Stop)
+	return bgpService
+}
+
+// TestParsePathReceivedAt verifies parsing stamps the wall-clock receipt time
+func TestParsePathReceivedAt(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"})}
+
+	got := bgpService.parsePath(path).ReceivedAt
+	if d := time.Since(got); d < 0 || d > time.Second {
+		t.Errorf("ReceivedAt = %v, want within a second of now", got)
+	}
+}
+
+// TestParsePathASLoop verifies the ASLoop flag tracks the local ASN in the AS path
+func TestParsePathASLoop(t *testing.T) {
+	bgpService := NewBGPService()
+	bgpService.localASN = 65001
+
+	tests := []struct {
+		name     string
+		segments []*api.AsSegment
+		want     bool
+	}{
+		{
+			name:     "Local ASN in path",
+			segments: []*api.AsSegment{{Type: 2, Numbers: []uint32{65002, 65001, 65010}}},
+			want:     true,
+		},
+		{
+			name:     "Local ASN absent",
+			segments: []*api.AsSegment{{Type: 2, Numbers: []uint32{65002, 65010}}},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := &api.Path{
+				Nlri:   mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+				Pattrs: []*anypb.Any{mustAny(t, &api.AsPathAttribute{Segments: tt.segments})},
+			}
+			if got := bgpService.parsePath(path).ASLoop; got != tt.want {
+				t.Errorf("ASLoop = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParsePathRouteReflection verifies ORIGINATOR_ID and CLUSTER_LIST are decoded
+func TestParsePathRouteReflection(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.OriginatorIdAttribute{Id: "192.0.2.10"}),
+			mustAny(t, &api.ClusterListAttribute{Ids: []string{"192.0.2.1", "192.0.2.2"}}),
+		},
+	}
+
+	update := bgpService.parsePath(path)
+	if !update.OriginatorID.Equal(net.ParseIP("192.0.2.10")) {
+		t.Errorf("OriginatorID = %v, want 192.0.2.10", update.OriginatorID)
+	}
+	want := []string{"192.0.2.1", "192.0.2.2"}
+	if len(update.ClusterList) != len(want) {
+		t.Fatalf("ClusterList = %v, want %v", update.ClusterList, want)
+	}
+	for i, id := range update.ClusterList {
+		if id.String() != want[i] {
+			t.Errorf("ClusterList[%d] = %v, want %s", i, id, want[i])
+		}
+	}
+}
+
+// TestParsePathInvalidClusterID verifies a CLUSTER_LIST id that does not
+// parse is left out and recorded in ParseErrors
+func TestParsePathInvalidClusterID(t *testing.T) {
+	path := &api.Path{
+		Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.ClusterListAttribute{Ids: []string{"192.0.2.1", "not-an-id"}}),
+		},
+	}
+
+	update := NewBGPService().parsePath(path)
+	if len(update.ClusterList) != 1 || update.ClusterList[0].String() != "192.0.2.1" {
+		t.Errorf("ClusterList = %v, want [192.0.2.1]", update.ClusterList)
+	}
+	if len(update.ParseErrors) != 1 || !strings.Contains(update.ParseErrors[0], "not-an-id") {
+		t.Errorf("ParseErrors = %v, want the invalid cluster id", update.ParseErrors)
+	}
+}
+
+// TestParsePathAIGP verifies the IGP metric TLV of an AIGP attribute is extracted
+func TestParsePathAIGP(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.AigpAttribute{Tlvs: []*anypb.Any{mustAny(t, &api.AigpTLVIGPMetric{Metric: 1500})}}),
+		},
+	}
+
+	if got := bgpService.parsePath(path).AIGP; got == nil || *got != 1500 {
+		t.Errorf("AIGP = %v, want 1500", got)
+	}
+}
+
+// TestParsePathVPNv4 verifies the route distinguisher, labels and prefix of a VPNv4 path
+func TestParsePathVPNv4(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri: mustAny(t, &api.LabeledVPNIPAddressPrefix{
+			Labels:    []uint32{16000},
+			Rd:        mustAny(t, &api.RouteDistinguisherTwoOctetASN{Admin: 65000, Assigned: 100}),
+			PrefixLen: 24,
+			Prefix:    "10.1.0.0",
+		}),
+	}
+
+	update := bgpService.parsePath(path)
+	if len(update.ParseErrors) != 0 {
+		t.Fatalf("ParseErrors = %v, want none", update.ParseErrors)
+	}
+	if update.RouteDistinguisher != "65000:100" {
+		t.Errorf("RouteDistinguisher = %q, want 65000:100", update.RouteDistinguisher)
+	}
+	if len(update.Labels) != 1 || update.Labels[0] != 16000 {
+		t.Errorf("Labels = %v, want [16000]", update.Labels)
+	}
+	if len(update.NLRI) != 1 || update.NLRI[0].Prefix.String() != "10.1.0.0" || update.NLRI[0].PrefixLength != 24 {
+		t.Errorf("NLRI = %v, want 10.1.0.0/24", update.NLRI)
+	}
+}
+
+// TestParsePathLinkLocalNextHop verifies both IPv6 next hops of MP_REACH_NLRI are kept
+func TestParsePathLinkLocalNextHop(t *testing.T) {
+	bgpService := NewBGPService()
+	nlri := mustAny(t, &api.IPAddressPrefix{PrefixLen: 32, Prefix: "2001:db8::"})
+	path := &api.Path{
+		Nlri: nlri,
+		Pattrs: []*anypb.Any{mustAny(t, &api.MpReachNLRIAttribute{
+			Family:   &api.Family{Afi: api.Family_AFI_IP6, Safi: api.Family_SAFI_UNICAST},
+			NextHops: []string{"2001:db8::1", "fe80::1"},
+			Nlris:    []*anypb.Any{nlri},
+		})},
+	}
+
+	mpReach := bgpService.parsePath(path).MPReachNLRI
+	if !mpReach.NextHopGlobal.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("NextHopGlobal = %v, want 2001:db8::1", mpReach.NextHopGlobal)
+	}
+	if !mpReach.NextHopLinkLocal.Equal(net.ParseIP("fe80::1")) {
+		t.Errorf("NextHopLinkLocal = %v, want fe80::1", mpReach.NextHopLinkLocal)
+	}
+	if mpReach.AFI != uint16(api.Family_AFI_IP6) || len(mpReach.NLRIs) != 1 {
+		t.Errorf("MPReachNLRI = %+v, want AFI 2 with one NLRI", mpReach)
+	}
+}
+
+// TestParsePathRPKIState verifies each gobgp validation state maps to the right label
+func TestParsePathRPKIState(t *testing.T) {
+	bgpService := NewBGPService()
+
+	tests := []struct {
+		name  string
+		state api.Validation_State
+		want  string // "" means RPKIValidationState stays nil
+	}{
+		{name: "None", state: api.Validation_STATE_NONE, want: ""},
+		{name: "Not found", state: api.Validation_STATE_NOT_FOUND, want: "not-found"},
+		{name: "Valid", state: api.Validation_STATE_VALID, want: "valid"},
+		{name: "Invalid", state: api.Validation_STATE_INVALID, want: "invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := &api.Path{
+				Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+				Validation: &api.Validation{State: tt.state},
+			}
+			got := bgpService.parsePath(path).RPKIValidationState
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("RPKIValidationState = %q, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tt.want {
+				t.Errorf("RPKIValidationState = %v, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParsePathASPathTooLong verifies paths over bgp.maxASPathLength are flagged
+func TestParsePathASPathTooLong(t *testing.T) {
+	config := &Config{}
+	config.BGP.MaxASPathLength = 50
+	bgpService := NewBGPServiceWithConfig(config)
+
+	tests := []struct {
+		name     string
+		segments []*api.AsSegment
+		want     bool
+	}{
+		{
+			name:     "60 AS sequence",
+			segments: []*api.AsSegment{{Type: asSequence, Numbers: make([]uint32, 60)}},
+			want:     true,
+		},
+		{
+			name:     "50 AS sequence",
+			segments: []*api.AsSegment{{Type: asSequence, Numbers: make([]uint32, 50)}},
+			want:     false,
+		},
+		{
+			name: "AS set counts once",
+			segments: []*api.AsSegment{
+				{Type: asSequence, Numbers: make([]uint32, 49)},
+				{Type: asSet, Numbers: make([]uint32, 20)},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := &api.Path{
+				Nlri:   mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+				Pattrs: []*anypb.Any{mustAny(t, &api.AsPathAttribute{Segments: tt.segments})},
+			}
+			if got := bgpService.parsePath(path).ASPathTooLong; got != tt.want {
+				t.Errorf("ASPathTooLong = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDispatchDropsLongASPaths verifies flagged updates are dropped and
+// counted when configured, replacing the peer's cached route as a withdrawal
+func TestDispatchDropsLongASPaths(t *testing.T) {
+	config := &Config{}
+	config.BGP.MaxASPathLength = 50
+	config.BGP.DropLongASPaths = true
+	bgpService := NewBGPServiceWithConfig(config)
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.0.0/24", false))
+	updates, unsubscribe := bgpService.Subscribe()
+	defer unsubscribe()
+
+	long := testUpdate("192.0.2.1", "10.0.0.0/24", false)
+	long.ASPathTooLong = true
+	bgpService.dispatch(long)
+	bgpService.dispatch(testUpdate("192.0.2.2", "10.0.1.0/24", false))
+
+	if got := <-updates; got.FromPeer != "192.0.2.2" || len(updates) != 0 {
+		t.Errorf("received update from %s with %d queued, want only the short path", got.FromPeer, len(updates))
+	}
+	if got, _ := bgpService.LookupReceived("10.0.0.0/24", false); len(got) != 0 {
+		t.Errorf("cached routes for 10.0.0.0/24 = %+v, want the dropped route withdrawn", got)
+	}
+	if got := bgpService.Stats().LongASPaths; got != 1 {
+		t.Errorf("Stats().LongASPaths = %d, want 1", got)
+	}
+}
+
+// TestBuildPeerLocalAS verifies the per-neighbor local ASN reaches the peer config
+func TestBuildPeerLocalAS(t *testing.T) {
+	tests := []struct {
+		name    string
+		localAS int
+	}{
+		{name: "Local AS override", localAS: 64512},
+		{name: "Global ASN", localAS: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peer, err := buildPeer(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002, LocalAS: tt.localAS})
+			if err != nil {
+				t.Fatalf("buildPeer() error = %v", err)
+			}
+			if got := peer.GetConf().GetLocalAsn(); got != uint32(tt.localAS) {
+				t.Errorf("LocalAsn = %d, want %d", got, tt.localAS)
+			}
+		})
+	}
+}
+
+// TestBuildPeerPort verifies peerPort reaches the transport, defaulting to 179
+func TestBuildPeerPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		peerPort uint16
+		want     uint32
+	}{
+		{name: "Mapped port", peerPort: 1179, want: 1179},
+		{name: "Default", peerPort: 0, want: 179},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peer, err := buildPeer(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002, PeerPort: tt.peerPort})
+			if err != nil {
+				t.Fatalf("buildPeer() error = %v", err)
+			}
+			if got := peer.GetTransport().GetRemotePort(); got != tt.want {
+				t.Errorf("RemotePort = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOriginAS verifies the origin is the last AS of a trailing AS_SEQUENCE only
+func TestOriginAS(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []*api.AsSegment
+		want     uint32
+	}{
+		{name: "Empty", want: 0},
+		{name: "Sequence", segments: []*api.AsSegment{{Type: asSequence, Numbers: []uint32{65100, 64501}}}, want: 64501},
+		{name: "Trailing set", segments: []*api.AsSegment{
+			{Type: asSequence, Numbers: []uint32{65100}},
+			{Type: asSet, Numbers: []uint32{64501, 64502}},
+		}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originAS(tt.segments); got != tt.want {
+				t.Errorf("originAS() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// updateGolden rewrites golden files from the current output instead of comparing
+var updateGolden = flag.Bool("update", false, "rewrite testdata golden files")
+
+// fullPath returns a path carrying every attribute parsePath decodes,
+// representative of a full-table IPv4 route seen through a route reflector
+func fullPath(t testing.TB) *api.Path {
+	return &api.Path{
+		NeighborIp: "192.0.2.1",
+		Age:        &timestamppb.Timestamp{Seconds: 1700000000},
+		Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "198.51.100.0"}),
+		Validation: &api.Validation{State: api.Validation_STATE_VALID},
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.OriginAttribute{Origin: 0}),
+			mustAny(t, &api.AsPathAttribute{Segments: []*api.AsSegment{
+				{Type: asSequence, Numbers: []uint32{65002, 3356, 174, 64501}},
+				{Type: asSet, Numbers: []uint32{64510, 64511}},
+			}}),
+			mustAny(t, &api.NextHopAttribute{NextHop: "192.0.2.1"}),
+			mustAny(t, &api.MpReachNLRIAttribute{
+				Family:   &api.Family{Afi: api.Family_AFI_IP6, Safi: api.Family_SAFI_UNICAST},
+				NextHops: []string{"2001:db8::1", "fe80::1%eth0"},
+				Nlris:    []*anypb.Any{mustAny(t, &api.IPAddressPrefix{PrefixLen: 48, Prefix: "2001:db8:100::"})},
+			}),
+			mustAny(t, &api.MultiExitDiscAttribute{Med: 50}),
+			mustAny(t, &api.LocalPrefAttribute{LocalPref: 200}),
+			mustAny(t, &api.AtomicAggregateAttribute{}),
+			mustAny(t, &api.AggregatorAttribute{Asn: 64501, Address: "203.0.113.1"}),
+			mustAny(t, &api.AigpAttribute{Tlvs: []*anypb.Any{mustAny(t, &api.AigpTLVIGPMetric{Metric: 1500})}}),
+			mustAny(t, &api.OriginatorIdAttribute{Id: "192.0.2.10"}),
+			mustAny(t, &api.ClusterListAttribute{Ids: []string{"192.0.2.20", "192.0.2.21"}}),
+			mustAny(t, &api.CommunitiesAttribute{Communities: []uint32{65002<<16 | 100, 65002<<16 | 200, 0xFFFFFF01}}),
+			mustAny(t, &api.ExtendedCommunitiesAttribute{Communities: []*anypb.Any{
+				mustAny(t, &api.TwoOctetAsSpecificExtended{IsTransitive: true, SubType: 2, Asn: 65002, LocalAdmin: 100}),
+			}}),
+			mustAny(t, &api.LargeCommunitiesAttribute{Communities: []*api.LargeCommunity{
+				{GlobalAdmin: 65002, LocalData1: 1, LocalData2: 2},
+			}}),
+			mustAny(t, &api.UnknownAttribute{Flags: 0xC0, Type: attrTypeOTC, Value: []byte{0, 0, 0xFD, 0xEA}}),
+			mustAny(t, &api.UnknownAttribute{Flags: 0xC0, Type: 99, Value: []byte{1, 2, 3}}),
+			mustAny(t, testPrefixSID(t)),
+			mustAny(t, testTunnelEncap(t)),
+		},
+	}
+}
+
+// TestParsePathGolden verifies the decoding of a fully populated path against
+// testdata, so parser optimizations can be shown not to change the output
+// Run with -update to accept an intended change
+func TestParsePathGolden(t *testing.T) {
+	bgpService := NewBGPService()
+	bgpService.localASN = 65001
+
+	update := bgpService.parsePath(fullPath(t))
+	update.ReceivedAt = time.Time{}
+	got, err := json.MarshalIndent(update, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "parse_path_full.json")
+	if *updateGolden {
+		if err := os.WriteFile(golden, append(got, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(append(got, '\n'), want) {
+		t.Errorf("parsePath output differs from %s:\n%s", golden, got)
+	}
+}
+
+func BenchmarkParsePath(b *testing.B) {
+	bgpService := NewBGPService()
+	bgpService.localASN = 65001
+	path := fullPath(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bgpService.parsePath(path)
+	}
+}
+
+// TestParsePathEVPN verifies the MAC, IP and RD of an EVPN MAC/IP advertisement are decoded
+func TestParsePathEVPN(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Family: &api.Family{Afi: api.Family_AFI_L2VPN, Safi: api.Family_SAFI_EVPN},
+		Nlri: mustAny(t, &api.EVPNMACIPAdvertisementRoute{
+			Rd:          mustAny(t, &api.RouteDistinguisherTwoOctetASN{Admin: 65000, Assigned: 100}),
+			EthernetTag: 10,
+			MacAddress:  "aa:bb:cc:dd:ee:ff",
+			IpAddress:   "10.1.1.10",
+			Labels:      []uint32{10010},
+		}),
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.PmsiTunnelAttribute{Type: 6, Label: 10010, Id: net.ParseIP("192.0.2.1").To4()}),
+		},
+	}
+
+	update := bgpService.parsePath(path)
+	if len(update.ParseErrors) > 0 {
+		t.Fatalf("ParseErrors = %v", update.ParseErrors)
+	}
+	evpn := update.EVPN
+	if evpn == nil {
+		t.Fatal("EVPN = nil, want a decoded route")
+	}
+	if evpn.RouteType != 2 || evpn.MAC != "aa:bb:cc:dd:ee:ff" || evpn.RouteDistinguisher != "65000:100" {
+		t.Errorf("EVPN = %+v, want type 2 aa:bb:cc:dd:ee:ff in 65000:100", evpn)
+	}
+	if !evpn.IP.Equal(net.ParseIP("10.1.1.10")) || evpn.EthernetTag != 10 {
+		t.Errorf("EVPN IP = %v, tag = %d, want 10.1.1.10 tag 10", evpn.IP, evpn.EthernetTag)
+	}
+	if len(update.NLRI) != 0 {
+		t.Errorf("NLRI = %v, want none for EVPN", update.NLRI)
+	}
+	if tunnel := update.PMSITunnel; tunnel == nil || tunnel.TunnelID != "192.0.2.1" || tunnel.Label != 10010 {
+		t.Errorf("PMSITunnel = %+v, want ingress replication to 192.0.2.1 label 10010", tunnel)
+	}
+}
+
+// TestUpdateGlobal verifies a new router ID and ASN apply on the next Start
+// and are refused while the service is running
+func TestUpdateGlobal(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+
+	if err := bgpService.UpdateGlobal("192.0.2.20", 65020); !errors.Is(err, ErrServiceRunning) {
+		t.Fatalf("UpdateGlobal while running error = %v, want ErrServiceRunning", err)
+	}
+
+	bgpService.Stop()
+	for _, bad := range []struct {
+		routerID string
+		asn      uint32
+	}{{"192.0.2.20", 0}, {"not-an-ip", 65020}, {"2001:db8::1", 65020}} {
+		if err := bgpService.UpdateGlobal(bad.routerID, bad.asn); err == nil {
+			t.Errorf("UpdateGlobal(%q, %d) succeeded, want an error", bad.routerID, bad.asn)
+		}
+	}
+	if err := bgpService.UpdateGlobal("192.0.2.20", 65020); err != nil {
+		t.Fatalf("UpdateGlobal while stopped error = %v", err)
+	}
+
+	if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Start error = %v", err)
+	}
+	global := fake.started.GetGlobal()
+	if global.GetRouterId() != "192.0.2.20" || global.GetAsn() != 65020 {
+		t.Errorf("StartBgp global = %s AS%d, want 192.0.2.20 AS65020", global.GetRouterId(), global.GetAsn())
+	}
+	if got := bgpService.RunningConfig().BGP.Local; got.RouterID != "192.0.2.20" || got.ASN != 65020 {
+		t.Errorf("running config local = %+v, want 192.0.2.20 AS65020", got)
+	}
+}
+
+// TestParsePathOTC verifies the Only to Customer attribute is decoded and a
+// malformed one reported rather than misread
+func TestParsePathOTC(t *testing.T) {
+	bgpService := NewBGPService()
+	path := func(value []byte) *api.Path {
+		return &api.Path{
+			Nlri:   mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+			Pattrs: []*anypb.Any{mustAny(t, &api.UnknownAttribute{Flags: 0xC0, Type: attrTypeOTC, Value: value})},
+		}
+	}
+
+	update := bgpService.parsePath(path([]byte{0, 0, 0xFD, 0xEA}))
+	if update.OnlyToCustomer == nil || *update.OnlyToCustomer != 65002 {
+		t.Errorf("OnlyToCustomer = %v, want 65002", update.OnlyToCustomer)
+	}
+
+	update = bgpService.parsePath(path([]byte{0, 0xFD, 0xEA}))
+	if update.OnlyToCustomer != nil || len(update.ParseErrors) != 1 {
+		t.Errorf("short OTC: OnlyToCustomer = %v, ParseErrors = %v, want nil and one error", update.OnlyToCustomer, update.ParseErrors)
+	}
+}
+
+// TestParsePathUnknownAttributes verifies attributes GoBGP cannot decode
+// are kept raw, while OTC is still decoded rather than listed
+func TestParsePathUnknownAttributes(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.UnknownAttribute{Flags: 0xC0, Type: 99, Value: []byte{0xDE, 0xAD}}),
+			mustAny(t, &api.UnknownAttribute{Flags: 0xC0, Type: attrTypeOTC, Value: []byte{0, 0, 0xFD, 0xEA}}),
+		},
+	}
+
+	update := bgpService.parsePath(path)
+	if len(update.UnknownAttributes) != 1 {
+		t.Fatalf("UnknownAttributes = %v, want one attribute", update.UnknownAttributes)
+	}
+	got := update.UnknownAttributes[0]
+	if got.Type != 99 || got.Flags != 0xC0 || !bytes.Equal(got.Value, []byte{0xDE, 0xAD}) {
+		t.Errorf("UnknownAttributes[0] = %+v, want type 99, flags 0xC0, value dead", got)
+	}
+	if update.OnlyToCustomer == nil {
+		t.Error("OTC was not decoded")
+	}
+}
+
+// TestParsePathCanonicalIP verifies IPv4 addresses, including IPv4-mapped
+// IPv6 input, are held as 4 bytes and serialize as dotted quads
+func TestParsePathCanonicalIP(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "::ffff:10.0.0.0"}),
+		Pattrs: []*anypb.Any{
+			mustAny(t, &api.NextHopAttribute{NextHop: "192.0.2.1"}),
+			mustAny(t, &api.OriginatorIdAttribute{Id: "::ffff:192.0.2.10"}),
+		},
+	}
+
+	update := bgpService.parsePath(path)
+	for name, ip := range map[string]net.IP{
+		"NLRI":         update.NLRI[0].Prefix,
+		"NextHop":      update.NextHop,
+		"OriginatorID": update.OriginatorID,
+	} {
+		if len(ip) != net.IPv4len {
+			t.Errorf("%s is %d bytes, want %d", name, len(ip), net.IPv4len)
+		}
+	}
+	data, err := json.Marshal(update.NLRI[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"PrefixLength":24,"Prefix":"10.0.0.0"}`; string(data) != want {
+		t.Errorf("NLRI JSON = %s, want %s", data, want)
+	}
+
+	v6 := bgpService.parsePath(&api.Path{Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 32, Prefix: "2001:db8::"})})
+	if len(v6.NLRI[0].Prefix) != net.IPv6len {
+		t.Errorf("IPv6 NLRI is %d bytes, want %d", len(v6.NLRI[0].Prefix), net.IPv6len)
+	}
+}
+
+// TestWatchFilter verifies watch.filter selects the table the route watch requests
+func TestWatchFilter(t *testing.T) {
+	tests := []struct {
+		filter WatchFilter
+		want   api.WatchEventRequest_Table_Filter_Type
+	}{
+		{filter: "", want: api.WatchEventRequest_Table_Filter_ADJIN},
+		{filter: WatchAdjIn, want: api.WatchEventRequest_Table_Filter_ADJIN},
+		{filter: WatchBest, want: api.WatchEventRequest_Table_Filter_BEST},
+		{filter: WatchPostPolicy, want: api.WatchEventRequest_Table_Filter_POST_POLICY},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.filter), func(t *testing.T) {
+			config := &Config{}
+			config.Watch.Filter = tt.filter
+			bgpService, fake := newFakeService(t, config)
+			_, unsubscribe := bgpService.Subscribe()
+			defer unsubscribe()
+
+			fake.waitForWatch(t)
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			filters := fake.tableWatches[0].GetTable().GetFilters()
+			if len(filters) != 1 || filters[0].GetType() != tt.want {
+				t.Errorf("watch filters = %v, want %v", filters, tt.want)
+			}
+		})
+	}
+
+	config := &Config{}
+	config.Watch.Filter = "pre-policy"
+	if err := NewBGPServiceWithServer(config, newFakeBgpServer()).Start("192.0.2.254", 65001); err == nil {
+		t.Error("Start() accepted an unknown watch filter")
+	}
+}
+
+// TestUpdateSequence verifies emitted updates are numbered one apart and
+// that the numbering carries on when the watch is re-established
+func TestUpdateSequence(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	path := &api.Path{
+		Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		NeighborIp: "192.0.2.1",
+	}
+	receive := func(updates <-chan BGPUpdateMessage) uint64 {
+		t.Helper()
+		select {
+		case update := <-updates:
+			return update.Sequence
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for the update")
+			return 0
+		}
+	}
+
+	updates, unsubscribe := bgpService.Subscribe()
+	fake.waitForWatch(t)
+	fake.emit(path, path)
+	if first, second := receive(updates), receive(updates); first != 1 || second != 2 {
+		t.Errorf("sequences = %d, %d, want 1, 2", first, second)
+	}
+	unsubscribe()
+
+	// The last subscriber leaving stops the watch; a new one starts it again
+	fake.mu.Lock()
+	fake.watchers = nil
+	fake.mu.Unlock()
+	updates, unsubscribe = bgpService.Subscribe()
+	defer unsubscribe()
+	fake.waitForWatch(t)
+	fake.emit(path)
+	if got := receive(updates); got != 3 {
+		t.Errorf("sequence after reconnect = %d, want 3", got)
+	}
+}
+
+// TestParsePathEBGP verifies routes are labelled eBGP or iBGP from the
+// configured ASN of the peer they came from
+func TestParsePathEBGP(t *testing.T) {
+	bgpService, _ := newFakeService(t, &Config{})
+	for _, n := range []NeighborConfig{
+		{PeerIP: "192.0.2.1", ASN: 65002},                 // eBGP
+		{PeerIP: "192.0.2.2", ASN: 65001},                 // iBGP
+		{PeerIP: "192.0.2.3", ASN: 65100, LocalAS: 65100}, // iBGP under a local-as override
+	} {
+		if err := bgpService.AddNeighborConfig(n); err != nil {
+			t.Fatalf("AddNeighborConfig(%s) error = %v", n.PeerIP, err)
+		}
+	}
+
+	tests := []struct {
+		neighbor  string
+		sourceASN uint32
+		want      bool
+	}{
+		{"192.0.2.1", 65002, true},
+		{"192.0.2.2", 65001, false},
+		{"192.0.2.3", 65100, false},
+		{"198.51.100.1", 65003, true}, // Unconfigured, e.g. a dynamic neighbor
+		{"<nil>", 0, false},           // Originated locally
+	}
+	for _, tt := range tests {
+		path := &api.Path{
+			Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+			NeighborIp: tt.neighbor,
+			SourceAsn:  tt.sourceASN,
+		}
+		if got := bgpService.parsePath(path).EBGP; got != tt.want {
+			t.Errorf("EBGP for a route from %s = %v, want %v", tt.neighbor, got, tt.want)
+		}
+	}
+
+	// Parsing must not wait behind a neighbor change in progress
+	bgpService.neighborMu.Lock()
+	defer bgpService.neighborMu.Unlock()
+	parsed := make(chan bool)
+	go func() {
+		parsed <- bgpService.parsePath(&api.Path{
+			Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+			NeighborIp: "192.0.2.1",
+		}).EBGP
+	}()
+	select {
+	case ebgp := <-parsed:
+		if !ebgp {
+			t.Error("EBGP for a route from 192.0.2.1 = false while neighborMu is held, want true")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("parsePath blocked on neighborMu")
+	}
+}
+
+// testPrefixSID returns a Prefix-SID attribute with one SRv6 L3 service SID
+func testPrefixSID(t testing.TB) *api.PrefixSID {
+	structure := mustAny(t, &api.SRv6StructureSubSubTLV{
+		LocatorBlockLength: 40,
+		LocatorNodeLength:  24,
+		FunctionLength:     16,
+	})
+	info := mustAny(t, &api.SRv6InformationSubTLV{
+		Sid:              net.ParseIP("2001:db8:0:1::100"),
+		EndpointBehavior: 0x13, // End.DT4
+		SubSubTlvs:       map[uint32]*api.SRv6TLV{1: {Tlv: []*anypb.Any{structure}}},
+	})
+	return &api.PrefixSID{Tlvs: []*anypb.Any{
+		mustAny(t, &api.SRv6L3ServiceTLV{SubTlvs: map[uint32]*api.SRv6TLV{1: {Tlv: []*anypb.Any{info}}}}),
+	}}
+}
+
+// TestParsePathPrefixSID verifies the SRv6 SID, behavior and structure are
+// extracted from the Prefix-SID attribute
+func TestParsePathPrefixSID(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri:   mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{mustAny(t, testPrefixSID(t))},
+	}
+
+	update := bgpService.parsePath(path)
+	if update.PrefixSID == nil || len(update.PrefixSID.SRv6L3Service) != 1 {
+		t.Fatalf("PrefixSID = %+v, want one SRv6 L3 service SID", update.PrefixSID)
+	}
+	sid := update.PrefixSID.SRv6L3Service[0]
+	if !sid.SID.Equal(net.ParseIP("2001:db8:0:1::100")) || sid.EndpointBehavior != 0x13 {
+		t.Errorf("SID = %v behavior %#x, want 2001:db8:0:1::100 End.DT4", sid.SID, sid.EndpointBehavior)
+	}
+	want := SRv6SIDStructure{LocatorBlockLength: 40, LocatorNodeLength: 24, FunctionLength: 16}
+	if sid.Structure == nil || *sid.Structure != want {
+		t.Errorf("Structure = %+v, want %+v", sid.Structure, want)
+	}
+
+	// A truncated SID is reported rather than emitted
+	path.Pattrs = []*anypb.Any{mustAny(t, &api.PrefixSID{Tlvs: []*anypb.Any{
+		mustAny(t, &api.SRv6L3ServiceTLV{SubTlvs: map[uint32]*api.SRv6TLV{1: {Tlv: []*anypb.Any{
+			mustAny(t, &api.SRv6InformationSubTLV{Sid: []byte{0x20, 0x01}}),
+		}}}}),
+	}})}
+	if update := bgpService.parsePath(path); update.PrefixSID != nil || len(update.ParseErrors) != 1 {
+		t.Errorf("truncated SID: PrefixSID = %+v, ParseErrors = %v, want one parse error", update.PrefixSID, update.ParseErrors)
+	}
+}
+
+// testTunnelEncap returns a Tunnel Encapsulation attribute with one VXLAN
+// tunnel for VNI 10100
+func testTunnelEncap(t testing.TB) *api.TunnelEncapAttribute {
+	return &api.TunnelEncapAttribute{Tlvs: []*api.TunnelEncapTLV{{
+		Type: 8, // VXLAN
+		Tlvs: []*anypb.Any{
+			mustAny(t, &api.TunnelEncapSubTLVEncapsulation{Key: 0x80<<24 | 10100, Cookie: []byte{0, 0x53, 0, 0, 0, 1, 0, 0}}),
+			mustAny(t, &api.TunnelEncapSubTLVEgressEndpoint{Address: "192.0.2.30"}),
+			mustAny(t, &api.TunnelEncapSubTLVUDPDestPort{Port: 4789}),
+		},
+	}}}
+}
+
+// TestParsePathTunnelEncap verifies the tunnel type, key and endpoint are
+// extracted from the Tunnel Encapsulation attribute
+func TestParsePathTunnelEncap(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri:   mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		Pattrs: []*anypb.Any{mustAny(t, testTunnelEncap(t))},
+	}
+
+	update := bgpService.parsePath(path)
+	if len(update.TunnelEncap) != 1 {
+		t.Fatalf("TunnelEncap = %+v, want one tunnel", update.TunnelEncap)
+	}
+	tunnel := update.TunnelEncap[0]
+	if tunnel.Type != 8 {
+		t.Errorf("Type = %d, want 8 (VXLAN)", tunnel.Type)
+	}
+	if tunnel.Key == nil || *tunnel.Key&0xFFFFFF != 10100 {
+		t.Errorf("Key = %v, want VNI 10100", tunnel.Key)
+	}
+	if !tunnel.EgressEndpoint.Equal(net.ParseIP("192.0.2.30")) || tunnel.UDPDestPort != 4789 {
+		t.Errorf("endpoint = %v port %d, want 192.0.2.30 port 4789", tunnel.EgressEndpoint, tunnel.UDPDestPort)
+	}
+	if tunnel.Color != nil {
+		t.Errorf("Color = %d, want none", *tunnel.Color)
+	}
+}
+
+// TestAllowASIn verifies allowASIn reaches GoBGP as allow-own-as and lets
+// the local ASN appear that many times before a path is flagged as a loop
+func TestAllowASIn(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	if err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.1",
+		ASN:              65002,
+		NeighborTemplate: NeighborTemplate{AllowASIn: 2},
+	}); err != nil {
+		t.Fatalf("AddNeighborConfig() error = %v", err)
+	}
+	if err := bgpService.AddNeighbor("192.0.2.2", 65002); err != nil {
+		t.Fatalf("AddNeighbor() error = %v", err)
+	}
+	if got := fake.addPeers[0].Peer.Conf.AllowOwnAsn; got != 2 {
+		t.Errorf("AllowOwnAsn = %d, want 2", got)
+	}
+	if err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.3",
+		ASN:              65002,
+		NeighborTemplate: NeighborTemplate{AllowASIn: 256},
+	}); err == nil {
+		t.Error("AddNeighborConfig() accepted allowASIn 256")
+	}
+
+	tests := []struct {
+		name     string
+		neighbor string
+		path     []uint32
+		want     bool
+	}{
+		{"Allowed once", "192.0.2.1", []uint32{65002, 65001, 65010}, false},
+		{"Allowed twice", "192.0.2.1", []uint32{65002, 65001, 65001, 65010}, false},
+		{"Beyond the allowance", "192.0.2.1", []uint32{65002, 65001, 65003, 65001, 65001}, true},
+		{"No allowance", "192.0.2.2", []uint32{65002, 65001, 65010}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := &api.Path{
+				Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+				NeighborIp: tt.neighbor,
+				Pattrs: []*anypb.Any{mustAny(t, &api.AsPathAttribute{Segments: []*api.AsSegment{
+					{Type: 2, Numbers: tt.path},
+				}})},
+			}
+			if got := bgpService.parsePath(path).ASLoop; got != tt.want {
+				t.Errorf("ASLoop = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"context"
+	api "github.com/osrg/gobgp/v3/api"
+)
+
+// BgpServer is the subset of *server.BgpServer that BGPService relies on
+// Depending on this interface lets tests substitute a fake for a real
+// GoBGP instance; *server.BgpServer satisfies it as-is
+type BgpServer interface {
+	Serve()
+	Stop()
+	StartBgp(ctx context.Context, r *api.StartBgpRequest) error
+	GetBgp(ctx context.Context, r *api.GetBgpRequest) (*api.GetBgpResponse, error)
+
+	AddPeer(ctx context.Context, r *api.AddPeerRequest) error
+	DeletePeer(ctx context.Context, r *api.DeletePeerRequest) error
+	UpdatePeer(ctx context.Context, r *api.UpdatePeerRequest) (*api.UpdatePeerResponse, error)
+	ListPeer(ctx context.Context, r *api.ListPeerRequest, fn func(*api.Peer)) error
+	ResetPeer(ctx context.Context, r *api.ResetPeerRequest) error
+	EnablePeer(ctx context.Context, r *api.EnablePeerRequest) error
+	DisablePeer(ctx context.Context, r *api.DisablePeerRequest) error
+	AddPeerGroup(ctx context.Context, r *api.AddPeerGroupRequest) error
+	AddDynamicNeighbor(ctx context.Context, r *api.AddDynamicNeighborRequest) error
+
+	AddBmp(ctx context.Context, r *api.AddBmpRequest) error
+	AddRpki(ctx context.Context, r *api.AddRpkiRequest) error
+
+	// WatchEvent returns once the watch is registered and streams events to
+	// fn in the background until ctx is done; GoBGP's in-process stream has
+	// no other end, so it cannot report one
+	WatchEvent(ctx context.Context, r *api.WatchEventRequest, fn func(*api.WatchEventResponse)) error
+
+	AddPath(ctx context.Context, r *api.AddPathRequest) (*api.AddPathResponse, error)
+	DeletePath(ctx context.Context, r *api.DeletePathRequest) error
+	ListPath(ctx context.Context, r *api.ListPathRequest, fn func(*api.Destination)) error
+	GetTable(ctx context.Context, r *api.GetTableRequest) (*api.GetTableResponse, error)
+
+	AddDefinedSet(ctx context.Context, r *api.AddDefinedSetRequest) error
+	DeleteDefinedSet(ctx context.Context, r *api.DeleteDefinedSetRequest) error
+	ListDefinedSet(ctx context.Context, r *api.ListDefinedSetRequest, fn func(*api.DefinedSet)) error
+	AddPolicy(ctx context.Context, r *api.AddPolicyRequest) error
+	DeletePolicy(ctx context.Context, r *api.DeletePolicyRequest) error
+	ListPolicy(ctx context.Context, r *api.ListPolicyRequest, fn func(*api.Policy)) error
+	ListPolicyAssignment(ctx context.Context, r *api.ListPolicyAssignmentRequest, fn func(*api.PolicyAssignment)) error
+	SetPolicyAssignment(ctx context.Context, r *api.SetPolicyAssignmentRequest) error
+}
@@ -1,6 +1,9 @@
 package pkg
 
-import "net"
+import (
+	"net"
+	"time"
+)
 
 // BGPUpdateMessage represents a comprehensive view of a BGP UPDATE message
 type BGPUpdateMessage struct {
@@ -16,18 +19,38 @@ type BGPUpdateMessage struct {
 
 	Origin            *uint8 // 0=IGP, 1=EGP, 2=INCOMPLETE
 	ASPath            [][]uint32
+	OriginAS          uint32 // Last AS of the path, 0 when empty or ending in an AS_SET
 	NextHop           net.IP
 	MED               *uint32
 	LocalPref         *uint32
 	AtomicAggregate   bool
 	AggregatorAS      *uint32
 	AggregatorAddress net.IP
+	AIGP              *uint64 // Accumulated IGP metric (RFC 7311)
+	OnlyToCustomer    *uint32 // OTC (RFC 9234), the AS that marked the route as customer-bound
+
+	// Route reflection (RFC 4456), reveals which reflectors a path crossed
+	OriginatorID net.IP
+	ClusterList  []net.IP
+
+	// ASLoop is set when the AS path already contains the local ASN
+	ASLoop bool
+	// ASPathTooLong is set when the AS path exceeds bgp.maxASPathLength
+	ASPathTooLong bool
 
 	Communities         []uint32
 	CommunityStrings    []string
 	ExtendedCommunities [][]byte
 	LargeCommunities    [][3]uint32
 
+	// UnknownAttributes carries the raw value of every path attribute GoBGP
+	// could not decode, such as ones defined after it was released
+	UnknownAttributes []struct {
+		Type  uint8
+		Flags uint8
+		Value []byte
+	}
+
 	// RPKI Origin Validation State (RFC 8097)
 	RPKIValidationState *string
 
@@ -36,7 +59,11 @@ type BGPUpdateMessage struct {
 		AFI     uint16
 		SAFI    uint8
 		NextHop net.IP
-		NLRIs   []struct {
+		// An IPv6 next hop can carry a global and a link-local address; the
+		// latter is what directly connected eBGP peers resolve against
+		NextHopGlobal    net.IP
+		NextHopLinkLocal net.IP
+		NLRIs            []struct {
 			PrefixLength uint8
 			Prefix       net.IP
 		}
@@ -57,8 +84,42 @@ type BGPUpdateMessage struct {
 		Prefix       net.IP
 	}
 
+	// L3VPN (RFC 4364), set for VPNv4/VPNv6 routes
+	RouteDistinguisher string   // e.g. "65000:100" or "192.0.2.1:100"
+	Labels             []uint32 // MPLS label stack carried with the NLRI
+
+	// FlowSpec holds the decoded rule of a flowspec (SAFI 133) route
+	FlowSpec *FlowSpecRule
+
+	// EVPN holds the decoded route of an EVPN (AFI 25, SAFI 70) update, and
+	// PMSITunnel the tunnel EVPN peers use for broadcast traffic
+	EVPN       *EVPNRoute
+	PMSITunnel *PMSITunnel
+
+	// PrefixSID carries the segment routing SIDs of the route, if any
+	PrefixSID *PrefixSID
+
+	// TunnelEncap lists the tunnels an overlay reaches the route through
+	TunnelEncap []TunnelEncap
+
 	// Metadata
+	// Sequence numbers emitted updates from 1 upwards; it carries on across
+	// watch reconnects, so a gap means updates were lost in between
+	Sequence   uint64
 	IsWithdraw bool
 	FromPeer   string
-	Timestamp  int64
+	PathID     uint32    // ADD-PATH identifier FromPeer sent the path with, 0 without ADD-PATH
+	EBGP       bool      // FromPeer is in another AS than ours; false for iBGP and local routes
+	Timestamp  int64     // Path age as reported by GoBGP, in Unix seconds
+	ReceivedAt time.Time // Wall-clock time the update was parsed
+
+	// Best marks the path GoBGP selected for its prefix and BestPathReason
+	// the comparison that made it win, e.g. "higher local-pref (200 vs 100)"
+	// Both are only set in RIB listings such as ListPaths and LookupPrefix
+	Best           bool
+	BestPathReason string
+
+	// ParseErrors lists anything the parser had to drop from this update
+	// An empty slice means the path was decoded completely
+	ParseErrors []string
 }
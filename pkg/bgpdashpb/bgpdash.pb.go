@@ -0,0 +1,1634 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: bgpdash.proto
+
+package bgpdashpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WatchUpdatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *UpdateFilter          `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"` // Every update when unset
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchUpdatesRequest) Reset() {
+	*x = WatchUpdatesRequest{}
+	mi := &file_bgpdash_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchUpdatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchUpdatesRequest) ProtoMessage() {}
+
+func (x *WatchUpdatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchUpdatesRequest.ProtoReflect.Descriptor instead.
+func (*WatchUpdatesRequest) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchUpdatesRequest) GetFilter() *UpdateFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+// UpdateFilter mirrors pkg.UpdateFilter, with prefixes in CIDR notation
+type UpdateFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefixes      []string               `protobuf:"bytes,1,rep,name=prefixes,proto3" json:"prefixes,omitempty"`
+	Communities   []string               `protobuf:"bytes,2,rep,name=communities,proto3" json:"communities,omitempty"`
+	RpkiStates    []string               `protobuf:"bytes,3,rep,name=rpki_states,json=rpkiStates,proto3" json:"rpki_states,omitempty"`
+	Families      []string               `protobuf:"bytes,4,rep,name=families,proto3" json:"families,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateFilter) Reset() {
+	*x = UpdateFilter{}
+	mi := &file_bgpdash_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFilter) ProtoMessage() {}
+
+func (x *UpdateFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFilter.ProtoReflect.Descriptor instead.
+func (*UpdateFilter) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *UpdateFilter) GetPrefixes() []string {
+	if x != nil {
+		return x.Prefixes
+	}
+	return nil
+}
+
+func (x *UpdateFilter) GetCommunities() []string {
+	if x != nil {
+		return x.Communities
+	}
+	return nil
+}
+
+func (x *UpdateFilter) GetRpkiStates() []string {
+	if x != nil {
+		return x.RpkiStates
+	}
+	return nil
+}
+
+func (x *UpdateFilter) GetFamilies() []string {
+	if x != nil {
+		return x.Families
+	}
+	return nil
+}
+
+// Prefix is a single NLRI entry
+type Prefix struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Length        uint32                 `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Prefix) Reset() {
+	*x = Prefix{}
+	mi := &file_bgpdash_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Prefix) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Prefix) ProtoMessage() {}
+
+func (x *Prefix) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Prefix.ProtoReflect.Descriptor instead.
+func (*Prefix) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Prefix) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *Prefix) GetLength() uint32 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+// AsSegment is one AS_PATH segment
+type AsSegment struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Asns          []uint32               `protobuf:"varint,1,rep,packed,name=asns,proto3" json:"asns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AsSegment) Reset() {
+	*x = AsSegment{}
+	mi := &file_bgpdash_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AsSegment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AsSegment) ProtoMessage() {}
+
+func (x *AsSegment) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AsSegment.ProtoReflect.Descriptor instead.
+func (*AsSegment) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AsSegment) GetAsns() []uint32 {
+	if x != nil {
+		return x.Asns
+	}
+	return nil
+}
+
+// Update mirrors pkg.BGPUpdateMessage
+type Update struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	FromPeer            string                 `protobuf:"bytes,1,opt,name=from_peer,json=fromPeer,proto3" json:"from_peer,omitempty"`
+	Timestamp           int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	IsWithdraw          bool                   `protobuf:"varint,3,opt,name=is_withdraw,json=isWithdraw,proto3" json:"is_withdraw,omitempty"`
+	Nlri                []*Prefix              `protobuf:"bytes,4,rep,name=nlri,proto3" json:"nlri,omitempty"`
+	NextHop             string                 `protobuf:"bytes,5,opt,name=next_hop,json=nextHop,proto3" json:"next_hop,omitempty"`
+	AsPath              []*AsSegment           `protobuf:"bytes,6,rep,name=as_path,json=asPath,proto3" json:"as_path,omitempty"`
+	Origin              *uint32                `protobuf:"varint,7,opt,name=origin,proto3,oneof" json:"origin,omitempty"`
+	Med                 *uint32                `protobuf:"varint,8,opt,name=med,proto3,oneof" json:"med,omitempty"`
+	LocalPref           *uint32                `protobuf:"varint,9,opt,name=local_pref,json=localPref,proto3,oneof" json:"local_pref,omitempty"`
+	Communities         []string               `protobuf:"bytes,10,rep,name=communities,proto3" json:"communities,omitempty"`
+	LargeCommunities    []*LargeCommunity      `protobuf:"bytes,11,rep,name=large_communities,json=largeCommunities,proto3" json:"large_communities,omitempty"`
+	RpkiValidationState *string                `protobuf:"bytes,12,opt,name=rpki_validation_state,json=rpkiValidationState,proto3,oneof" json:"rpki_validation_state,omitempty"`
+	OriginatorId        string                 `protobuf:"bytes,13,opt,name=originator_id,json=originatorId,proto3" json:"originator_id,omitempty"`
+	ClusterList         []string               `protobuf:"bytes,14,rep,name=cluster_list,json=clusterList,proto3" json:"cluster_list,omitempty"`
+	AsLoop              bool                   `protobuf:"varint,15,opt,name=as_loop,json=asLoop,proto3" json:"as_loop,omitempty"`
+	ParseErrors         []string               `protobuf:"bytes,16,rep,name=parse_errors,json=parseErrors,proto3" json:"parse_errors,omitempty"`
+	// Fields below complete the mirror of pkg.BGPUpdateMessage for sinks
+	// that encode updates as protobuf; IP addresses are in text form
+	WithdrawnRoutesLength    uint32              `protobuf:"varint,17,opt,name=withdrawn_routes_length,json=withdrawnRoutesLength,proto3" json:"withdrawn_routes_length,omitempty"`
+	WithdrawnRoutes          []*Prefix           `protobuf:"bytes,18,rep,name=withdrawn_routes,json=withdrawnRoutes,proto3" json:"withdrawn_routes,omitempty"`
+	TotalPathAttributeLength uint32              `protobuf:"varint,19,opt,name=total_path_attribute_length,json=totalPathAttributeLength,proto3" json:"total_path_attribute_length,omitempty"`
+	OriginAs                 uint32              `protobuf:"varint,20,opt,name=origin_as,json=originAs,proto3" json:"origin_as,omitempty"`
+	AtomicAggregate          bool                `protobuf:"varint,21,opt,name=atomic_aggregate,json=atomicAggregate,proto3" json:"atomic_aggregate,omitempty"`
+	AggregatorAs             *uint32             `protobuf:"varint,22,opt,name=aggregator_as,json=aggregatorAs,proto3,oneof" json:"aggregator_as,omitempty"`
+	AggregatorAddress        string              `protobuf:"bytes,23,opt,name=aggregator_address,json=aggregatorAddress,proto3" json:"aggregator_address,omitempty"`
+	Aigp                     *uint64             `protobuf:"varint,24,opt,name=aigp,proto3,oneof" json:"aigp,omitempty"`
+	OnlyToCustomer           *uint32             `protobuf:"varint,25,opt,name=only_to_customer,json=onlyToCustomer,proto3,oneof" json:"only_to_customer,omitempty"`
+	AsPathTooLong            bool                `protobuf:"varint,26,opt,name=as_path_too_long,json=asPathTooLong,proto3" json:"as_path_too_long,omitempty"`
+	CommunityValues          []uint32            `protobuf:"varint,27,rep,packed,name=community_values,json=communityValues,proto3" json:"community_values,omitempty"` // communities as 32-bit values
+	ExtendedCommunities      [][]byte            `protobuf:"bytes,28,rep,name=extended_communities,json=extendedCommunities,proto3" json:"extended_communities,omitempty"`
+	MpReach                  *MpReach            `protobuf:"bytes,29,opt,name=mp_reach,json=mpReach,proto3" json:"mp_reach,omitempty"`
+	MpUnreach                *MpUnreach          `protobuf:"bytes,30,opt,name=mp_unreach,json=mpUnreach,proto3" json:"mp_unreach,omitempty"`
+	RouteDistinguisher       string              `protobuf:"bytes,31,opt,name=route_distinguisher,json=routeDistinguisher,proto3" json:"route_distinguisher,omitempty"`
+	Labels                   []uint32            `protobuf:"varint,32,rep,packed,name=labels,proto3" json:"labels,omitempty"`
+	Flowspec                 *FlowSpecRule       `protobuf:"bytes,33,opt,name=flowspec,proto3" json:"flowspec,omitempty"`
+	Evpn                     *EvpnRoute          `protobuf:"bytes,34,opt,name=evpn,proto3" json:"evpn,omitempty"`
+	PmsiTunnel               *PmsiTunnel         `protobuf:"bytes,35,opt,name=pmsi_tunnel,json=pmsiTunnel,proto3" json:"pmsi_tunnel,omitempty"`
+	ReceivedAtUnixNano       int64               `protobuf:"varint,36,opt,name=received_at_unix_nano,json=receivedAtUnixNano,proto3" json:"received_at_unix_nano,omitempty"` // 0 when unset
+	UnknownAttributes        []*UnknownAttribute `protobuf:"bytes,37,rep,name=unknown_attributes,json=unknownAttributes,proto3" json:"unknown_attributes,omitempty"`
+	Sequence                 uint64              `protobuf:"varint,38,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Ebgp                     bool                `protobuf:"varint,39,opt,name=ebgp,proto3" json:"ebgp,omitempty"` // learned from a peer in another AS
+	PrefixSid                *PrefixSid          `protobuf:"bytes,40,opt,name=prefix_sid,json=prefixSid,proto3" json:"prefix_sid,omitempty"`
+	TunnelEncap              []*TunnelEncap      `protobuf:"bytes,41,rep,name=tunnel_encap,json=tunnelEncap,proto3" json:"tunnel_encap,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *Update) Reset() {
+	*x = Update{}
+	mi := &file_bgpdash_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Update) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Update) ProtoMessage() {}
+
+func (x *Update) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Update.ProtoReflect.Descriptor instead.
+func (*Update) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Update) GetFromPeer() string {
+	if x != nil {
+		return x.FromPeer
+	}
+	return ""
+}
+
+func (x *Update) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Update) GetIsWithdraw() bool {
+	if x != nil {
+		return x.IsWithdraw
+	}
+	return false
+}
+
+func (x *Update) GetNlri() []*Prefix {
+	if x != nil {
+		return x.Nlri
+	}
+	return nil
+}
+
+func (x *Update) GetNextHop() string {
+	if x != nil {
+		return x.NextHop
+	}
+	return ""
+}
+
+func (x *Update) GetAsPath() []*AsSegment {
+	if x != nil {
+		return x.AsPath
+	}
+	return nil
+}
+
+func (x *Update) GetOrigin() uint32 {
+	if x != nil && x.Origin != nil {
+		return *x.Origin
+	}
+	return 0
+}
+
+func (x *Update) GetMed() uint32 {
+	if x != nil && x.Med != nil {
+		return *x.Med
+	}
+	return 0
+}
+
+func (x *Update) GetLocalPref() uint32 {
+	if x != nil && x.LocalPref != nil {
+		return *x.LocalPref
+	}
+	return 0
+}
+
+func (x *Update) GetCommunities() []string {
+	if x != nil {
+		return x.Communities
+	}
+	return nil
+}
+
+func (x *Update) GetLargeCommunities() []*LargeCommunity {
+	if x != nil {
+		return x.LargeCommunities
+	}
+	return nil
+}
+
+func (x *Update) GetRpkiValidationState() string {
+	if x != nil && x.RpkiValidationState != nil {
+		return *x.RpkiValidationState
+	}
+	return ""
+}
+
+func (x *Update) GetOriginatorId() string {
+	if x != nil {
+		return x.OriginatorId
+	}
+	return ""
+}
+
+func (x *Update) GetClusterList() []string {
+	if x != nil {
+		return x.ClusterList
+	}
+	return nil
+}
+
+func (x *Update) GetAsLoop() bool {
+	if x != nil {
+		return x.AsLoop
+	}
+	return false
+}
+
+func (x *Update) GetParseErrors() []string {
+	if x != nil {
+		return x.ParseErrors
+	}
+	return nil
+}
+
+func (x *Update) GetWithdrawnRoutesLength() uint32 {
+	if x != nil {
+		return x.WithdrawnRoutesLength
+	}
+	return 0
+}
+
+func (x *Update) GetWithdrawnRoutes() []*Prefix {
+	if x != nil {
+		return x.WithdrawnRoutes
+	}
+	return nil
+}
+
+func (x *Update) GetTotalPathAttributeLength() uint32 {
+	if x != nil {
+		return x.TotalPathAttributeLength
+	}
+	return 0
+}
+
+func (x *Update) GetOriginAs() uint32 {
+	if x != nil {
+		return x.OriginAs
+	}
+	return 0
+}
+
+func (x *Update) GetAtomicAggregate() bool {
+	if x != nil {
+		return x.AtomicAggregate
+	}
+	return false
+}
+
+func (x *Update) GetAggregatorAs() uint32 {
+	if x != nil && x.AggregatorAs != nil {
+		return *x.AggregatorAs
+	}
+	return 0
+}
+
+func (x *Update) GetAggregatorAddress() string {
+	if x != nil {
+		return x.AggregatorAddress
+	}
+	return ""
+}
+
+func (x *Update) GetAigp() uint64 {
+	if x != nil && x.Aigp != nil {
+		return *x.Aigp
+	}
+	return 0
+}
+
+func (x *Update) GetOnlyToCustomer() uint32 {
+	if x != nil && x.OnlyToCustomer != nil {
+		return *x.OnlyToCustomer
+	}
+	return 0
+}
+
+func (x *Update) GetAsPathTooLong() bool {
+	if x != nil {
+		return x.AsPathTooLong
+	}
+	return false
+}
+
+func (x *Update) GetCommunityValues() []uint32 {
+	if x != nil {
+		return x.CommunityValues
+	}
+	return nil
+}
+
+func (x *Update) GetExtendedCommunities() [][]byte {
+	if x != nil {
+		return x.ExtendedCommunities
+	}
+	return nil
+}
+
+func (x *Update) GetMpReach() *MpReach {
+	if x != nil {
+		return x.MpReach
+	}
+	return nil
+}
+
+func (x *Update) GetMpUnreach() *MpUnreach {
+	if x != nil {
+		return x.MpUnreach
+	}
+	return nil
+}
+
+func (x *Update) GetRouteDistinguisher() string {
+	if x != nil {
+		return x.RouteDistinguisher
+	}
+	return ""
+}
+
+func (x *Update) GetLabels() []uint32 {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Update) GetFlowspec() *FlowSpecRule {
+	if x != nil {
+		return x.Flowspec
+	}
+	return nil
+}
+
+func (x *Update) GetEvpn() *EvpnRoute {
+	if x != nil {
+		return x.Evpn
+	}
+	return nil
+}
+
+func (x *Update) GetPmsiTunnel() *PmsiTunnel {
+	if x != nil {
+		return x.PmsiTunnel
+	}
+	return nil
+}
+
+func (x *Update) GetReceivedAtUnixNano() int64 {
+	if x != nil {
+		return x.ReceivedAtUnixNano
+	}
+	return 0
+}
+
+func (x *Update) GetUnknownAttributes() []*UnknownAttribute {
+	if x != nil {
+		return x.UnknownAttributes
+	}
+	return nil
+}
+
+func (x *Update) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Update) GetEbgp() bool {
+	if x != nil {
+		return x.Ebgp
+	}
+	return false
+}
+
+func (x *Update) GetPrefixSid() *PrefixSid {
+	if x != nil {
+		return x.PrefixSid
+	}
+	return nil
+}
+
+func (x *Update) GetTunnelEncap() []*TunnelEncap {
+	if x != nil {
+		return x.TunnelEncap
+	}
+	return nil
+}
+
+// UnknownAttribute is a path attribute passed through undecoded
+type UnknownAttribute struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          uint32                 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Flags         uint32                 `protobuf:"varint,2,opt,name=flags,proto3" json:"flags,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnknownAttribute) Reset() {
+	*x = UnknownAttribute{}
+	mi := &file_bgpdash_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnknownAttribute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnknownAttribute) ProtoMessage() {}
+
+func (x *UnknownAttribute) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnknownAttribute.ProtoReflect.Descriptor instead.
+func (*UnknownAttribute) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UnknownAttribute) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *UnknownAttribute) GetFlags() uint32 {
+	if x != nil {
+		return x.Flags
+	}
+	return 0
+}
+
+func (x *UnknownAttribute) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// MpReach mirrors the MP_REACH_NLRI attribute
+type MpReach struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Afi              uint32                 `protobuf:"varint,1,opt,name=afi,proto3" json:"afi,omitempty"`
+	Safi             uint32                 `protobuf:"varint,2,opt,name=safi,proto3" json:"safi,omitempty"`
+	NextHop          string                 `protobuf:"bytes,3,opt,name=next_hop,json=nextHop,proto3" json:"next_hop,omitempty"`
+	NextHopGlobal    string                 `protobuf:"bytes,4,opt,name=next_hop_global,json=nextHopGlobal,proto3" json:"next_hop_global,omitempty"`
+	NextHopLinkLocal string                 `protobuf:"bytes,5,opt,name=next_hop_link_local,json=nextHopLinkLocal,proto3" json:"next_hop_link_local,omitempty"`
+	Nlris            []*Prefix              `protobuf:"bytes,6,rep,name=nlris,proto3" json:"nlris,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MpReach) Reset() {
+	*x = MpReach{}
+	mi := &file_bgpdash_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MpReach) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MpReach) ProtoMessage() {}
+
+func (x *MpReach) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MpReach.ProtoReflect.Descriptor instead.
+func (*MpReach) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MpReach) GetAfi() uint32 {
+	if x != nil {
+		return x.Afi
+	}
+	return 0
+}
+
+func (x *MpReach) GetSafi() uint32 {
+	if x != nil {
+		return x.Safi
+	}
+	return 0
+}
+
+func (x *MpReach) GetNextHop() string {
+	if x != nil {
+		return x.NextHop
+	}
+	return ""
+}
+
+func (x *MpReach) GetNextHopGlobal() string {
+	if x != nil {
+		return x.NextHopGlobal
+	}
+	return ""
+}
+
+func (x *MpReach) GetNextHopLinkLocal() string {
+	if x != nil {
+		return x.NextHopLinkLocal
+	}
+	return ""
+}
+
+func (x *MpReach) GetNlris() []*Prefix {
+	if x != nil {
+		return x.Nlris
+	}
+	return nil
+}
+
+// MpUnreach mirrors the MP_UNREACH_NLRI attribute
+type MpUnreach struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Afi           uint32                 `protobuf:"varint,1,opt,name=afi,proto3" json:"afi,omitempty"`
+	Safi          uint32                 `protobuf:"varint,2,opt,name=safi,proto3" json:"safi,omitempty"`
+	Nlris         []*Prefix              `protobuf:"bytes,3,rep,name=nlris,proto3" json:"nlris,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MpUnreach) Reset() {
+	*x = MpUnreach{}
+	mi := &file_bgpdash_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MpUnreach) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MpUnreach) ProtoMessage() {}
+
+func (x *MpUnreach) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MpUnreach.ProtoReflect.Descriptor instead.
+func (*MpUnreach) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MpUnreach) GetAfi() uint32 {
+	if x != nil {
+		return x.Afi
+	}
+	return 0
+}
+
+func (x *MpUnreach) GetSafi() uint32 {
+	if x != nil {
+		return x.Safi
+	}
+	return 0
+}
+
+func (x *MpUnreach) GetNlris() []*Prefix {
+	if x != nil {
+		return x.Nlris
+	}
+	return nil
+}
+
+// FlowSpecRule mirrors pkg.FlowSpecRule
+type FlowSpecRule struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	DestinationPrefix string                 `protobuf:"bytes,1,opt,name=destination_prefix,json=destinationPrefix,proto3" json:"destination_prefix,omitempty"`
+	SourcePrefix      string                 `protobuf:"bytes,2,opt,name=source_prefix,json=sourcePrefix,proto3" json:"source_prefix,omitempty"`
+	Protocols         []string               `protobuf:"bytes,3,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	Ports             []string               `protobuf:"bytes,4,rep,name=ports,proto3" json:"ports,omitempty"`
+	DestinationPorts  []string               `protobuf:"bytes,5,rep,name=destination_ports,json=destinationPorts,proto3" json:"destination_ports,omitempty"`
+	SourcePorts       []string               `protobuf:"bytes,6,rep,name=source_ports,json=sourcePorts,proto3" json:"source_ports,omitempty"`
+	Other             []uint32               `protobuf:"varint,7,rep,packed,name=other,proto3" json:"other,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *FlowSpecRule) Reset() {
+	*x = FlowSpecRule{}
+	mi := &file_bgpdash_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlowSpecRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlowSpecRule) ProtoMessage() {}
+
+func (x *FlowSpecRule) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlowSpecRule.ProtoReflect.Descriptor instead.
+func (*FlowSpecRule) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FlowSpecRule) GetDestinationPrefix() string {
+	if x != nil {
+		return x.DestinationPrefix
+	}
+	return ""
+}
+
+func (x *FlowSpecRule) GetSourcePrefix() string {
+	if x != nil {
+		return x.SourcePrefix
+	}
+	return ""
+}
+
+func (x *FlowSpecRule) GetProtocols() []string {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *FlowSpecRule) GetPorts() []string {
+	if x != nil {
+		return x.Ports
+	}
+	return nil
+}
+
+func (x *FlowSpecRule) GetDestinationPorts() []string {
+	if x != nil {
+		return x.DestinationPorts
+	}
+	return nil
+}
+
+func (x *FlowSpecRule) GetSourcePorts() []string {
+	if x != nil {
+		return x.SourcePorts
+	}
+	return nil
+}
+
+func (x *FlowSpecRule) GetOther() []uint32 {
+	if x != nil {
+		return x.Other
+	}
+	return nil
+}
+
+// EvpnRoute mirrors pkg.EVPNRoute
+type EvpnRoute struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	RouteType          uint32                 `protobuf:"varint,1,opt,name=route_type,json=routeType,proto3" json:"route_type,omitempty"`
+	RouteDistinguisher string                 `protobuf:"bytes,2,opt,name=route_distinguisher,json=routeDistinguisher,proto3" json:"route_distinguisher,omitempty"`
+	EthernetTag        uint32                 `protobuf:"varint,3,opt,name=ethernet_tag,json=ethernetTag,proto3" json:"ethernet_tag,omitempty"`
+	Mac                string                 `protobuf:"bytes,4,opt,name=mac,proto3" json:"mac,omitempty"`
+	Ip                 string                 `protobuf:"bytes,5,opt,name=ip,proto3" json:"ip,omitempty"`
+	PrefixLength       uint32                 `protobuf:"varint,6,opt,name=prefix_length,json=prefixLength,proto3" json:"prefix_length,omitempty"`
+	Gateway            string                 `protobuf:"bytes,7,opt,name=gateway,proto3" json:"gateway,omitempty"`
+	Labels             []uint32               `protobuf:"varint,8,rep,packed,name=labels,proto3" json:"labels,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *EvpnRoute) Reset() {
+	*x = EvpnRoute{}
+	mi := &file_bgpdash_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EvpnRoute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EvpnRoute) ProtoMessage() {}
+
+func (x *EvpnRoute) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EvpnRoute.ProtoReflect.Descriptor instead.
+func (*EvpnRoute) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EvpnRoute) GetRouteType() uint32 {
+	if x != nil {
+		return x.RouteType
+	}
+	return 0
+}
+
+func (x *EvpnRoute) GetRouteDistinguisher() string {
+	if x != nil {
+		return x.RouteDistinguisher
+	}
+	return ""
+}
+
+func (x *EvpnRoute) GetEthernetTag() uint32 {
+	if x != nil {
+		return x.EthernetTag
+	}
+	return 0
+}
+
+func (x *EvpnRoute) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *EvpnRoute) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *EvpnRoute) GetPrefixLength() uint32 {
+	if x != nil {
+		return x.PrefixLength
+	}
+	return 0
+}
+
+func (x *EvpnRoute) GetGateway() string {
+	if x != nil {
+		return x.Gateway
+	}
+	return ""
+}
+
+func (x *EvpnRoute) GetLabels() []uint32 {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// PmsiTunnel mirrors pkg.PMSITunnel
+type PmsiTunnel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          uint32                 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Label         uint32                 `protobuf:"varint,2,opt,name=label,proto3" json:"label,omitempty"`
+	TunnelId      string                 `protobuf:"bytes,3,opt,name=tunnel_id,json=tunnelId,proto3" json:"tunnel_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PmsiTunnel) Reset() {
+	*x = PmsiTunnel{}
+	mi := &file_bgpdash_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PmsiTunnel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PmsiTunnel) ProtoMessage() {}
+
+func (x *PmsiTunnel) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PmsiTunnel.ProtoReflect.Descriptor instead.
+func (*PmsiTunnel) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PmsiTunnel) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *PmsiTunnel) GetLabel() uint32 {
+	if x != nil {
+		return x.Label
+	}
+	return 0
+}
+
+func (x *PmsiTunnel) GetTunnelId() string {
+	if x != nil {
+		return x.TunnelId
+	}
+	return ""
+}
+
+// PrefixSid mirrors pkg.PrefixSID
+type PrefixSid struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Srv6L3Service []*Srv6Sid             `protobuf:"bytes,1,rep,name=srv6_l3_service,json=srv6L3Service,proto3" json:"srv6_l3_service,omitempty"`
+	Srv6L2Service []*Srv6Sid             `protobuf:"bytes,2,rep,name=srv6_l2_service,json=srv6L2Service,proto3" json:"srv6_l2_service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrefixSid) Reset() {
+	*x = PrefixSid{}
+	mi := &file_bgpdash_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrefixSid) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrefixSid) ProtoMessage() {}
+
+func (x *PrefixSid) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrefixSid.ProtoReflect.Descriptor instead.
+func (*PrefixSid) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PrefixSid) GetSrv6L3Service() []*Srv6Sid {
+	if x != nil {
+		return x.Srv6L3Service
+	}
+	return nil
+}
+
+func (x *PrefixSid) GetSrv6L2Service() []*Srv6Sid {
+	if x != nil {
+		return x.Srv6L2Service
+	}
+	return nil
+}
+
+// Srv6Sid mirrors pkg.SRv6SID
+type Srv6Sid struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Sid              string                 `protobuf:"bytes,1,opt,name=sid,proto3" json:"sid,omitempty"`
+	EndpointBehavior uint32                 `protobuf:"varint,2,opt,name=endpoint_behavior,json=endpointBehavior,proto3" json:"endpoint_behavior,omitempty"`
+	Structure        *Srv6SidStructure      `protobuf:"bytes,3,opt,name=structure,proto3" json:"structure,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Srv6Sid) Reset() {
+	*x = Srv6Sid{}
+	mi := &file_bgpdash_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Srv6Sid) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Srv6Sid) ProtoMessage() {}
+
+func (x *Srv6Sid) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Srv6Sid.ProtoReflect.Descriptor instead.
+func (*Srv6Sid) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Srv6Sid) GetSid() string {
+	if x != nil {
+		return x.Sid
+	}
+	return ""
+}
+
+func (x *Srv6Sid) GetEndpointBehavior() uint32 {
+	if x != nil {
+		return x.EndpointBehavior
+	}
+	return 0
+}
+
+func (x *Srv6Sid) GetStructure() *Srv6SidStructure {
+	if x != nil {
+		return x.Structure
+	}
+	return nil
+}
+
+// Srv6SidStructure mirrors pkg.SRv6SIDStructure
+type Srv6SidStructure struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	LocatorBlockLength  uint32                 `protobuf:"varint,1,opt,name=locator_block_length,json=locatorBlockLength,proto3" json:"locator_block_length,omitempty"`
+	LocatorNodeLength   uint32                 `protobuf:"varint,2,opt,name=locator_node_length,json=locatorNodeLength,proto3" json:"locator_node_length,omitempty"`
+	FunctionLength      uint32                 `protobuf:"varint,3,opt,name=function_length,json=functionLength,proto3" json:"function_length,omitempty"`
+	ArgumentLength      uint32                 `protobuf:"varint,4,opt,name=argument_length,json=argumentLength,proto3" json:"argument_length,omitempty"`
+	TranspositionLength uint32                 `protobuf:"varint,5,opt,name=transposition_length,json=transpositionLength,proto3" json:"transposition_length,omitempty"`
+	TranspositionOffset uint32                 `protobuf:"varint,6,opt,name=transposition_offset,json=transpositionOffset,proto3" json:"transposition_offset,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Srv6SidStructure) Reset() {
+	*x = Srv6SidStructure{}
+	mi := &file_bgpdash_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Srv6SidStructure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Srv6SidStructure) ProtoMessage() {}
+
+func (x *Srv6SidStructure) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Srv6SidStructure.ProtoReflect.Descriptor instead.
+func (*Srv6SidStructure) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Srv6SidStructure) GetLocatorBlockLength() uint32 {
+	if x != nil {
+		return x.LocatorBlockLength
+	}
+	return 0
+}
+
+func (x *Srv6SidStructure) GetLocatorNodeLength() uint32 {
+	if x != nil {
+		return x.LocatorNodeLength
+	}
+	return 0
+}
+
+func (x *Srv6SidStructure) GetFunctionLength() uint32 {
+	if x != nil {
+		return x.FunctionLength
+	}
+	return 0
+}
+
+func (x *Srv6SidStructure) GetArgumentLength() uint32 {
+	if x != nil {
+		return x.ArgumentLength
+	}
+	return 0
+}
+
+func (x *Srv6SidStructure) GetTranspositionLength() uint32 {
+	if x != nil {
+		return x.TranspositionLength
+	}
+	return 0
+}
+
+func (x *Srv6SidStructure) GetTranspositionOffset() uint32 {
+	if x != nil {
+		return x.TranspositionOffset
+	}
+	return 0
+}
+
+type LargeCommunity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GlobalAdmin   uint32                 `protobuf:"varint,1,opt,name=global_admin,json=globalAdmin,proto3" json:"global_admin,omitempty"`
+	LocalData1    uint32                 `protobuf:"varint,2,opt,name=local_data1,json=localData1,proto3" json:"local_data1,omitempty"`
+	LocalData2    uint32                 `protobuf:"varint,3,opt,name=local_data2,json=localData2,proto3" json:"local_data2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LargeCommunity) Reset() {
+	*x = LargeCommunity{}
+	mi := &file_bgpdash_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LargeCommunity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LargeCommunity) ProtoMessage() {}
+
+func (x *LargeCommunity) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LargeCommunity.ProtoReflect.Descriptor instead.
+func (*LargeCommunity) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *LargeCommunity) GetGlobalAdmin() uint32 {
+	if x != nil {
+		return x.GlobalAdmin
+	}
+	return 0
+}
+
+func (x *LargeCommunity) GetLocalData1() uint32 {
+	if x != nil {
+		return x.LocalData1
+	}
+	return 0
+}
+
+func (x *LargeCommunity) GetLocalData2() uint32 {
+	if x != nil {
+		return x.LocalData2
+	}
+	return 0
+}
+
+// TunnelEncap mirrors pkg.TunnelEncap
+type TunnelEncap struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Type           uint32                 `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Key            *uint32                `protobuf:"varint,2,opt,name=key,proto3,oneof" json:"key,omitempty"`
+	Cookie         []byte                 `protobuf:"bytes,3,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	Color          *uint32                `protobuf:"varint,4,opt,name=color,proto3,oneof" json:"color,omitempty"`
+	EgressEndpoint string                 `protobuf:"bytes,5,opt,name=egress_endpoint,json=egressEndpoint,proto3" json:"egress_endpoint,omitempty"`
+	UdpDestPort    uint32                 `protobuf:"varint,6,opt,name=udp_dest_port,json=udpDestPort,proto3" json:"udp_dest_port,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TunnelEncap) Reset() {
+	*x = TunnelEncap{}
+	mi := &file_bgpdash_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TunnelEncap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TunnelEncap) ProtoMessage() {}
+
+func (x *TunnelEncap) ProtoReflect() protoreflect.Message {
+	mi := &file_bgpdash_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TunnelEncap.ProtoReflect.Descriptor instead.
+func (*TunnelEncap) Descriptor() ([]byte, []int) {
+	return file_bgpdash_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TunnelEncap) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *TunnelEncap) GetKey() uint32 {
+	if x != nil && x.Key != nil {
+		return *x.Key
+	}
+	return 0
+}
+
+func (x *TunnelEncap) GetCookie() []byte {
+	if x != nil {
+		return x.Cookie
+	}
+	return nil
+}
+
+func (x *TunnelEncap) GetColor() uint32 {
+	if x != nil && x.Color != nil {
+		return *x.Color
+	}
+	return 0
+}
+
+func (x *TunnelEncap) GetEgressEndpoint() string {
+	if x != nil {
+		return x.EgressEndpoint
+	}
+	return ""
+}
+
+func (x *TunnelEncap) GetUdpDestPort() uint32 {
+	if x != nil {
+		return x.UdpDestPort
+	}
+	return 0
+}
+
+var File_bgpdash_proto protoreflect.FileDescriptor
+
+const file_bgpdash_proto_rawDesc = "" +
+	"\n" +
+	"\rbgpdash.proto\x12\abgpdash\"D\n" +
+	"\x13WatchUpdatesRequest\x12-\n" +
+	"\x06filter\x18\x01 \x01(\v2\x15.bgpdash.UpdateFilterR\x06filter\"\x89\x01\n" +
+	"\fUpdateFilter\x12\x1a\n" +
+	"\bprefixes\x18\x01 \x03(\tR\bprefixes\x12 \n" +
+	"\vcommunities\x18\x02 \x03(\tR\vcommunities\x12\x1f\n" +
+	"\vrpki_states\x18\x03 \x03(\tR\n" +
+	"rpkiStates\x12\x1a\n" +
+	"\bfamilies\x18\x04 \x03(\tR\bfamilies\"8\n" +
+	"\x06Prefix\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\rR\x06length\"\x1f\n" +
+	"\tAsSegment\x12\x12\n" +
+	"\x04asns\x18\x01 \x03(\rR\x04asns\"\xb0\x0e\n" +
+	"\x06Update\x12\x1b\n" +
+	"\tfrom_peer\x18\x01 \x01(\tR\bfromPeer\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12\x1f\n" +
+	"\vis_withdraw\x18\x03 \x01(\bR\n" +
+	"isWithdraw\x12#\n" +
+	"\x04nlri\x18\x04 \x03(\v2\x0f.bgpdash.PrefixR\x04nlri\x12\x19\n" +
+	"\bnext_hop\x18\x05 \x01(\tR\anextHop\x12+\n" +
+	"\aas_path\x18\x06 \x03(\v2\x12.bgpdash.AsSegmentR\x06asPath\x12\x1b\n" +
+	"\x06origin\x18\a \x01(\rH\x00R\x06origin\x88\x01\x01\x12\x15\n" +
+	"\x03med\x18\b \x01(\rH\x01R\x03med\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"local_pref\x18\t \x01(\rH\x02R\tlocalPref\x88\x01\x01\x12 \n" +
+	"\vcommunities\x18\n" +
+	" \x03(\tR\vcommunities\x12D\n" +
+	"\x11large_communities\x18\v \x03(\v2\x17.bgpdash.LargeCommunityR\x10largeCommunities\x127\n" +
+	"\x15rpki_validation_state\x18\f \x01(\tH\x03R\x13rpkiValidationState\x88\x01\x01\x12#\n" +
+	"\roriginator_id\x18\r \x01(\tR\foriginatorId\x12!\n" +
+	"\fcluster_list\x18\x0e \x03(\tR\vclusterList\x12\x17\n" +
+	"\aas_loop\x18\x0f \x01(\bR\x06asLoop\x12!\n" +
+	"\fparse_errors\x18\x10 \x03(\tR\vparseErrors\x126\n" +
+	"\x17withdrawn_routes_length\x18\x11 \x01(\rR\x15withdrawnRoutesLength\x12:\n" +
+	"\x10withdrawn_routes\x18\x12 \x03(\v2\x0f.bgpdash.PrefixR\x0fwithdrawnRoutes\x12=\n" +
+	"\x1btotal_path_attribute_length\x18\x13 \x01(\rR\x18totalPathAttributeLength\x12\x1b\n" +
+	"\torigin_as\x18\x14 \x01(\rR\boriginAs\x12)\n" +
+	"\x10atomic_aggregate\x18\x15 \x01(\bR\x0fatomicAggregate\x12(\n" +
+	"\raggregator_as\x18\x16 \x01(\rH\x04R\faggregatorAs\x88\x01\x01\x12-\n" +
+	"\x12aggregator_address\x18\x17 \x01(\tR\x11aggregatorAddress\x12\x17\n" +
+	"\x04aigp\x18\x18 \x01(\x04H\x05R\x04aigp\x88\x01\x01\x12-\n" +
+	"\x10only_to_customer\x18\x19 \x01(\rH\x06R\x0eonlyToCustomer\x88\x01\x01\x12'\n" +
+	"\x10as_path_too_long\x18\x1a \x01(\bR\rasPathTooLong\x12)\n" +
+	"\x10community_values\x18\x1b \x03(\rR\x0fcommunityValues\x121\n" +
+	"\x14extended_communities\x18\x1c \x03(\fR\x13extendedCommunities\x12+\n" +
+	"\bmp_reach\x18\x1d \x01(\v2\x10.bgpdash.MpReachR\ampReach\x121\n" +
+	"\n" +
+	"mp_unreach\x18\x1e \x01(\v2\x12.bgpdash.MpUnreachR\tmpUnreach\x12/\n" +
+	"\x13route_distinguisher\x18\x1f \x01(\tR\x12routeDistinguisher\x12\x16\n" +
+	"\x06labels\x18  \x03(\rR\x06labels\x121\n" +
+	"\bflowspec\x18! \x01(\v2\x15.bgpdash.FlowSpecRuleR\bflowspec\x12&\n" +
+	"\x04evpn\x18\" \x01(\v2\x12.bgpdash.EvpnRouteR\x04evpn\x124\n" +
+	"\vpmsi_tunnel\x18# \x01(\v2\x13.bgpdash.PmsiTunnelR\n" +
+	"pmsiTunnel\x121\n" +
+	"\x15received_at_unix_nano\x18$ \x01(\x03R\x12receivedAtUnixNano\x12H\n" +
+	"\x12unknown_attributes\x18% \x03(\v2\x19.bgpdash.UnknownAttributeR\x11unknownAttributes\x12\x1a\n" +
+	"\bsequence\x18& \x01(\x04R\bsequence\x12\x12\n" +
+	"\x04ebgp\x18' \x01(\bR\x04ebgp\x121\n" +
+	"\n" +
+	"prefix_sid\x18( \x01(\v2\x12.bgpdash.PrefixSidR\tprefixSid\x127\n" +
+	"\ftunnel_encap\x18) \x03(\v2\x14.bgpdash.TunnelEncapR\vtunnelEncapB\t\n" +
+	"\a_originB\x06\n" +
+	"\x04_medB\r\n" +
+	"\v_local_prefB\x18\n" +
+	"\x16_rpki_validation_stateB\x10\n" +
+	"\x0e_aggregator_asB\a\n" +
+	"\x05_aigpB\x13\n" +
+	"\x11_only_to_customer\"R\n" +
+	"\x10UnknownAttribute\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\rR\x04type\x12\x14\n" +
+	"\x05flags\x18\x02 \x01(\rR\x05flags\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\"\xc8\x01\n" +
+	"\aMpReach\x12\x10\n" +
+	"\x03afi\x18\x01 \x01(\rR\x03afi\x12\x12\n" +
+	"\x04safi\x18\x02 \x01(\rR\x04safi\x12\x19\n" +
+	"\bnext_hop\x18\x03 \x01(\tR\anextHop\x12&\n" +
+	"\x0fnext_hop_global\x18\x04 \x01(\tR\rnextHopGlobal\x12-\n" +
+	"\x13next_hop_link_local\x18\x05 \x01(\tR\x10nextHopLinkLocal\x12%\n" +
+	"\x05nlris\x18\x06 \x03(\v2\x0f.bgpdash.PrefixR\x05nlris\"X\n" +
+	"\tMpUnreach\x12\x10\n" +
+	"\x03afi\x18\x01 \x01(\rR\x03afi\x12\x12\n" +
+	"\x04safi\x18\x02 \x01(\rR\x04safi\x12%\n" +
+	"\x05nlris\x18\x03 \x03(\v2\x0f.bgpdash.PrefixR\x05nlris\"\xfc\x01\n" +
+	"\fFlowSpecRule\x12-\n" +
+	"\x12destination_prefix\x18\x01 \x01(\tR\x11destinationPrefix\x12#\n" +
+	"\rsource_prefix\x18\x02 \x01(\tR\fsourcePrefix\x12\x1c\n" +
+	"\tprotocols\x18\x03 \x03(\tR\tprotocols\x12\x14\n" +
+	"\x05ports\x18\x04 \x03(\tR\x05ports\x12+\n" +
+	"\x11destination_ports\x18\x05 \x03(\tR\x10destinationPorts\x12!\n" +
+	"\fsource_ports\x18\x06 \x03(\tR\vsourcePorts\x12\x14\n" +
+	"\x05other\x18\a \x03(\rR\x05other\"\xf7\x01\n" +
+	"\tEvpnRoute\x12\x1d\n" +
+	"\n" +
+	"route_type\x18\x01 \x01(\rR\trouteType\x12/\n" +
+	"\x13route_distinguisher\x18\x02 \x01(\tR\x12routeDistinguisher\x12!\n" +
+	"\fethernet_tag\x18\x03 \x01(\rR\vethernetTag\x12\x10\n" +
+	"\x03mac\x18\x04 \x01(\tR\x03mac\x12\x0e\n" +
+	"\x02ip\x18\x05 \x01(\tR\x02ip\x12#\n" +
+	"\rprefix_length\x18\x06 \x01(\rR\fprefixLength\x12\x18\n" +
+	"\agateway\x18\a \x01(\tR\agateway\x12\x16\n" +
+	"\x06labels\x18\b \x03(\rR\x06labels\"S\n" +
+	"\n" +
+	"PmsiTunnel\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\rR\x04type\x12\x14\n" +
+	"\x05label\x18\x02 \x01(\rR\x05label\x12\x1b\n" +
+	"\ttunnel_id\x18\x03 \x01(\tR\btunnelId\"\x7f\n" +
+	"\tPrefixSid\x128\n" +
+	"\x0fsrv6_l3_service\x18\x01 \x03(\v2\x10.bgpdash.Srv6SidR\rsrv6L3Service\x128\n" +
+	"\x0fsrv6_l2_service\x18\x02 \x03(\v2\x10.bgpdash.Srv6SidR\rsrv6L2Service\"\x81\x01\n" +
+	"\aSrv6Sid\x12\x10\n" +
+	"\x03sid\x18\x01 \x01(\tR\x03sid\x12+\n" +
+	"\x11endpoint_behavior\x18\x02 \x01(\rR\x10endpointBehavior\x127\n" +
+	"\tstructure\x18\x03 \x01(\v2\x19.bgpdash.Srv6SidStructureR\tstructure\"\xac\x02\n" +
+	"\x10Srv6SidStructure\x120\n" +
+	"\x14locator_block_length\x18\x01 \x01(\rR\x12locatorBlockLength\x12.\n" +
+	"\x13locator_node_length\x18\x02 \x01(\rR\x11locatorNodeLength\x12'\n" +
+	"\x0ffunction_length\x18\x03 \x01(\rR\x0efunctionLength\x12'\n" +
+	"\x0fargument_length\x18\x04 \x01(\rR\x0eargumentLength\x121\n" +
+	"\x14transposition_length\x18\x05 \x01(\rR\x13transpositionLength\x121\n" +
+	"\x14transposition_offset\x18\x06 \x01(\rR\x13transpositionOffset\"u\n" +
+	"\x0eLargeCommunity\x12!\n" +
+	"\fglobal_admin\x18\x01 \x01(\rR\vglobalAdmin\x12\x1f\n" +
+	"\vlocal_data1\x18\x02 \x01(\rR\n" +
+	"localData1\x12\x1f\n" +
+	"\vlocal_data2\x18\x03 \x01(\rR\n" +
+	"localData2\"\xca\x01\n" +
+	"\vTunnelEncap\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\rR\x04type\x12\x15\n" +
+	"\x03key\x18\x02 \x01(\rH\x00R\x03key\x88\x01\x01\x12\x16\n" +
+	"\x06cookie\x18\x03 \x01(\fR\x06cookie\x12\x19\n" +
+	"\x05color\x18\x04 \x01(\rH\x01R\x05color\x88\x01\x01\x12'\n" +
+	"\x0fegress_endpoint\x18\x05 \x01(\tR\x0eegressEndpoint\x12\"\n" +
+	"\rudp_dest_port\x18\x06 \x01(\rR\vudpDestPortB\x06\n" +
+	"\x04_keyB\b\n" +
+	"\x06_color2P\n" +
+	"\rBgpDashStream\x12?\n" +
+	"\fWatchUpdates\x12\x1c.bgpdash.WatchUpdatesRequest\x1a\x0f.bgpdash.Update0\x01B\x1dZ\x1bbgp_dashboard/pkg/bgpdashpbb\x06proto3"
+
+var (
+	file_bgpdash_proto_rawDescOnce sync.Once
+	file_bgpdash_proto_rawDescData []byte
+)
+
+func file_bgpdash_proto_rawDescGZIP() []byte {
+	file_bgpdash_proto_rawDescOnce.Do(func() {
+		file_bgpdash_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bgpdash_proto_rawDesc), len(file_bgpdash_proto_rawDesc)))
+	})
+	return file_bgpdash_proto_rawDescData
+}
+
+var file_bgpdash_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_bgpdash_proto_goTypes = []any{
+	(*WatchUpdatesRequest)(nil), // 0: bgpdash.WatchUpdatesRequest
+	(*UpdateFilter)(nil),        // 1: bgpdash.UpdateFilter
+	(*Prefix)(nil),              // 2: bgpdash.Prefix
+	(*AsSegment)(nil),           // 3: bgpdash.AsSegment
+	(*Update)(nil),              // 4: bgpdash.Update
+	(*UnknownAttribute)(nil),    // 5: bgpdash.UnknownAttribute
+	(*MpReach)(nil),             // 6: bgpdash.MpReach
+	(*MpUnreach)(nil),           // 7: bgpdash.MpUnreach
+	(*FlowSpecRule)(nil),        // 8: bgpdash.FlowSpecRule
+	(*EvpnRoute)(nil),           // 9: bgpdash.EvpnRoute
+	(*PmsiTunnel)(nil),          // 10: bgpdash.PmsiTunnel
+	(*PrefixSid)(nil),           // 11: bgpdash.PrefixSid
+	(*Srv6Sid)(nil),             // 12: bgpdash.Srv6Sid
+	(*Srv6SidStructure)(nil),    // 13: bgpdash.Srv6SidStructure
+	(*LargeCommunity)(nil),      // 14: bgpdash.LargeCommunity
+	(*TunnelEncap)(nil),         // 15: bgpdash.TunnelEncap
+}
+var file_bgpdash_proto_depIdxs = []int32{
+	1,  // 0: bgpdash.WatchUpdatesRequest.filter:type_name -> bgpdash.UpdateFilter
+	2,  // 1: bgpdash.Update.nlri:type_name -> bgpdash.Prefix
+	3,  // 2: bgpdash.Update.as_path:type_name -> bgpdash.AsSegment
+	14, // 3: bgpdash.Update.large_communities:type_name -> bgpdash.LargeCommunity
+	2,  // 4: bgpdash.Update.withdrawn_routes:type_name -> bgpdash.Prefix
+	6,  // 5: bgpdash.Update.mp_reach:type_name -> bgpdash.MpReach
+	7,  // 6: bgpdash.Update.mp_unreach:type_name -> bgpdash.MpUnreach
+	8,  // 7: bgpdash.Update.flowspec:type_name -> bgpdash.FlowSpecRule
+	9,  // 8: bgpdash.Update.evpn:type_name -> bgpdash.EvpnRoute
+	10, // 9: bgpdash.Update.pmsi_tunnel:type_name -> bgpdash.PmsiTunnel
+	5,  // 10: bgpdash.Update.unknown_attributes:type_name -> bgpdash.UnknownAttribute
+	11, // 11: bgpdash.Update.prefix_sid:type_name -> bgpdash.PrefixSid
+	15, // 12: bgpdash.Update.tunnel_encap:type_name -> bgpdash.TunnelEncap
+	2,  // 13: bgpdash.MpReach.nlris:type_name -> bgpdash.Prefix
+	2,  // 14: bgpdash.MpUnreach.nlris:type_name -> bgpdash.Prefix
+	12, // 15: bgpdash.PrefixSid.srv6_l3_service:type_name -> bgpdash.Srv6Sid
+	12, // 16: bgpdash.PrefixSid.srv6_l2_service:type_name -> bgpdash.Srv6Sid
+	13, // 17: bgpdash.Srv6Sid.structure:type_name -> bgpdash.Srv6SidStructure
+	0,  // 18: bgpdash.BgpDashStream.WatchUpdates:input_type -> bgpdash.WatchUpdatesRequest
+	4,  // 19: bgpdash.BgpDashStream.WatchUpdates:output_type -> bgpdash.Update
+	19, // [19:20] is the sub-list for method output_type
+	18, // [18:19] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_bgpdash_proto_init() }
+func file_bgpdash_proto_init() {
+	if File_bgpdash_proto != nil {
+		return
+	}
+	file_bgpdash_proto_msgTypes[4].OneofWrappers = []any{}
+	file_bgpdash_proto_msgTypes[15].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bgpdash_proto_rawDesc), len(file_bgpdash_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bgpdash_proto_goTypes,
+		DependencyIndexes: file_bgpdash_proto_depIdxs,
+		MessageInfos:      file_bgpdash_proto_msgTypes,
+	}.Build()
+	File_bgpdash_proto = out.File
+	file_bgpdash_proto_goTypes = nil
+	file_bgpdash_proto_depIdxs = nil
+}
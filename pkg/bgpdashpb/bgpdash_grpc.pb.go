@@ -0,0 +1,140 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: bgpdash.proto
+
+package bgpdashpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BgpDashStream_WatchUpdates_FullMethodName = "/bgpdash.BgpDashStream/WatchUpdates"
+)
+
+// BgpDashStreamClient is the client API for BgpDashStream service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BgpDashStreamClient interface {
+	// WatchUpdates sends every update received after the call is made
+	// The stream stays open until the client cancels or the server stops
+	WatchUpdates(ctx context.Context, in *WatchUpdatesRequest, opts ...grpc.CallOption) (BgpDashStream_WatchUpdatesClient, error)
+}
+
+type bgpDashStreamClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBgpDashStreamClient(cc grpc.ClientConnInterface) BgpDashStreamClient {
+	return &bgpDashStreamClient{cc}
+}
+
+func (c *bgpDashStreamClient) WatchUpdates(ctx context.Context, in *WatchUpdatesRequest, opts ...grpc.CallOption) (BgpDashStream_WatchUpdatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BgpDashStream_ServiceDesc.Streams[0], BgpDashStream_WatchUpdates_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bgpDashStreamWatchUpdatesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BgpDashStream_WatchUpdatesClient interface {
+	Recv() (*Update, error)
+	grpc.ClientStream
+}
+
+type bgpDashStreamWatchUpdatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *bgpDashStreamWatchUpdatesClient) Recv() (*Update, error) {
+	m := new(Update)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BgpDashStreamServer is the server API for BgpDashStream service.
+// All implementations must embed UnimplementedBgpDashStreamServer
+// for forward compatibility
+type BgpDashStreamServer interface {
+	// WatchUpdates sends every update received after the call is made
+	// The stream stays open until the client cancels or the server stops
+	WatchUpdates(*WatchUpdatesRequest, BgpDashStream_WatchUpdatesServer) error
+	mustEmbedUnimplementedBgpDashStreamServer()
+}
+
+// UnimplementedBgpDashStreamServer must be embedded to have forward compatible implementations.
+type UnimplementedBgpDashStreamServer struct {
+}
+
+func (UnimplementedBgpDashStreamServer) WatchUpdates(*WatchUpdatesRequest, BgpDashStream_WatchUpdatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUpdates not implemented")
+}
+func (UnimplementedBgpDashStreamServer) mustEmbedUnimplementedBgpDashStreamServer() {}
+
+// UnsafeBgpDashStreamServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BgpDashStreamServer will
+// result in compilation errors.
+type UnsafeBgpDashStreamServer interface {
+	mustEmbedUnimplementedBgpDashStreamServer()
+}
+
+func RegisterBgpDashStreamServer(s grpc.ServiceRegistrar, srv BgpDashStreamServer) {
+	s.RegisterService(&BgpDashStream_ServiceDesc, srv)
+}
+
+func _BgpDashStream_WatchUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUpdatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BgpDashStreamServer).WatchUpdates(m, &bgpDashStreamWatchUpdatesServer{stream})
+}
+
+type BgpDashStream_WatchUpdatesServer interface {
+	Send(*Update) error
+	grpc.ServerStream
+}
+
+type bgpDashStreamWatchUpdatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *bgpDashStreamWatchUpdatesServer) Send(m *Update) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// BgpDashStream_ServiceDesc is the grpc.ServiceDesc for BgpDashStream service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BgpDashStream_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bgpdash.BgpDashStream",
+	HandlerType: (*BgpDashStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchUpdates",
+			Handler:       _BgpDashStream_WatchUpdates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "bgpdash.proto",
+}
@@ -0,0 +1,69 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+)
+
+// bmpPort is the port BMP stations conventionally listen on (RFC 7854 leaves it open)
+const bmpPort = 11019
+
+// BMPStationConfig is a BMP collector (RFC 7854) GoBGP streams its RIBs to
+type BMPStationConfig struct {
+	Address string `yaml:"address"` // Station address, e.g. "192.0.2.50"
+	Port    uint16 `yaml:"port"`    // 11019 when 0
+
+	// Policy picks the routes reported: pre-policy (default), post-policy,
+	// both, local-rib or all
+	Policy string `yaml:"policy"`
+
+	// StatisticsInterval is the seconds between statistics reports, 0 disables them
+	StatisticsInterval int32 `yaml:"statisticsInterval"`
+}
+
+// bmpPolicies maps BMPStationConfig.Policy to GoBGP monitoring policies
+var bmpPolicies = map[string]api.AddBmpRequest_MonitoringPolicy{
+	"":            api.AddBmpRequest_PRE,
+	"pre-policy":  api.AddBmpRequest_PRE,
+	"post-policy": api.AddBmpRequest_POST,
+	"both":        api.AddBmpRequest_BOTH,
+	"local-rib":   api.AddBmpRequest_LOCAL,
+	"all":         api.AddBmpRequest_ALL,
+}
+
+// validateBMPStations checks every station has an address and a known policy
+func validateBMPStations(stations []BMPStationConfig) error {
+	for i, station := range stations {
+		if station.Address == "" {
+			return fmt.Errorf("bmp[%d]: address is required", i)
+		}
+		if _, ok := bmpPolicies[station.Policy]; !ok {
+			return fmt.Errorf("bmp station %s: invalid policy %q, expected pre-policy, post-policy, both, local-rib or all", station.Address, station.Policy)
+		}
+	}
+	return nil
+}
+
+// addBMPStations starts monitoring to every station under bmp
+// GoBGP keeps reconnecting to a station that is down, so an unreachable
+// collector does not stop the service
+func (s *BGPService) addBMPStations() error {
+	build := GetBuildInfo()
+	for _, station := range s.config.BMP {
+		port := uint32(station.Port)
+		if port == 0 {
+			port = bmpPort
+		}
+		if err := s.server.AddBmp(s.context, &api.AddBmpRequest{
+			Address:           station.Address,
+			Port:              port,
+			Policy:            bmpPolicies[station.Policy],
+			StatisticsTimeout: station.StatisticsInterval,
+			SysName:           "bgpdash",
+			SysDescr:          "bgpdash " + build.Version,
+		}); err != nil {
+			return fmt.Errorf("adding BMP station %s: %w", station.Address, err)
+		}
+	}
+	return nil
+}
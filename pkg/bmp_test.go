@@ -0,0 +1,43 @@
+package pkg
+
+import (
+	api "github.com/osrg/gobgp/v3/api"
+	"testing"
+)
+
+// TestBMPStations verifies Start issues one AddBmp per configured station
+func TestBMPStations(t *testing.T) {
+	config := &Config{}
+	config.BMP = []BMPStationConfig{
+		{Address: "192.0.2.50"},
+		{Address: "192.0.2.51", Port: 5000, Policy: "post-policy", StatisticsInterval: 60},
+	}
+	_, fake := newFakeService(t, config)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if len(fake.bmpStations) != 2 {
+		t.Fatalf("AddBmp called %d times, want 2", len(fake.bmpStations))
+	}
+	first, second := fake.bmpStations[0], fake.bmpStations[1]
+	if first.Address != "192.0.2.50" || first.Port != bmpPort || first.Policy != api.AddBmpRequest_PRE {
+		t.Errorf("first station = %v, want 192.0.2.50:%d pre-policy", first, bmpPort)
+	}
+	if second.Address != "192.0.2.51" || second.Port != 5000 || second.Policy != api.AddBmpRequest_POST || second.StatisticsTimeout != 60 {
+		t.Errorf("second station = %v, want 192.0.2.51:5000 post-policy with 60s statistics", second)
+	}
+}
+
+// TestBMPStationsInvalid verifies Start rejects a station without an address or with an unknown policy
+func TestBMPStationsInvalid(t *testing.T) {
+	for _, station := range []BMPStationConfig{
+		{Policy: "pre-policy"},
+		{Address: "192.0.2.50", Policy: "adj-rib-out"},
+	} {
+		config := &Config{}
+		config.BMP = []BMPStationConfig{station}
+		if err := NewBGPServiceWithServer(config, newFakeBgpServer()).Start("192.0.2.254", 65001); err == nil {
+			t.Errorf("Start with BMP station %+v succeeded, want an error", station)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"log"
+	"net"
+)
+
+// DynamicNeighborConfig accepts incoming sessions from any address in Prefix
+// Peers are created on connect with the settings of PeerGroup and any ASN,
+// which is learned from their OPEN message
+type DynamicNeighborConfig struct {
+	Prefix    string `yaml:"prefix"`    // e.g. "192.0.2.0/24"
+	PeerGroup string `yaml:"peerGroup"` // Must name an entry under bgp.peerGroups
+}
+
+// addDynamicNeighbors registers bgp.dynamicNeighbors with GoBGP, adding
+// each referenced peer group once
+// Only the settings GoBGP negotiates itself, such as families, timers and
+// prefix limits, apply to dynamic peers; per-neighbor policies like
+// nextHopSelf or sendCommunity need the peer's address up front, and so
+// does bgp.allowedPeers, which dynamic peers are exempt from
+func (s *BGPService) addDynamicNeighbors() error {
+	if len(s.config.BGP.DynamicNeighbors) > 0 && len(s.config.BGP.AllowedPeers) > 0 {
+		log.Printf("bgp.allowedPeers does not apply to dynamic neighbors, sessions from their ranges are accepted with any ASN")
+	}
+	added := make(map[string]bool)
+	for _, dyn := range s.config.BGP.DynamicNeighbors {
+		_, ipNet, err := net.ParseCIDR(dyn.Prefix)
+		if err != nil {
+			return fmt.Errorf("dynamic neighbor: %w %q", ErrInvalidPrefix, dyn.Prefix)
+		}
+		if dyn.PeerGroup == "" {
+			return fmt.Errorf("dynamic neighbor %s: peerGroup is required", dyn.Prefix)
+		}
+		if !added[dyn.PeerGroup] {
+			group, err := s.buildPeerGroup(dyn)
+			if err != nil {
+				return err
+			}
+			if err := s.server.AddPeerGroup(s.context, &api.AddPeerGroupRequest{PeerGroup: group}); err != nil {
+				return fmt.Errorf("adding peer group %s: %w", dyn.PeerGroup, err)
+			}
+			added[dyn.PeerGroup] = true
+		}
+		if err := s.server.AddDynamicNeighbor(s.context, &api.AddDynamicNeighborRequest{
+			DynamicNeighbor: &api.DynamicNeighbor{
+				Prefix:    ipNet.String(),
+				PeerGroup: dyn.PeerGroup,
+			},
+		}); err != nil {
+			return fmt.Errorf("adding dynamic neighbor %s: %w", dyn.Prefix, err)
+		}
+	}
+	return nil
+}
+
+// buildPeerGroup translates the peer group of dyn into a GoBGP peer group,
+// reusing buildPeer for the settings the two share
+func (s *BGPService) buildPeerGroup(dyn DynamicNeighborConfig) (*api.PeerGroup, error) {
+	// The prefix stands in for the address in errors about the group
+	cfg, err := s.resolveNeighbor(NeighborConfig{PeerIP: dyn.Prefix, PeerGroup: dyn.PeerGroup})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRPKIFamilies(s.config.RPKI, cfg.Families); err != nil {
+		return nil, fmt.Errorf("peer group %s: %w", dyn.PeerGroup, err)
+	}
+	peer, err := buildPeer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &api.PeerGroup{
+		Conf: &api.PeerGroupConf{
+			PeerGroupName: dyn.PeerGroup,
+			AuthPassword:  cfg.AuthPassword,
+		},
+		Timers:          peer.Timers,
+		Transport:       &api.Transport{PassiveMode: true}, // Dynamic peers can only connect to us
+		GracefulRestart: peer.GracefulRestart,
+		AfiSafis:        peer.AfiSafis,
+	}, nil
+}
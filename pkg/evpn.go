@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"encoding/hex"
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/types/known/anypb"
+	"net"
+)
+
+// EVPN route types (RFC 7432 section 7, RFC 9136)
+const (
+	evpnMACIPAdvertisement = 2
+	evpnIPPrefix           = 5
+)
+
+// EVPNRoute is a decoded EVPN NLRI (AFI 25, SAFI 70)
+// Only the MAC/IP advertisement (type 2) and IP prefix (type 5) routes are
+// decoded; other route types leave EVPN nil
+type EVPNRoute struct {
+	RouteType          uint8
+	RouteDistinguisher string   // e.g. "65000:100"
+	EthernetTag        uint32   // Identifies the broadcast domain within the EVI
+	MAC                string   // Type 2 only, e.g. "aa:bb:cc:dd:ee:ff"
+	IP                 net.IP   // Type 2 host address, if any, or the type 5 prefix
+	PrefixLength       uint8    // Type 5 only
+	Gateway            net.IP   // Type 5 only, the overlay next hop if set
+	Labels             []uint32 // MPLS labels or VNIs carried with the route
+}
+
+// PMSITunnel is the PMSI_TUNNEL attribute (RFC 6514), which tells EVPN
+// peers how to deliver broadcast and multicast traffic to the originator
+type PMSITunnel struct {
+	Type     uint32 // Tunnel type, 6 for ingress replication
+	Label    uint32 // MPLS label or VNI
+	TunnelID string // Tunnel endpoint; an address for ingress replication, hex otherwise
+}
+
+// parseEVPN decodes an EVPN NLRI, returning nil for unsupported route types
+func parseEVPN(nlri *anypb.Any) (*EVPNRoute, error) {
+	msg, err := nlri.UnmarshalNew()
+	if err != nil {
+		return nil, err
+	}
+	switch r := msg.(type) {
+	case *api.EVPNMACIPAdvertisementRoute:
+		rd, err := routeDistinguisher(r.Rd)
+		if err != nil {
+			return nil, err
+		}
+		route := &EVPNRoute{
+			RouteType:          evpnMACIPAdvertisement,
+			RouteDistinguisher: rd,
+			EthernetTag:        r.EthernetTag,
+			MAC:                r.MacAddress,
+			Labels:             r.Labels,
+		}
+		// The IP is optional and GoBGP reports a missing one as "0.0.0.0"
+		if ip := parseIP(r.IpAddress); ip != nil && !ip.IsUnspecified() {
+			route.IP = ip
+		}
+		return route, nil
+	case *api.EVPNIPPrefixRoute:
+		rd, err := routeDistinguisher(r.Rd)
+		if err != nil {
+			return nil, err
+		}
+		route := &EVPNRoute{
+			RouteType:          evpnIPPrefix,
+			RouteDistinguisher: rd,
+			EthernetTag:        r.EthernetTag,
+			IP:                 parseIP(r.IpPrefix),
+			PrefixLength:       uint8(r.IpPrefixLen),
+			Labels:             []uint32{r.Label},
+		}
+		if route.IP == nil {
+			return nil, fmt.Errorf("evpn: invalid prefix %q", r.IpPrefix)
+		}
+		if gw := parseIP(r.GwAddress); gw != nil && !gw.IsUnspecified() {
+			route.Gateway = gw
+		}
+		return route, nil
+	}
+	return nil, nil
+}
+
+// parsePMSITunnel converts the PMSI_TUNNEL attribute
+func parsePMSITunnel(a *api.PmsiTunnelAttribute) *PMSITunnel {
+	tunnel := &PMSITunnel{Type: a.Type, Label: a.Label}
+	if len(a.Id) == net.IPv4len || len(a.Id) == net.IPv6len {
+		tunnel.TunnelID = net.IP(a.Id).String()
+	} else {
+		tunnel.TunnelID = hex.EncodeToString(a.Id)
+	}
+	return tunnel
+}
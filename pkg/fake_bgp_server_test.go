@@ -0,0 +1,386 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/proto"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBgpServer is an in-memory BgpServer for unit tests
+// Peer and path calls are recorded and served back from memory; peer
+// groups, dynamic neighbors and policies are not modelled and fail with
+// errFakeUnsupported
+type fakeBgpServer struct {
+	mu           sync.Mutex
+	started      *api.StartBgpRequest
+	stopped      bool
+	peers        []*api.Peer
+	addPeers     []*api.AddPeerRequest
+	resets       []*api.ResetPeerRequest
+	disables     []*api.DisablePeerRequest
+	bmpStations  []*api.AddBmpRequest
+	rpkiServers  []*api.AddRpkiRequest
+	paths        []*api.Path
+	watchers     []func(*api.WatchEventResponse)
+	tableWatches []*api.WatchEventRequest
+
+	peerWatchers []func(*api.WatchEventResponse) // Watches on peer events, fed by emitPeerState
+	getBgpBlock  chan struct{}                   // When set, GetBgp hangs until it is closed
+}
+
+func newFakeBgpServer() *fakeBgpServer {
+	return &fakeBgpServer{}
+}
+
+func (f *fakeBgpServer) Serve() {}
+
+func (f *fakeBgpServer) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+}
+
+func (f *fakeBgpServer) StartBgp(_ context.Context, r *api.StartBgpRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.started != nil && !f.stopped {
+		return errors.New("already started")
+	}
+	f.started, f.stopped = r, false
+	return nil
+}
+
+// GetBgp answers straight away unless getBgpBlock is set, in which case it
+// hangs like a wedged GoBGP until the channel is closed
+func (f *fakeBgpServer) GetBgp(_ context.Context, _ *api.GetBgpRequest) (*api.GetBgpResponse, error) {
+	f.mu.Lock()
+	block, started := f.getBgpBlock, f.started
+	f.mu.Unlock()
+	if block != nil {
+		<-block
+	}
+	return &api.GetBgpResponse{Global: started.GetGlobal()}, nil
+}
+
+func (f *fakeBgpServer) AddPeer(_ context.Context, r *api.AddPeerRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.peers {
+		if p.Conf.NeighborAddress == r.Peer.Conf.NeighborAddress {
+			return errors.New("can't overwrite the existing peer")
+		}
+	}
+	f.addPeers = append(f.addPeers, r)
+	f.peers = append(f.peers, &api.Peer{Conf: r.Peer.Conf, State: &api.PeerState{}})
+	return nil
+}
+
+func (f *fakeBgpServer) DeletePeer(_ context.Context, r *api.DeletePeerRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, p := range f.peers {
+		if p.Conf.NeighborAddress == r.Address {
+			f.peers = append(f.peers[:i], f.peers[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("peer not found")
+}
+
+// UpdatePeer replaces the stored configuration of an existing peer
+func (f *fakeBgpServer) UpdatePeer(_ context.Context, r *api.UpdatePeerRequest) (*api.UpdatePeerResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.peers {
+		if p.Conf.NeighborAddress == r.Peer.Conf.NeighborAddress {
+			p.Conf = r.Peer.Conf
+			return &api.UpdatePeerResponse{}, nil
+		}
+	}
+	return nil, errors.New("peer not found")
+}
+
+func (f *fakeBgpServer) ListPeer(_ context.Context, r *api.ListPeerRequest, fn func(*api.Peer)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.peers {
+		if r.Address == "" || r.Address == p.Conf.NeighborAddress {
+			fn(p)
+		}
+	}
+	return nil
+}
+
+func (f *fakeBgpServer) ResetPeer(_ context.Context, r *api.ResetPeerRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resets = append(f.resets, r)
+	return nil
+}
+
+func (f *fakeBgpServer) EnablePeer(_ context.Context, r *api.EnablePeerRequest) error {
+	return f.setAdminState(r.Address, api.PeerState_UP)
+}
+
+func (f *fakeBgpServer) DisablePeer(_ context.Context, r *api.DisablePeerRequest) error {
+	f.mu.Lock()
+	f.disables = append(f.disables, r)
+	f.mu.Unlock()
+	return f.setAdminState(r.Address, api.PeerState_DOWN)
+}
+
+func (f *fakeBgpServer) setAdminState(address string, state api.PeerState_AdminState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range f.peers {
+		if p.Conf.NeighborAddress == address {
+			p.State.AdminState = state
+			return nil
+		}
+	}
+	return errors.New("peer not found")
+}
+
+func (f *fakeBgpServer) AddBmp(_ context.Context, r *api.AddBmpRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bmpStations = append(f.bmpStations, r)
+	return nil
+}
+
+func (f *fakeBgpServer) AddRpki(_ context.Context, r *api.AddRpkiRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rpkiServers = append(f.rpkiServers, r)
+	return nil
+}
+
+// WatchEvent registers fn for events sent with emit and, like GoBGP,
+// returns straight away
+func (f *fakeBgpServer) WatchEvent(_ context.Context, r *api.WatchEventRequest, fn func(*api.WatchEventResponse)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.GetPeer() != nil {
+		f.peerWatchers = append(f.peerWatchers, fn)
+		return nil
+	}
+	f.watchers = append(f.watchers, fn)
+	f.tableWatches = append(f.tableWatches, r)
+	return nil
+}
+
+// waitForWatch blocks until the service's watch goroutine has registered
+func (f *fakeBgpServer) waitForWatch(t *testing.T) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		f.mu.Lock()
+		n := len(f.watchers)
+		f.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for WatchEvent")
+		}
+	}
+}
+
+// waitForPeerWatch blocks until the service's peer state watch has registered
+func (f *fakeBgpServer) waitForPeerWatch(t *testing.T) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		f.mu.Lock()
+		n := len(f.peerWatchers)
+		f.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the peer state watch")
+		}
+	}
+}
+
+// emit delivers paths to every registered watcher as one table event
+func (f *fakeBgpServer) emit(paths ...*api.Path) {
+	f.mu.Lock()
+	watchers := append([]func(*api.WatchEventResponse){}, f.watchers...)
+	f.mu.Unlock()
+
+	event := &api.WatchEventResponse{Event: &api.WatchEventResponse_Table{
+		Table: &api.WatchEventResponse_TableEvent{Paths: paths},
+	}}
+	for _, fn := range watchers {
+		fn(event)
+	}
+}
+
+// emitPeerState delivers a session state change to every peer watcher
+func (f *fakeBgpServer) emitPeerState(address string, state api.PeerState_SessionState) {
+	f.mu.Lock()
+	watchers := append([]func(*api.WatchEventResponse){}, f.peerWatchers...)
+	f.mu.Unlock()
+
+	event := &api.WatchEventResponse{Event: &api.WatchEventResponse_Peer{
+		Peer: &api.WatchEventResponse_PeerEvent{
+			Type: api.WatchEventResponse_PeerEvent_STATE,
+			Peer: &api.Peer{State: &api.PeerState{NeighborAddress: address, SessionState: state}},
+		},
+	}}
+	for _, fn := range watchers {
+		fn(event)
+	}
+}
+
+func (f *fakeBgpServer) AddPath(_ context.Context, r *api.AddPathRequest) (*api.AddPathResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, r.Path)
+	return &api.AddPathResponse{}, nil
+}
+
+func (f *fakeBgpServer) DeletePath(context.Context, *api.DeletePathRequest) error {
+	return nil
+}
+
+// GetTable counts the stored paths of the requested family
+func (f *fakeBgpServer) GetTable(_ context.Context, r *api.GetTableRequest) (*api.GetTableResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n uint64
+	for _, p := range f.paths {
+		if proto.Equal(p.GetFamily(), r.GetFamily()) {
+			n++
+		}
+	}
+	return &api.GetTableResponse{NumPath: n}, nil
+}
+
+// ListPath returns the stored paths of the requested family, or without
+// one, grouped into destinations
+func (f *fakeBgpServer) ListPath(_ context.Context, r *api.ListPathRequest, fn func(*api.Destination)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Paths to the same NLRI share a destination, as in GoBGP's RIB
+	var dests []*api.Destination
+	byNLRI := make(map[string]*api.Destination)
+	for _, p := range f.paths {
+		if p.GetFamily() != nil && !proto.Equal(p.GetFamily(), r.GetFamily()) {
+			continue
+		}
+		key := p.GetNlri().String()
+		d, ok := byNLRI[key]
+		if !ok {
+			d = &api.Destination{}
+			byNLRI[key] = d
+			dests = append(dests, d)
+		}
+		d.Paths = append(d.Paths, p)
+	}
+	for _, d := range dests {
+		fn(d)
+	}
+	return nil
+}
+
+// errFakeUnsupported is returned by the fakeBgpServer methods it does not model
+var errFakeUnsupported = errors.New("not supported by fakeBgpServer")
+
+func (f *fakeBgpServer) AddPeerGroup(context.Context, *api.AddPeerGroupRequest) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) AddDynamicNeighbor(context.Context, *api.AddDynamicNeighborRequest) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) AddDefinedSet(context.Context, *api.AddDefinedSetRequest) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) DeleteDefinedSet(context.Context, *api.DeleteDefinedSetRequest) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) ListDefinedSet(context.Context, *api.ListDefinedSetRequest, func(*api.DefinedSet)) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) AddPolicy(context.Context, *api.AddPolicyRequest) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) DeletePolicy(context.Context, *api.DeletePolicyRequest) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) ListPolicy(context.Context, *api.ListPolicyRequest, func(*api.Policy)) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) ListPolicyAssignment(context.Context, *api.ListPolicyAssignmentRequest, func(*api.PolicyAssignment)) error {
+	return errFakeUnsupported
+}
+
+func (f *fakeBgpServer) SetPolicyAssignment(context.Context, *api.SetPolicyAssignmentRequest) error {
+	return errFakeUnsupported
+}
+
+// newFakeService returns a started BGPService backed by a fakeBgpServer
+func newFakeService(t *testing.T, config *Config) (*BGPService, *fakeBgpServer) {
+	t.Helper()
+	fake := newFakeBgpServer()
+	s := NewBGPServiceWithServer(config, fake)
+	if err := s.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Failed to start BGP service: %v", err)
+	}
+	t.Cleanup(s.Stop)
+	return s, fake
+}
+
+// TestFakeAddNeighbor verifies AddNeighbor issues the expected AddPeer call
+func TestFakeAddNeighbor(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor error = %v", err)
+	}
+
+	if len(fake.addPeers) != 1 {
+		t.Fatalf("AddPeer called %d times, want 1", len(fake.addPeers))
+	}
+	conf := fake.addPeers[0].GetPeer().GetConf()
+	if conf.GetNeighborAddress() != "192.0.2.1" || conf.GetPeerAsn() != 65002 {
+		t.Errorf("AddPeer conf = %v, want 192.0.2.1 AS65002", conf)
+	}
+	if fake.started.GetGlobal().GetAsn() != 65001 {
+		t.Errorf("StartBgp ASN = %d, want 65001", fake.started.GetGlobal().GetAsn())
+	}
+}
+
+// TestFakeWatchDispatch verifies watched paths reach subscribers without a real server
+func TestFakeWatchDispatch(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	updates, unsubscribe := bgpService.Subscribe()
+	defer unsubscribe()
+
+	fake.waitForWatch(t)
+	fake.emit(&api.Path{
+		Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+		NeighborIp: "192.0.2.1",
+		Family:     &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST},
+	})
+
+	select {
+	case update := <-updates:
+		if len(update.NLRI) != 1 || update.NLRI[0].Prefix.String() != "10.0.0.0" {
+			t.Errorf("NLRI = %v, want 10.0.0.0/24", update.NLRI)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the update")
+	}
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// outputFields maps each selectable field name to the BGPUpdateMessage
+// fields it carries; copying them is all a selection has to do
+var outputFields = map[string]func(dst, src *BGPUpdateMessage){
+	"prefix": func(dst, src *BGPUpdateMessage) {
+		dst.NLRI = src.NLRI
+		dst.WithdrawnRoutesLength = src.WithdrawnRoutesLength
+		dst.WithdrawnRoutes = src.WithdrawnRoutes
+		dst.MPReachNLRI = src.MPReachNLRI
+		dst.MPUnreachNLRI = src.MPUnreachNLRI
+		dst.RouteDistinguisher = src.RouteDistinguisher
+		dst.Labels = src.Labels
+		dst.FlowSpec = src.FlowSpec
+		dst.EVPN = src.EVPN
+		dst.PMSITunnel = src.PMSITunnel
+		dst.PrefixSID = src.PrefixSID
+		dst.TunnelEncap = src.TunnelEncap
+	},
+	"peer": func(dst, src *BGPUpdateMessage) {
+		dst.FromPeer = src.FromPeer
+		dst.PathID = src.PathID
+		dst.EBGP = src.EBGP
+	},
+	"timestamp": func(dst, src *BGPUpdateMessage) {
+		dst.Timestamp = src.Timestamp
+		dst.ReceivedAt = src.ReceivedAt
+	},
+	"withdraw": func(dst, src *BGPUpdateMessage) { dst.IsWithdraw = src.IsWithdraw },
+	"as_path": func(dst, src *BGPUpdateMessage) {
+		dst.ASPath = src.ASPath
+		dst.OriginAS = src.OriginAS
+		dst.ASLoop = src.ASLoop
+		dst.ASPathTooLong = src.ASPathTooLong
+	},
+	"next_hop":   func(dst, src *BGPUpdateMessage) { dst.NextHop = src.NextHop },
+	"origin":     func(dst, src *BGPUpdateMessage) { dst.Origin = src.Origin },
+	"med":        func(dst, src *BGPUpdateMessage) { dst.MED = src.MED },
+	"local_pref": func(dst, src *BGPUpdateMessage) { dst.LocalPref = src.LocalPref },
+	"aggregator": func(dst, src *BGPUpdateMessage) {
+		dst.AtomicAggregate = src.AtomicAggregate
+		dst.AggregatorAS = src.AggregatorAS
+		dst.AggregatorAddress = src.AggregatorAddress
+	},
+	"aigp": func(dst, src *BGPUpdateMessage) { dst.AIGP = src.AIGP },
+	"otc":  func(dst, src *BGPUpdateMessage) { dst.OnlyToCustomer = src.OnlyToCustomer },
+	"route_reflection": func(dst, src *BGPUpdateMessage) {
+		dst.OriginatorID = src.OriginatorID
+		dst.ClusterList = src.ClusterList
+	},
+	"communities": func(dst, src *BGPUpdateMessage) {
+		dst.Communities = src.Communities
+		dst.CommunityStrings = src.CommunityStrings
+	},
+	"extended_communities": func(dst, src *BGPUpdateMessage) { dst.ExtendedCommunities = src.ExtendedCommunities },
+	"large_communities":    func(dst, src *BGPUpdateMessage) { dst.LargeCommunities = src.LargeCommunities },
+	"unknown_attributes":   func(dst, src *BGPUpdateMessage) { dst.UnknownAttributes = src.UnknownAttributes },
+	"rpki":                 func(dst, src *BGPUpdateMessage) { dst.RPKIValidationState = src.RPKIValidationState },
+	"parse_errors":         func(dst, src *BGPUpdateMessage) { dst.ParseErrors = src.ParseErrors },
+}
+
+// validateFields reports any name in fields that outputFields does not know
+func validateFields(fields []string) error {
+	for _, name := range fields {
+		if _, ok := outputFields[name]; !ok {
+			known := make([]string, 0, len(outputFields))
+			for k := range outputFields {
+				known = append(known, k)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown output field %q, expected one of %s", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// selectFields returns a copy of update holding only the named fields,
+// with everything else left at its zero value
+// Sequence is always kept so consumers can still detect gaps
+// An empty selection returns the update unchanged
+func selectFields(update BGPUpdateMessage, fields []string) BGPUpdateMessage {
+	if len(fields) == 0 {
+		return update
+	}
+	trimmed := BGPUpdateMessage{Sequence: update.Sequence}
+	for _, name := range fields {
+		if copyField, ok := outputFields[name]; ok {
+			copyField(&trimmed, &update)
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,46 @@
+package pkg
+
+import (
+	"net"
+	"testing"
+)
+
+// TestSelectFields verifies only the requested fields survive a selection
+func TestSelectFields(t *testing.T) {
+	med := uint32(10)
+	config := &Config{}
+	config.Output.Fields = []string{"prefix", "as_path"}
+	bgpService := NewBGPServiceWithConfig(config)
+	updates, unsubscribe := bgpService.Subscribe()
+	defer unsubscribe()
+
+	bgpService.dispatch(BGPUpdateMessage{
+		FromPeer:         "192.0.2.1",
+		NextHop:          net.ParseIP("192.0.2.1"),
+		MED:              &med,
+		ASPath:           [][]uint32{{65002}},
+		CommunityStrings: []string{"65002:1"},
+		NLRI: []struct {
+			PrefixLength uint8
+			Prefix       net.IP
+		}{{PrefixLength: 24, Prefix: net.ParseIP("10.0.0.0")}},
+	})
+
+	got := <-updates
+	if len(got.NLRI) != 1 || len(got.ASPath) != 1 {
+		t.Errorf("NLRI = %v, ASPath = %v, want both kept", got.NLRI, got.ASPath)
+	}
+	if got.FromPeer != "" || got.NextHop != nil || got.MED != nil || got.CommunityStrings != nil {
+		t.Errorf("got %+v, want unselected fields zeroed", got)
+	}
+}
+
+// TestValidateFields verifies unknown field names are rejected
+func TestValidateFields(t *testing.T) {
+	if err := validateFields([]string{"prefix", "as_path"}); err != nil {
+		t.Errorf("validateFields() error = %v, want nil", err)
+	}
+	if err := validateFields([]string{"prefix", "aspath"}); err == nil {
+		t.Error("validateFields() accepted unknown field \"aspath\"")
+	}
+}
@@ -0,0 +1,206 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeLayout stamps rotated file names; it sorts lexically in time
+// order and is fine-grained enough that quick size rotations never collide
+const rotatedTimeLayout = "20060102T150405.000000000Z"
+
+// FileSinkConfig enables writing every update to a local NDJSON file
+// The sink is disabled when Path is empty
+type FileSinkConfig struct {
+	Path    string        `yaml:"path"`    // Active file, e.g. "/var/lib/bgpdash/updates.ndjson"
+	MaxSize int64         `yaml:"maxSize"` // Bytes before the file is rotated, 0 for no size limit
+	MaxAge  time.Duration `yaml:"maxAge"`  // Age before the file is rotated, e.g. "1h"; 0 for no limit
+	Keep    int           `yaml:"keep"`    // Rotated files kept, 0 keeps all
+	Format  UpdateFormat  `yaml:"format"`  // json (default) or protobuf
+}
+
+// FileSink is an UpdateHandler that appends each update as one JSON line
+// to a file, rotating it by size or age
+// Rotated files are renamed next to the active one with a timestamp, e.g.
+// updates-20240101T120000.000000000Z.ndjson, and can be fed to ReplayUpdates
+// With the protobuf format each update is instead written as a
+// varint length-prefixed bgpdash.Update, readable with protodelim;
+// ReplayUpdates only reads the JSON format
+type FileSink struct {
+	cfg FileSinkConfig
+	now func() time.Time // Replaced in tests to control rotation by age
+
+	mu     sync.Mutex // Guards the fields below and serializes writes
+	file   *os.File
+	size   int64     // Bytes in the active file
+	opened time.Time // When the active file was started
+}
+
+// NewFileSink opens cfg.Path for appending, creating its directory if needed
+func NewFileSink(cfg FileSinkConfig) (*FileSink, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("file sink: path is required")
+	}
+	if err := validateUpdateFormat(cfg.Format); err != nil {
+		return nil, fmt.Errorf("file sink: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, err
+	}
+	f := &FileSink{cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open starts appending to the active file; f.mu must be held or f unshared
+func (f *FileSink) open() error {
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// HandleUpdate appends the update, rotating first if it would overflow the file
+// Write errors are logged and the update dropped
+func (f *FileSink) HandleUpdate(update BGPUpdateMessage) {
+	line, err := f.encode(update)
+	if err != nil {
+		log.Printf("Error encoding update for file sink: %v", err)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return // Closed
+	}
+	if f.dueForRotation(int64(len(line))) {
+		if err := f.rotate(); err != nil {
+			log.Printf("Error rotating %s: %v", f.cfg.Path, err)
+			if f.file == nil {
+				return
+			}
+		}
+	}
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err != nil {
+		log.Printf("Error writing update to %s: %v", f.cfg.Path, err)
+	}
+}
+
+// encode returns the bytes written for update: a JSON line, or a
+// length-prefixed protobuf message
+func (f *FileSink) encode(update BGPUpdateMessage) ([]byte, error) {
+	if f.cfg.Format == FormatProtobuf {
+		var buf bytes.Buffer
+		if _, err := protodelim.MarshalTo(&buf, updateToProto(update)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	line, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// dueForRotation reports whether the active file must be rotated before
+// writing n more bytes; an empty file is never rotated
+func (f *FileSink) dueForRotation(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.cfg.MaxSize > 0 && f.size+n > f.cfg.MaxSize {
+		return true
+	}
+	return f.cfg.MaxAge > 0 && f.now().Sub(f.opened) >= f.cfg.MaxAge
+}
+
+// rotate renames the active file aside, starts a new one and prunes old ones
+func (f *FileSink) rotate() error {
+	if err := f.file.Close(); err != nil {
+		log.Printf("Error closing %s: %v", f.cfg.Path, err)
+	}
+	f.file = nil
+
+	if err := os.Rename(f.cfg.Path, f.rotatedName(f.now())); err != nil {
+		// Keep appending to the same file rather than losing updates
+		if openErr := f.open(); openErr != nil {
+			return errors.Join(err, openErr)
+		}
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune()
+}
+
+// rotatedName returns the name the active file is renamed to at t
+func (f *FileSink) rotatedName(t time.Time) string {
+	ext := filepath.Ext(f.cfg.Path)
+	base := strings.TrimSuffix(f.cfg.Path, ext)
+	return base + "-" + t.UTC().Format(rotatedTimeLayout) + ext
+}
+
+// rotatedFiles lists the rotated files, oldest first
+func (f *FileSink) rotatedFiles() ([]string, error) {
+	ext := filepath.Ext(f.cfg.Path)
+	files, err := filepath.Glob(strings.TrimSuffix(f.cfg.Path, ext) + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// prune deletes all but the newest cfg.Keep rotated files
+func (f *FileSink) prune() error {
+	if f.cfg.Keep <= 0 {
+		return nil
+	}
+	files, err := f.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for len(files) > f.cfg.Keep {
+		if err := os.Remove(files[0]); err != nil {
+			return fmt.Errorf("removing old update file: %w", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// Close closes the active file; later updates are dropped
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
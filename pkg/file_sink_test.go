@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readLines returns the updates in an NDJSON file, failing on invalid lines
+func readLines(t *testing.T, name string) []BGPUpdateMessage {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var updates []BGPUpdateMessage
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var u BGPUpdateMessage
+		if err := json.Unmarshal(scanner.Bytes(), &u); err != nil {
+			t.Fatalf("%s: invalid JSON line %q: %v", name, scanner.Text(), err)
+		}
+		updates = append(updates, u)
+	}
+	return updates
+}
+
+// TestFileSinkRotatesBySize verifies an overflowing file is rotated and both hold valid lines
+func TestFileSinkRotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "updates.ndjson")
+	line, _ := json.Marshal(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+	sink, err := NewFileSink(FileSinkConfig{Path: path, MaxSize: int64(len(line)+1) * 2})
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	defer sink.Close()
+
+	for _, peer := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		sink.HandleUpdate(BGPUpdateMessage{FromPeer: peer})
+	}
+
+	rotated, err := sink.rotatedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 1 {
+		t.Fatalf("rotated files = %v, want 1", rotated)
+	}
+	if got := readLines(t, rotated[0]); len(got) != 2 || got[0].FromPeer != "192.0.2.1" {
+		t.Errorf("rotated file holds %+v, want the first two updates", got)
+	}
+	if got := readLines(t, path); len(got) != 1 || got[0].FromPeer != "192.0.2.3" {
+		t.Errorf("active file holds %+v, want the third update", got)
+	}
+}
+
+// TestFileSinkRotatesByAge verifies age rotation and that only Keep rotated files remain
+func TestFileSinkRotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "updates.ndjson")
+	sink, err := NewFileSink(FileSinkConfig{Path: path, MaxAge: time.Hour, Keep: 2})
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+	defer sink.Close()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sink.now = func() time.Time { return now }
+	sink.opened = now
+
+	for i := 0; i < 4; i++ {
+		sink.HandleUpdate(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+		now = now.Add(time.Hour)
+	}
+
+	rotated, err := sink.rotatedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Errorf("rotated files = %v, want the newest 2", rotated)
+	}
+	if want := sink.rotatedName(now.Add(-time.Hour)); len(rotated) > 0 && rotated[len(rotated)-1] != want {
+		t.Errorf("newest rotated file = %s, want %s", rotated[len(rotated)-1], want)
+	}
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+)
+
+// Flowspec component types (RFC 8955 section 4.2.2)
+const (
+	flowSpecDestPrefix = 1
+	flowSpecSrcPrefix  = 2
+	flowSpecProtocol   = 3
+	flowSpecPort       = 4
+	flowSpecDestPort   = 5
+	flowSpecSrcPort    = 6
+)
+
+// Numeric operator bits (RFC 8955 section 4.2.1.1)
+const (
+	flowSpecOpAnd     = 0x40
+	flowSpecOpCompare = 0x07
+)
+
+// flowSpecCompare names the lt/gt/eq combinations of a numeric operator
+var flowSpecCompare = [8]string{"false", "==", ">", ">=", "<", "<=", "!=", "true"}
+
+// FlowSpecRule is a decoded flowspec NLRI (SAFI 133)
+// Numeric matches are listed as alternatives, each a "&"-joined
+// conjunction such as "==6" or ">=1024&<=2048"; a packet matches the
+// component when any alternative matches
+type FlowSpecRule struct {
+	DestinationPrefix string   // e.g. "192.0.2.0/24"
+	SourcePrefix      string   // e.g. "198.51.100.0/24"
+	Protocols         []string // IP protocol numbers
+	Ports             []string // Either source or destination port
+	DestinationPorts  []string
+	SourcePorts       []string
+
+	// Other lists component types the rule matches on but that are not decoded
+	Other []uint32
+}
+
+// parseFlowSpec decodes the components of a GoBGP flowspec NLRI
+func parseFlowSpec(nlri *api.FlowSpecNLRI) (*FlowSpecRule, error) {
+	rule := &FlowSpecRule{}
+	for _, r := range nlri.Rules {
+		msg, err := r.UnmarshalNew()
+		if err != nil {
+			return nil, fmt.Errorf("flowspec rule: %w", err)
+		}
+		switch v := msg.(type) {
+		case *api.FlowSpecIPPrefix:
+			prefix := fmt.Sprintf("%s/%d", v.Prefix, v.PrefixLen)
+			switch v.Type {
+			case flowSpecDestPrefix:
+				rule.DestinationPrefix = prefix
+			case flowSpecSrcPrefix:
+				rule.SourcePrefix = prefix
+			}
+		case *api.FlowSpecComponent:
+			matches := flowSpecNumeric(v.Items)
+			switch v.Type {
+			case flowSpecProtocol:
+				rule.Protocols = matches
+			case flowSpecPort:
+				rule.Ports = matches
+			case flowSpecDestPort:
+				rule.DestinationPorts = matches
+			case flowSpecSrcPort:
+				rule.SourcePorts = matches
+			default:
+				rule.Other = append(rule.Other, v.Type)
+			}
+		case *api.FlowSpecMAC:
+			rule.Other = append(rule.Other, v.Type)
+		}
+	}
+	return rule, nil
+}
+
+// flowSpecNumeric renders numeric operator/value pairs, folding items
+// whose AND bit is set into the preceding alternative
+func flowSpecNumeric(items []*api.FlowSpecComponentItem) []string {
+	var matches []string
+	for _, item := range items {
+		term := fmt.Sprintf("%s%d", flowSpecCompare[item.Op&flowSpecOpCompare], item.Value)
+		if item.Op&flowSpecOpAnd != 0 && len(matches) > 0 {
+			matches[len(matches)-1] += "&" + term
+			continue
+		}
+		matches = append(matches, term)
+	}
+	return matches
+}
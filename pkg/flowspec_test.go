@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/types/known/anypb"
+	"reflect"
+	"testing"
+)
+
+// TestParsePathFlowSpec verifies prefix and numeric components of a flowspec rule are decoded
+func TestParsePathFlowSpec(t *testing.T) {
+	bgpService := NewBGPService()
+	path := &api.Path{
+		Nlri: mustAny(t, &api.FlowSpecNLRI{Rules: []*anypb.Any{
+			mustAny(t, &api.FlowSpecIPPrefix{Type: flowSpecDestPrefix, PrefixLen: 24, Prefix: "192.0.2.0"}),
+			mustAny(t, &api.FlowSpecIPPrefix{Type: flowSpecSrcPrefix, PrefixLen: 16, Prefix: "198.51.0.0"}),
+			mustAny(t, &api.FlowSpecComponent{Type: flowSpecProtocol, Items: []*api.FlowSpecComponentItem{
+				{Op: 0x81, Value: 6}, // ==6, end of list
+			}}),
+			mustAny(t, &api.FlowSpecComponent{Type: flowSpecDestPort, Items: []*api.FlowSpecComponentItem{
+				{Op: 0x01, Value: 80},   // ==80
+				{Op: 0x13, Value: 1024}, // or >=1024
+				{Op: 0xd5, Value: 2048}, // and <=2048, end of list
+			}}),
+		}}),
+	}
+
+	update := bgpService.parsePath(path)
+	if len(update.ParseErrors) != 0 {
+		t.Fatalf("ParseErrors = %v, want none", update.ParseErrors)
+	}
+	want := &FlowSpecRule{
+		DestinationPrefix: "192.0.2.0/24",
+		SourcePrefix:      "198.51.0.0/16",
+		Protocols:         []string{"==6"},
+		DestinationPorts:  []string{"==80", ">=1024&<=2048"},
+	}
+	if !reflect.DeepEqual(update.FlowSpec, want) {
+		t.Errorf("FlowSpec = %+v, want %+v", update.FlowSpec, want)
+	}
+}
@@ -0,0 +1,114 @@
+package pkg
+
+import (
+	"bgp_dashboard/pkg/bgpdashpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"math"
+	"net"
+	"time"
+)
+
+// GRPCServer exposes the update stream over gRPC as the BgpDashStream service
+// The service definition lives in proto/bgpdash.proto; regenerate
+// pkg/bgpdashpb with "buf generate proto" after changing it
+type GRPCServer struct {
+	bgpdashpb.UnimplementedBgpDashStreamServer
+	service *BGPService
+	server  *grpc.Server
+}
+
+// NewGRPCServer creates the gRPC API for service
+func NewGRPCServer(service *BGPService) *GRPCServer {
+	g := &GRPCServer{
+		service: service,
+		server:  grpc.NewServer(keepaliveOptions(service.config.GRPC.Keepalive)...),
+	}
+	bgpdashpb.RegisterBgpDashStreamServer(g.server, g)
+	return g
+}
+
+// GRPCKeepaliveConfig controls the HTTP/2 pings on gRPC connections
+// Load balancers drop connections that are quiet for too long, which a
+// stream with no updates to deliver can easily be; pinging keeps them busy
+// Fields left at 0 take the defaults, a negative Time disables server pings
+type GRPCKeepaliveConfig struct {
+	Time    time.Duration `yaml:"time"`    // Quiet time before the server pings the client, 30s when 0
+	Timeout time.Duration `yaml:"timeout"` // Wait for the ping ack before closing, 20s when 0
+	MinTime time.Duration `yaml:"minTime"` // Shortest client ping interval tolerated, 10s when 0
+}
+
+// Defaults applied to GRPCKeepaliveConfig fields left at 0
+const (
+	defaultGRPCKeepaliveTime    = 30 * time.Second
+	defaultGRPCKeepaliveTimeout = 20 * time.Second
+	defaultGRPCKeepaliveMinTime = 10 * time.Second
+)
+
+// keepaliveOptions translates cfg into gRPC server options
+// Clients may ping without an active stream, so an idle dashboard can
+// keep its connection up between subscriptions
+func keepaliveOptions(cfg GRPCKeepaliveConfig) []grpc.ServerOption {
+	params := keepalive.ServerParameters{
+		Time:    timeoutOrDefault(cfg.Time, defaultGRPCKeepaliveTime),
+		Timeout: timeoutOrDefault(cfg.Timeout, defaultGRPCKeepaliveTimeout),
+	}
+	if params.Time == 0 {
+		params.Time = time.Duration(math.MaxInt64) // gRPC's value for never
+	}
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(params),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             timeoutOrDefault(cfg.MinTime, defaultGRPCKeepaliveMinTime),
+			PermitWithoutStream: true,
+		}),
+	}
+}
+
+// Serve accepts connections on lis until Stop is called or lis fails
+func (g *GRPCServer) Serve(lis net.Listener) error {
+	return g.server.Serve(lis)
+}
+
+// ListenAndServe listens on addr and serves the API until it fails
+func (g *GRPCServer) ListenAndServe(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return g.Serve(lis)
+}
+
+// Stop closes every stream and the listener immediately
+func (g *GRPCServer) Stop() {
+	g.server.Stop()
+}
+
+// WatchUpdates streams updates to the client through its own subscription,
+// limited to the updates matching the request's filter
+// The stream ends when the client goes away or the service stops
+func (g *GRPCServer) WatchUpdates(req *bgpdashpb.WatchUpdatesRequest, stream bgpdashpb.BgpDashStream_WatchUpdatesServer) error {
+	f := req.GetFilter()
+	filter, err := ParseUpdateFilter(f.GetPrefixes(), f.GetCommunities(), f.GetRpkiStates(), f.GetFamilies())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	updates, unsubscribe := g.service.SubscribeWithOptions(SubscribeOptions{Filter: filter})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(updateToProto(update)); err != nil {
+				return err
+			}
+		}
+	}
+}
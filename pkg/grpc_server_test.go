@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"bgp_dashboard/pkg/bgpdashpb"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestGRPCWatchUpdates connects a client and receives an injected update
+func TestGRPCWatchUpdates(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := NewGRPCServer(bgpService)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := bgpdashpb.NewBgpDashStreamClient(conn).WatchUpdates(ctx, &bgpdashpb.WatchUpdatesRequest{})
+	if err != nil {
+		t.Fatalf("WatchUpdates error = %v", err)
+	}
+
+	// The server subscribes asynchronously; wait for it before injecting
+	for bgpService.Stats().Subscribers == 0 {
+		if ctx.Err() != nil {
+			t.Fatal("Timed out waiting for the stream to subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	localPref := uint32(200)
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1", LocalPref: &localPref, ASPath: [][]uint32{{65002, 65010}}})
+
+	update, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv error = %v", err)
+	}
+	if update.GetFromPeer() != "192.0.2.1" || update.GetLocalPref() != 200 {
+		t.Errorf("got %v, want update from 192.0.2.1 with local pref 200", update)
+	}
+	if len(update.GetAsPath()) != 1 || len(update.GetAsPath()[0].GetAsns()) != 2 {
+		t.Errorf("AsPath = %v, want one segment of two ASNs", update.GetAsPath())
+	}
+}
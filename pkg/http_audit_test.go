@@ -0,0 +1,48 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestAuditLog verifies a peer-add request is audited with its status while
+// read-only requests are not
+func TestAuditLog(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	httpServer := NewHTTPServer(bgpService)
+	var buf bytes.Buffer
+	httpServer.SetAuditLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	req := httptest.NewRequest(http.MethodPost, "/neighbors", strings.NewReader(`{"peerIP": "192.0.2.1", "asn": 65002}`))
+	req.SetBasicAuth("alice", "secret")
+	req.RemoteAddr = "198.51.100.7:40000"
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /neighbors status = %d, want 201 (body %s)", rec.Code, rec.Body.String())
+	}
+	httpServer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/neighbors/192.0.2.1", nil))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("audit log = %q, want exactly one entry", buf.String())
+	}
+	var entry struct {
+		Method, Path, Remote, User string
+		Status                     int
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("decoding audit entry %q: %v", lines[0], err)
+	}
+	if entry.Method != "POST" || entry.Path != "/neighbors" || entry.Remote != "198.51.100.7:40000" || entry.User != "alice" || entry.Status != http.StatusCreated {
+		t.Errorf("audit entry = %+v, want POST /neighbors from 198.51.100.7:40000 by alice with 201", entry)
+	}
+	if strings.Contains(lines[0], "secret") {
+		t.Errorf("audit entry %q leaks the password", lines[0])
+	}
+}
@@ -0,0 +1,283 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gopkg.in/yaml.v3"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPServer exposes a BGPService over a small JSON REST API
+// It is an http.Handler, so it can be mounted on any server or mux
+type HTTPServer struct {
+	service *BGPService
+	mux     *http.ServeMux
+	audit   *slog.Logger // Receives mutating requests, see SetAuditLogger
+}
+
+// NewHTTPServer creates the REST API for service and registers its routes
+func NewHTTPServer(service *BGPService) *HTTPServer {
+	h := &HTTPServer{
+		service: service,
+		mux:     http.NewServeMux(),
+		audit:   slog.Default(),
+	}
+	h.mux.HandleFunc("POST /neighbors", h.handleAddNeighbor)
+	h.mux.HandleFunc("DELETE /neighbors/{ip}", h.handleRemoveNeighbor)
+	h.mux.HandleFunc("GET /neighbors/{ip}", h.handleGetNeighbor)
+	h.mux.HandleFunc("POST /neighbors/{ip}/reset", h.handleResetNeighbor)
+	h.mux.HandleFunc("GET /route", h.handleRoute)
+	h.mux.HandleFunc("GET /updates/recent", h.handleRecentUpdates)
+	h.mux.HandleFunc("GET /readyz", h.handleReadyz)
+	h.mux.HandleFunc("GET /healthz", h.handleHealthz)
+	h.mux.HandleFunc("GET /config", h.handleConfig)
+	h.mux.HandleFunc("GET /version", h.handleVersion)
+	if enabled := service.config.Metrics.Prometheus; enabled == nil || *enabled {
+		h.mux.Handle("GET /metrics", promhttp.HandlerFor(service.metrics.registry, promhttp.HandlerOpts{}))
+	}
+	return h
+}
+
+// ServeHTTP dispatches the request to the matching route and records it
+// in the request metrics served on /metrics
+func (h *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if h.audit != nil && isMutating(r) {
+		h.serveAudited(h.mux, rec, r)
+	} else {
+		h.mux.ServeHTTP(rec, r)
+	}
+	// The mux stores the pattern it matched in the request
+	h.service.metrics.observeHTTP(r.Pattern, rec.status, time.Since(start))
+}
+
+// Defaults applied to HTTP timeouts left at 0
+const (
+	defaultHTTPReadTimeout  = 30 * time.Second
+	defaultHTTPWriteTimeout = 60 * time.Second
+	defaultHTTPIdleTimeout  = 120 * time.Second
+)
+
+// ListenAndServe serves the API on addr until the listener fails
+func (h *HTTPServer) ListenAndServe(addr string) error {
+	return h.httpServer(addr).ListenAndServe()
+}
+
+// httpServer returns the server for addr with the timeouts under http in the config
+func (h *HTTPServer) httpServer(addr string) *http.Server {
+	cfg := h.service.config.HTTP
+	return &http.Server{
+		Addr:         addr,
+		Handler:      h,
+		ReadTimeout:  timeoutOrDefault(cfg.ReadTimeout, defaultHTTPReadTimeout),
+		WriteTimeout: timeoutOrDefault(cfg.WriteTimeout, defaultHTTPWriteTimeout),
+		IdleTimeout:  timeoutOrDefault(cfg.IdleTimeout, defaultHTTPIdleTimeout),
+	}
+}
+
+// timeoutOrDefault returns def for 0 and 0, meaning no timeout, for negative d
+func timeoutOrDefault(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	}
+	return d
+}
+
+// handleGetNeighbor returns the details and message counters of one peer
+func (h *HTTPServer) handleGetNeighbor(w http.ResponseWriter, r *http.Request) {
+	detail, err := h.service.GetNeighbor(r.PathValue("ip"))
+	if err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// handleAddNeighbor configures a peer from a JSON NeighborConfig body and
+// returns 201 with the resolved neighbor, e.g. {"peerIP": "192.0.2.1", "asn": 65002}
+func (h *HTTPServer) handleAddNeighbor(w http.ResponseWriter, r *http.Request) {
+	var cfg NeighborConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid neighbor: %w", err))
+		return
+	}
+	if net.ParseIP(cfg.PeerIP) == nil || cfg.ASN <= 0 {
+		writeError(w, http.StatusBadRequest, errors.New("peerIP and asn are required"))
+		return
+	}
+	// Reading server-side files on a client's behalf would expose them
+	if cfg.AuthPasswordFile != "" {
+		writeError(w, http.StatusBadRequest, errors.New("authPasswordFile is not accepted over HTTP, use authPassword"))
+		return
+	}
+
+	if err := h.service.AddNeighborConfig(cfg); err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	detail, err := h.service.GetNeighbor(cfg.PeerIP)
+	if err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	w.Header().Set("Location", "/neighbors/"+cfg.PeerIP)
+	writeJSON(w, http.StatusCreated, detail)
+}
+
+// handleRemoveNeighbor deletes a peer; ?reason= is sent to it as the
+// shutdown communication
+func (h *HTTPServer) handleRemoveNeighbor(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+	if err := h.service.RemoveNeighbor(ip, r.URL.Query().Get("reason")); err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"neighbor": ip})
+}
+
+// handleResetNeighbor resets a session; ?soft=true requests a soft reset
+func (h *HTTPServer) handleResetNeighbor(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+
+	soft := false
+	if v := r.URL.Query().Get("soft"); v != "" {
+		var err error
+		if soft, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("soft must be true or false"))
+			return
+		}
+	}
+
+	if err := h.service.ResetNeighbor(ip, soft); err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"neighbor": ip, "soft": soft})
+}
+
+// handleRoute looks up the RIB paths for ?prefix=, using ?lpm=true for longest match
+func (h *HTTPServer) handleRoute(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	if prefix == "" {
+		writeError(w, http.StatusBadRequest, errors.New("prefix is required"))
+		return
+	}
+
+	lpm := false
+	if v := r.URL.Query().Get("lpm"); v != "" {
+		var err error
+		if lpm, err = strconv.ParseBool(v); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("lpm must be true or false"))
+			return
+		}
+	}
+
+	paths, err := h.service.LookupPrefix(prefix, lpm)
+	if err != nil {
+		writeError(w, statusForError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, paths)
+}
+
+// handleRecentUpdates returns the latest updates, oldest first; ?n= limits the count
+func (h *HTTPServer) handleRecentUpdates(w http.ResponseWriter, r *http.Request) {
+	n := 0
+	if v := r.URL.Query().Get("n"); v != "" {
+		var err error
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errors.New("n must be a non-negative integer"))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, h.service.RecentUpdates(n))
+}
+
+// handleReadyz returns 200 when the service is ready and 503 with the reason otherwise
+func (h *HTTPServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready, reason, err := h.service.Ready()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if !ready {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "not ready", "reason": reason})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// handleHealthz returns 200 while GoBGP's API answers and 503 with the reason once degraded
+func (h *HTTPServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if healthy, reason := h.service.Healthy(); !healthy {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "degraded", "reason": reason})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleConfig returns the redacted running config as YAML, or JSON with ?format=json
+func (h *HTTPServer) handleConfig(w http.ResponseWriter, r *http.Request) {
+	config := h.service.RunningConfig().Redacted()
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "yaml":
+		data, err := yaml.Marshal(config)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(data)
+	case "json":
+		writeJSON(w, http.StatusOK, config)
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unsupported format %q, expected yaml or json", format))
+	}
+}
+
+// statusForError maps service errors onto HTTP status codes
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, ErrNeighborNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrNeighborExists), errors.Is(err, ErrTooManyNeighbors):
+		return http.StatusConflict
+	case errors.Is(err, ErrPeerNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, ErrInvalidPrefix), errors.Is(err, ErrShutdownReasonTooLong), errors.Is(err, ErrInvalidNeighbor):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// handleVersion returns the build details of the running binary
+func (h *HTTPServer) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, GetBuildInfo())
+}
+
+// writeJSON writes v as the JSON response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing HTTP response: %v", err)
+	}
+}
+
+// writeError writes err as a JSON {"error": "..."} body
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
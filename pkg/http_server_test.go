@@ -0,0 +1,334 @@
+package pkg
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestHTTPServer starts a service with one configured neighbor behind the REST API
+func newTestHTTPServer(t *testing.T) (*BGPService, *HTTPServer) {
+	t.Helper()
+	bgpService := newTestService(t, &Config{})
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("Failed to add neighbor: %v", err)
+	}
+	return bgpService, NewHTTPServer(bgpService)
+}
+
+// TestResetNeighborEndpoint covers soft, hard and unknown-peer resets
+func TestResetNeighborEndpoint(t *testing.T) {
+	_, httpServer := newTestHTTPServer(t)
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "Soft reset",
+			path:       "/neighbors/192.0.2.1/reset?soft=true",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Hard reset",
+			path:       "/neighbors/192.0.2.1/reset",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Unknown peer",
+			path:       "/neighbors/192.0.2.99/reset",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Invalid soft flag",
+			path:       "/neighbors/192.0.2.1/reset?soft=maybe",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestRouteEndpoint covers exact match, longest-prefix match and bad input
+func TestRouteEndpoint(t *testing.T) {
+	bgpService, httpServer := newTestHTTPServer(t)
+	for _, prefix := range []string{"10.0.0.0/8", "10.1.0.0/16"} {
+		if err := bgpService.AddPath(PathSpec{Prefix: prefix, NextHop: "192.0.2.254"}); err != nil {
+			t.Fatalf("AddPath(%s) error = %v", prefix, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantPrefix string // Expected prefix of the single returned path, "" for none
+	}{
+		{
+			name:       "Exact match",
+			query:      "prefix=10.1.0.0/16",
+			wantStatus: http.StatusOK,
+			wantPrefix: "10.1.0.0",
+		},
+		{
+			name:       "Exact match not found",
+			query:      "prefix=10.1.2.0/24",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Longest prefix match",
+			query:      "prefix=10.1.2.0/24&lpm=true",
+			wantStatus: http.StatusOK,
+			wantPrefix: "10.1.0.0",
+		},
+		{
+			name:       "Bad CIDR",
+			query:      "prefix=10.1.2.0/33",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Missing prefix",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/route?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var paths []BGPUpdateMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &paths); err != nil {
+				t.Fatalf("response is not a JSON list: %v", err)
+			}
+			if tt.wantPrefix == "" {
+				if paths == nil || len(paths) != 0 {
+					t.Errorf("got %v, want an empty list", paths)
+				}
+				return
+			}
+			if len(paths) != 1 || len(paths[0].NLRI) != 1 || paths[0].NLRI[0].Prefix.String() != tt.wantPrefix {
+				t.Errorf("got %+v, want a single path for %s", paths, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+// TestConfigEndpoint verifies the running config is served redacted as YAML and JSON
+func TestConfigEndpoint(t *testing.T) {
+	bgpService, httpServer := newTestHTTPServer(t)
+	secret := NeighborConfig{PeerIP: "192.0.2.2", ASN: 65003}
+	secret.AuthPassword = "hunter2"
+	if err := bgpService.AddNeighborConfig(secret); err != nil {
+		t.Fatalf("Failed to add neighbor: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		contentType string
+		unmarshal   func([]byte, any) error
+	}{
+		{name: "YAML", query: "", contentType: "application/yaml", unmarshal: yaml.Unmarshal},
+		{name: "JSON", query: "?format=json", contentType: "application/json", unmarshal: json.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config"+tt.query, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if strings.Contains(rec.Body.String(), "hunter2") {
+				t.Error("response contains the neighbor password")
+			}
+
+			var config Config
+			if err := tt.unmarshal(rec.Body.Bytes(), &config); err != nil {
+				t.Fatalf("response does not parse: %v", err)
+			}
+			if config.BGP.Local.RouterID != "192.0.2.254" {
+				t.Errorf("RouterID = %q, want 192.0.2.254", config.BGP.Local.RouterID)
+			}
+			var peers []string
+			for _, n := range config.BGP.Neighbors {
+				peers = append(peers, n.PeerIP)
+			}
+			if len(peers) != 2 || peers[0] != "192.0.2.1" || peers[1] != "192.0.2.2" {
+				t.Errorf("neighbors = %v, want [192.0.2.1 192.0.2.2]", peers)
+			}
+			if got := config.BGP.Neighbors[1].AuthPassword; got != redactedSecret {
+				t.Errorf("AuthPassword = %q, want %q", got, redactedSecret)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/config?format=xml", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("format=xml status = %d, want 400", rec.Code)
+	}
+}
+
+// TestGetNeighborEndpoint verifies a configured peer is described and an unknown one is 404
+func TestGetNeighborEndpoint(t *testing.T) {
+	_, httpServer := newTestHTTPServer(t)
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/neighbors/192.0.2.1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var detail NeighborDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatal(err)
+	}
+	if detail.Address != "192.0.2.1" || detail.ASN != 65002 {
+		t.Errorf("neighbor = %+v, want 192.0.2.1 AS65002", detail.NeighborInfo)
+	}
+
+	rec = httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/neighbors/192.0.2.9", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d for an unknown neighbor, want 404", rec.Code)
+	}
+}
+
+// TestRecentUpdatesEndpoint verifies the latest updates are served and n is validated
+func TestRecentUpdatesEndpoint(t *testing.T) {
+	bgpService, httpServer := newTestHTTPServer(t)
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1", Timestamp: 1})
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1", Timestamp: 2})
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/updates/recent?n=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body.String())
+	}
+	var updates []BGPUpdateMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &updates); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if len(updates) != 1 || updates[0].Timestamp != 2 {
+		t.Errorf("updates = %+v, want only the newest", updates)
+	}
+
+	rec = httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/updates/recent?n=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status for n=-1 = %d, want 400", rec.Code)
+	}
+}
+
+// TestVersionEndpoint verifies the link-time build details are served as JSON
+func TestVersionEndpoint(t *testing.T) {
+	_, httpServer := newTestHTTPServer(t)
+	defer func(version, commit, date string) { Version, Commit, Date = version, commit, date }(Version, Commit, Date)
+	Version, Commit, Date = "v1.2.0", "abc123", "2024-01-01T00:00:00Z"
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body.String())
+	}
+	var got BuildInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if got.Version != "v1.2.0" || got.Commit != "abc123" || got.Date != "2024-01-01T00:00:00Z" || got.GoVersion == "" {
+		t.Errorf("build info = %+v, want the linked values and a Go version", got)
+	}
+}
+
+// TestAddRemoveNeighborEndpoints covers adding, duplicate, invalid and removed peers
+func TestAddRemoveNeighborEndpoints(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	httpServer := NewHTTPServer(bgpService)
+	httpServer.SetAuditLogger(nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"Add", http.MethodPost, "/neighbors", `{"peerIP": "192.0.2.1", "asn": 65002}`, http.StatusCreated},
+		{"Duplicate", http.MethodPost, "/neighbors", `{"peerIP": "192.0.2.1", "asn": 65002}`, http.StatusConflict},
+		{"Missing ASN", http.MethodPost, "/neighbors", `{"peerIP": "192.0.2.2"}`, http.StatusBadRequest},
+		{"Malformed body", http.MethodPost, "/neighbors", `{`, http.StatusBadRequest},
+		{"Unknown family", http.MethodPost, "/neighbors", `{"peerIP": "192.0.2.2", "asn": 65002, "families": ["ipv9-unicast"]}`, http.StatusBadRequest},
+		{"Unknown peer group", http.MethodPost, "/neighbors", `{"peerIP": "192.0.2.2", "asn": 65002, "peerGroup": "missing"}`, http.StatusBadRequest},
+		{"Password file", http.MethodPost, "/neighbors", `{"peerIP": "192.0.2.2", "asn": 65002, "authPasswordFile": "/etc/shadow"}`, http.StatusBadRequest},
+		{"Reason too long", http.MethodDelete, "/neighbors/192.0.2.1?reason=" + strings.Repeat("x", 129), "", http.StatusBadRequest},
+		{"Remove", http.MethodDelete, "/neighbors/192.0.2.1?reason=decommissioned", "", http.StatusOK},
+		{"Remove unknown", http.MethodDelete, "/neighbors/192.0.2.1", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		httpServer.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.name, rec.Code, tt.wantStatus, rec.Body.String())
+		}
+	}
+}
+
+// TestAddNeighborEndpointNotAllowed verifies a peer outside bgp.allowedPeers is forbidden
+func TestAddNeighborEndpointNotAllowed(t *testing.T) {
+	config := &Config{}
+	config.BGP.AllowedPeers = []AllowedPeerConfig{{PeerIP: "192.0.2.1", ASN: 65002}}
+	httpServer := NewHTTPServer(newTestService(t, config))
+	httpServer.SetAuditLogger(nil)
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/neighbors", strings.NewReader(`{"peerIP": "192.0.2.2", "asn": 65002}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d (body %s)", rec.Code, http.StatusForbidden, rec.Body.String())
+	}
+}
+
+// TestHTTPServerTimeouts verifies configured timeouts reach the http.Server,
+// with defaults for unset ones and negative values disabling a timeout
+func TestHTTPServerTimeouts(t *testing.T) {
+	config := &Config{}
+	config.HTTP.ReadTimeout = 5 * time.Second
+	config.HTTP.WriteTimeout = -1
+	srv := NewHTTPServer(NewBGPServiceWithConfig(config)).httpServer(":8080")
+
+	if srv.Addr != ":8080" {
+		t.Errorf("Addr = %q, want :8080", srv.Addr)
+	}
+	if srv.ReadTimeout != 5*time.Second {
+		t.Errorf("ReadTimeout = %v, want 5s", srv.ReadTimeout)
+	}
+	if srv.WriteTimeout != 0 {
+		t.Errorf("WriteTimeout = %v, want disabled", srv.WriteTimeout)
+	}
+	if srv.IdleTimeout != defaultHTTPIdleTimeout {
+		t.Errorf("IdleTimeout = %v, want the %v default", srv.IdleTimeout, defaultHTTPIdleTimeout)
+	}
+}
@@ -0,0 +1,180 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultKafkaQueueSize is the number of updates waiting for the broker
+// when kafka.queueSize is 0
+const defaultKafkaQueueSize = 1000
+
+// defaultKafkaMaxRetries is the number of retries of a failed write when
+// kafka.maxRetries is not set
+const defaultKafkaMaxRetries = 3
+
+// KafkaConfig selects the brokers and topic the Kafka sink publishes to
+// The sink is disabled when no brokers are listed
+type KafkaConfig struct {
+	Brokers    []string      `yaml:"brokers"`
+	Topic      string        `yaml:"topic"`
+	MaxRetries *int          `yaml:"maxRetries"` // Attempts after the first failure, 3 when unset and none when 0
+	Retry      BackoffConfig `yaml:"retry"`      // Backoff between attempts
+	Format     UpdateFormat  `yaml:"format"`     // json (default) or protobuf
+	QueueSize  int           `yaml:"queueSize"`  // Updates waiting to be written, 1000 when 0; more are dropped
+}
+
+// kafkaProducer is the subset of *kafka.Writer used by KafkaSink
+// Tests substitute an in-memory implementation
+type kafkaProducer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaSink is an UpdateHandler that publishes each update to a Kafka topic,
+// encoded as JSON or protobuf per KafkaConfig.Format
+// Updates are queued and written by a single goroutine, keeping per-peer
+// ordering; a slow or unreachable broker fills the queue and further
+// updates are dropped rather than holding up dispatch
+type KafkaSink struct {
+	producer   kafkaProducer
+	format     UpdateFormat
+	maxRetries int
+	backoff    *backoff            // Only used by the writer goroutine
+	sleep      func(time.Duration) // Replaced in tests to skip real waits
+
+	mu      sync.RWMutex // Guards closed against sends on the closed queue
+	closed  bool
+	queue   chan kafka.Message
+	done    chan struct{} // Closed when the writer goroutine has drained the queue
+	dropped atomic.Uint64
+}
+
+// NewKafkaSink returns a KafkaSink writing to cfg.Topic on cfg.Brokers
+func NewKafkaSink(cfg KafkaConfig) (*KafkaSink, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("kafka: at least one broker is required")
+	}
+	if cfg.Topic == "" {
+		return nil, errors.New("kafka: topic is required")
+	}
+	if err := validateUpdateFormat(cfg.Format); err != nil {
+		return nil, fmt.Errorf("kafka: %w", err)
+	}
+	if cfg.MaxRetries != nil && *cfg.MaxRetries < 0 {
+		return nil, fmt.Errorf("kafka: maxRetries %d must not be negative", *cfg.MaxRetries)
+	}
+
+	writer := &kafka.Writer{
+		Addr:  kafka.TCP(cfg.Brokers...),
+		Topic: cfg.Topic,
+		// Updates are written one at a time from the queue, so do not hold
+		// each one back waiting for a batch to fill
+		BatchTimeout: 10 * time.Millisecond,
+		// Retries are handled by the sink so failures can be logged
+		MaxAttempts: 1,
+	}
+	return newKafkaSink(writer, cfg), nil
+}
+
+// newKafkaSink wraps an existing producer, applying defaults from cfg, and
+// starts the writer goroutine
+func newKafkaSink(producer kafkaProducer, cfg KafkaConfig) *KafkaSink {
+	maxRetries := defaultKafkaMaxRetries
+	if cfg.MaxRetries != nil {
+		maxRetries = *cfg.MaxRetries
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultKafkaQueueSize
+	}
+	k := &KafkaSink{
+		producer:   producer,
+		format:     cfg.Format,
+		maxRetries: maxRetries,
+		backoff:    newBackoff(cfg.Retry),
+		sleep:      time.Sleep,
+		queue:      make(chan kafka.Message, queueSize),
+		done:       make(chan struct{}),
+	}
+	go k.run()
+	return k
+}
+
+// HandleUpdate queues the update for publishing without waiting for the
+// broker; when the queue is full the update is dropped and counted
+func (k *KafkaSink) HandleUpdate(update BGPUpdateMessage) {
+	value, err := encodeUpdate(update, k.format)
+	if err != nil {
+		log.Printf("Error encoding update for Kafka: %v", err)
+		return
+	}
+	msg := kafka.Message{Key: []byte(update.FromPeer), Value: value}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.closed {
+		return
+	}
+	select {
+	case k.queue <- msg:
+	default:
+		// Logged on the first drop and then every 1000th so an unreachable
+		// broker does not flood the log
+		if n := k.dropped.Add(1); n%1000 == 1 {
+			log.Printf("Kafka queue full, %d updates dropped so far", n)
+		}
+	}
+}
+
+// Dropped returns the number of updates dropped because the queue was full
+// or a write kept failing
+func (k *KafkaSink) Dropped() uint64 {
+	return k.dropped.Load()
+}
+
+// run writes queued messages until the queue is closed
+func (k *KafkaSink) run() {
+	defer close(k.done)
+	for msg := range k.queue {
+		k.write(msg)
+	}
+}
+
+// write publishes msg, retrying up to maxRetries times
+// A message that still cannot be written is logged and dropped
+func (k *KafkaSink) write(msg kafka.Message) {
+	k.backoff.Reset()
+	for attempt := 0; ; attempt++ {
+		err := k.producer.WriteMessages(context.Background(), msg)
+		if err == nil {
+			return
+		}
+		if attempt >= k.maxRetries {
+			k.dropped.Add(1)
+			log.Printf("Dropping update after %d Kafka write attempts: %v", attempt+1, err)
+			return
+		}
+		delay := k.backoff.Next()
+		log.Printf("Kafka write failed, retrying in %v: %v", delay, err)
+		k.sleep(delay)
+	}
+}
+
+// Close writes the updates still queued, then flushes and releases the producer
+func (k *KafkaSink) Close() error {
+	k.mu.Lock()
+	if !k.closed {
+		k.closed = true
+		close(k.queue)
+	}
+	k.mu.Unlock()
+	<-k.done
+	return k.producer.Close()
+}
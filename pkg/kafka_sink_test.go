@@ -0,0 +1,121 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"testing"
+	"time"
+)
+
+// memoryProducer records messages in memory and fails the first failures writes
+type memoryProducer struct {
+	failures int
+	attempts int
+	messages []kafka.Message
+}
+
+func (p *memoryProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	p.attempts++
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+func (p *memoryProducer) Close() error { return nil }
+
+// TestKafkaSinkPublishes verifies injected updates are published as JSON
+func TestKafkaSinkPublishes(t *testing.T) {
+	producer := &memoryProducer{}
+	sink := newKafkaSink(producer, KafkaConfig{})
+	bgpService := NewBGPService()
+	bgpService.AddUpdateHandler(sink)
+
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1", Timestamp: 1})
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.2", Timestamp: 2})
+	sink.Close() // Waits for the queue to drain
+
+	if len(producer.messages) != 2 {
+		t.Fatalf("published %d messages, want 2", len(producer.messages))
+	}
+	var got BGPUpdateMessage
+	if err := json.Unmarshal(producer.messages[1].Value, &got); err != nil {
+		t.Fatalf("message is not JSON: %v", err)
+	}
+	if got.FromPeer != "192.0.2.2" || string(producer.messages[1].Key) != "192.0.2.2" {
+		t.Errorf("got update from %q with key %q, want 192.0.2.2", got.FromPeer, producer.messages[1].Key)
+	}
+}
+
+// TestKafkaSinkRetry verifies failed writes are retried a bounded number of
+// times, 3 by default and none with maxRetries 0
+func TestKafkaSinkRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   *int
+		failures     int
+		wantAttempts int
+		wantMessages int
+	}{
+		{name: "Recovers after retries", failures: 2, wantAttempts: 3, wantMessages: 1},
+		{name: "Gives up after max retries", failures: 10, wantAttempts: 4, wantMessages: 0},
+		{name: "Retries disabled", maxRetries: new(int), failures: 1, wantAttempts: 1, wantMessages: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			producer := &memoryProducer{failures: tt.failures}
+			sink := newKafkaSink(producer, KafkaConfig{MaxRetries: tt.maxRetries})
+			sink.sleep = func(time.Duration) {}
+
+			sink.HandleUpdate(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+			sink.Close()
+			if producer.attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", producer.attempts, tt.wantAttempts)
+			}
+			if len(producer.messages) != tt.wantMessages {
+				t.Errorf("published %d messages, want %d", len(producer.messages), tt.wantMessages)
+			}
+		})
+	}
+}
+
+// blockingProducer holds every write until release is closed
+type blockingProducer struct{ release chan struct{} }
+
+func (p *blockingProducer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	<-p.release
+	return nil
+}
+
+func (p *blockingProducer) Close() error { return nil }
+
+// TestKafkaSinkDoesNotBlock verifies a stuck broker never holds up
+// HandleUpdate and that updates beyond the queue are dropped and counted
+func TestKafkaSinkDoesNotBlock(t *testing.T) {
+	producer := &blockingProducer{release: make(chan struct{})}
+	sink := newKafkaSink(producer, KafkaConfig{QueueSize: 1})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// One update is being written, one queued, the rest have no room
+		for i := 0; i < 5; i++ {
+			sink.HandleUpdate(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("HandleUpdate blocked on the broker")
+	}
+	if got := sink.Dropped(); got < 3 {
+		t.Errorf("Dropped() = %d, want at least 3", got)
+	}
+	close(producer.release)
+	sink.Close()
+}
@@ -0,0 +1,103 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"log"
+	"time"
+)
+
+// Defaults applied to liveness settings left at 0
+const (
+	defaultLivenessInterval = 10 * time.Second
+	defaultLivenessTimeout  = 2 * time.Second
+	defaultLivenessFailures = 3
+)
+
+// LivenessConfig controls the probe that calls GoBGP's API in the background
+// to catch a wedged control plane whose sessions may still look up
+type LivenessConfig struct {
+	Interval time.Duration `yaml:"interval"` // Time between probes, 10s when 0, negative disables the probe
+	Timeout  time.Duration `yaml:"timeout"`  // How long a probe may take, 2s when 0
+	Failures int           `yaml:"failures"` // Consecutive failed probes before degrading, 3 when 0
+}
+
+// Healthy reports whether GoBGP's API is answering, and why not
+// The service is degraded once the liveness probe fails
+// liveness.failures times in a row and healthy again on the next success
+func (s *BGPService) Healthy() (bool, string) {
+	s.livenessMu.Lock()
+	defer s.livenessMu.Unlock()
+	if s.degraded != nil {
+		return false, s.degraded.Error()
+	}
+	return true, ""
+}
+
+// runLivenessProbe calls GetBgp every interval until ctx is done
+// GoBGP does not give up on a call when its context ends, so a call that
+// hangs is left running and waited on by the next probe instead of piling
+// up another one behind it
+func (s *BGPService) runLivenessProbe(ctx context.Context, cfg LivenessConfig) {
+	timeout := timeoutOrDefault(cfg.Timeout, defaultLivenessTimeout)
+	failures := cfg.Failures
+	if failures <= 0 {
+		failures = defaultLivenessFailures
+	}
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	var pending chan error // The call in flight, nil when none is
+	consecutive := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if pending == nil {
+			pending = make(chan error, 1)
+			go func(done chan<- error) {
+				_, err := s.server.GetBgp(ctx, &api.GetBgpRequest{})
+				done <- err
+			}(pending)
+		}
+		var err error
+		timer := time.NewTimer(timeout)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case err = <-pending:
+			pending = nil
+		case <-timer.C:
+			err = fmt.Errorf("GetBgp did not answer within %v", timeout)
+		}
+		timer.Stop()
+
+		if err == nil {
+			consecutive = 0
+			s.setDegraded(nil)
+			continue
+		}
+		consecutive++
+		if consecutive >= failures {
+			s.setDegraded(fmt.Errorf("%d consecutive API probes failed: %w", consecutive, err))
+		}
+	}
+}
+
+// setDegraded records the outcome of the liveness probe, logging changes
+func (s *BGPService) setDegraded(err error) {
+	s.livenessMu.Lock()
+	defer s.livenessMu.Unlock()
+	switch {
+	case err != nil && s.degraded == nil:
+		log.Printf("GoBGP API is not responding, marking the service degraded: %v", err)
+	case err == nil && s.degraded != nil:
+		log.Printf("GoBGP API is responding again")
+	}
+	s.degraded = err
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitHealthy polls Healthy until it reports want or the test times out
+func waitHealthy(t *testing.T, s *BGPService, want bool) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		healthy, reason := s.Healthy()
+		if healthy == want {
+			return reason
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Healthy() = %v, want %v", healthy, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// TestLivenessProbe verifies a hanging GetBgp degrades the service after the
+// configured failures, shows on /healthz, and that an answer clears it
+func TestLivenessProbe(t *testing.T) {
+	config := &Config{}
+	config.Liveness = LivenessConfig{Interval: 10 * time.Millisecond, Timeout: 10 * time.Millisecond, Failures: 3}
+	fake := newFakeBgpServer()
+	block := make(chan struct{})
+	fake.getBgpBlock = block
+	bgpService := NewBGPServiceWithServer(config, fake)
+
+	start := time.Now()
+	if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(bgpService.Stop)
+
+	if reason := waitHealthy(t, bgpService, false); reason == "" {
+		t.Error("Healthy() gave no reason for being degraded")
+	}
+	// Three probes, each an interval apart and timing out, come first
+	if elapsed := time.Since(start); elapsed < 3*config.Liveness.Interval {
+		t.Errorf("degraded after %v, before 3 probes could fail", elapsed)
+	}
+
+	rec := httptest.NewRecorder()
+	NewHTTPServer(bgpService).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /healthz status = %d while degraded, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	fake.mu.Lock()
+	fake.getBgpBlock = nil
+	fake.mu.Unlock()
+	close(block)
+	waitHealthy(t, bgpService, true)
+
+	rec = httptest.NewRecorder()
+	NewHTTPServer(bgpService).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /healthz status = %d after recovering, want %d", rec.Code, http.StatusOK)
+	}
+}
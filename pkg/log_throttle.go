@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// defaultLogRate is the update log lines per second when output.logRate is 0
+const defaultLogRate = 100
+
+// logThrottle limits how many update lines are logged per one-second window
+// Lines over the limit are counted and reported in a single summary once
+// the window ends, so a full-table load cannot flood the log
+type logThrottle struct {
+	limit int                                     // Lines per window, negative for no limit
+	now   func() time.Time                        // Replaced in tests
+	logf  func(format string, args ...any)        // Replaced in tests
+	after func(time.Duration, func()) *time.Timer // Schedules the summary, replaced in tests
+
+	mu          sync.Mutex
+	windowStart time.Time
+	count       int         // Lines logged in the current window
+	suppressed  int         // Lines dropped since the last summary
+	summary     *time.Timer // Pending summary, nil when none is scheduled
+}
+
+func newLogThrottle(limit int) *logThrottle {
+	if limit == 0 {
+		limit = defaultLogRate
+	}
+	return &logThrottle{limit: limit, now: time.Now, logf: log.Printf, after: time.AfterFunc}
+}
+
+// allow reports whether another line may be logged in the current window
+func (t *logThrottle) allow() bool {
+	if t.limit < 0 {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.windowStart) >= time.Second {
+		t.flushLocked()
+		t.windowStart, t.count = now, 0
+	}
+	if t.count < t.limit {
+		t.count++
+		return true
+	}
+	t.suppressed++
+	if t.summary == nil {
+		t.summary = t.after(t.windowStart.Add(time.Second).Sub(now), t.flush)
+	}
+	return false
+}
+
+// flush logs the suppressed line count, if any
+func (t *logThrottle) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushLocked()
+}
+
+func (t *logThrottle) flushLocked() {
+	if t.summary != nil {
+		t.summary.Stop()
+		t.summary = nil
+	}
+	if t.suppressed > 0 {
+		t.logf("Suppressed %d update log lines, limit is %d per second", t.suppressed, t.limit)
+		t.suppressed = 0
+	}
+}
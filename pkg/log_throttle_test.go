@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLogThrottle dispatches a burst and verifies only the configured number
+// of update lines is logged, followed by one summary, while handlers still
+// receive every update
+func TestLogThrottle(t *testing.T) {
+	cfg := &Config{}
+	cfg.Output.LogRate = 10
+	bgpService := NewBGPServiceWithConfig(cfg)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var summary func()
+	var summaryDelay time.Duration
+	throttle := bgpService.logRate
+	throttle.now = func() time.Time { return now }
+	throttle.after = func(d time.Duration, f func()) *time.Timer {
+		summaryDelay, summary = d, f
+		return time.NewTimer(time.Hour)
+	}
+
+	handled := 0
+	bgpService.AddUpdateHandler(UpdateHandlerFunc(func(BGPUpdateMessage) { handled++ }))
+	for i := 0; i < 50; i++ {
+		now = now.Add(10 * time.Millisecond)
+		bgpService.dispatch(testUpdate("192.0.2.1", fmt.Sprintf("10.0.%d.0/24", i), false))
+	}
+
+	if got := strings.Count(buf.String(), "BGP Update JSON"); got != 10 {
+		t.Errorf("logged %d updates, want 10", got)
+	}
+	if handled != 50 {
+		t.Errorf("handler received %d updates, want all 50", handled)
+	}
+	if summary == nil {
+		t.Fatal("no summary scheduled for the suppressed lines")
+	}
+	if summaryDelay <= 0 || summaryDelay > time.Second {
+		t.Errorf("summary scheduled in %v, want within the one-second window", summaryDelay)
+	}
+
+	summary()
+	if !strings.Contains(buf.String(), "Suppressed 40 update log lines") {
+		t.Errorf("log = %q, want a summary of 40 suppressed lines", buf.String())
+	}
+
+	// The next window starts over
+	now = now.Add(time.Second)
+	buf.Reset()
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.1.0.0/24", false))
+	if got := strings.Count(buf.String(), "BGP Update JSON"); got != 1 {
+		t.Errorf("logged %d updates in the next window, want 1", got)
+	}
+	if strings.Contains(buf.String(), "Suppressed") {
+		t.Errorf("log = %q, want no second summary", buf.String())
+	}
+}
@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// serviceMetrics holds the Prometheus instruments owned by a BGPService
+// Each service has its own registry so several can live in one process
+type serviceMetrics struct {
+	registry    *prometheus.Registry
+	subscribers prometheus.Gauge
+
+	// Counted with atomics so Stats can read them; exported through CounterFuncs
+	updates atomic.Uint64 // Every update dispatched, sampling or not
+	sampled atomic.Uint64 // Subscriber deliveries skipped by sampling
+	dropped atomic.Uint64 // Subscriber deliveries lost to a full queue
+
+	pausedDropped atomic.Uint64 // Updates discarded while delivery was paused
+	longASPaths   atomic.Uint64 // Updates dropped for an AS path over bgp.maxASPathLength
+
+	stateChanges atomic.Uint64 // Session state transitions, including debounced ones
+
+	// HTTP API requests by route pattern, kept apart from the BGP metrics
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+}
+
+// newServiceMetrics creates and registers the service instruments
+// The Go collector contributes go_goroutines, which helps spot leaked watchers
+// Route counts are read through routeCounts at scrape time
+func newServiceMetrics(routeCounts func() map[string]int) *serviceMetrics {
+	m := &serviceMetrics{
+		registry: prometheus.NewRegistry(),
+		subscribers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "bgpdash",
+			Name:      "subscribers",
+			Help:      "Number of active update subscribers.",
+		}),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP API requests, by route and status code.",
+		}, []string{"route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "bgpdash",
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP API requests, by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route"}),
+	}
+	m.registry.MustRegister(
+		m.subscribers,
+		m.httpRequests,
+		m.httpDuration,
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "updates_total",
+			Help:      "Number of BGP updates dispatched.",
+		}, func() float64 { return float64(m.updates.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "updates_sampled_total",
+			Help:      "Number of subscriber deliveries skipped by sampling.",
+		}, func() float64 { return float64(m.sampled.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "updates_dropped_total",
+			Help:      "Number of subscriber deliveries dropped because the subscriber queue was full.",
+		}, func() float64 { return float64(m.dropped.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "updates_paused_dropped_total",
+			Help:      "Number of updates discarded because delivery was paused.",
+		}, func() float64 { return float64(m.pausedDropped.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "updates_long_as_path_dropped_total",
+			Help:      "Number of updates dropped because their AS path exceeded bgp.maxASPathLength.",
+		}, func() float64 { return float64(m.longASPaths.Load()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "bgpdash",
+			Name:      "peer_state_changes_total",
+			Help:      "Number of neighbor session state transitions, including debounced flaps.",
+		}, func() float64 { return float64(m.stateChanges.Load()) }),
+		&routeFamilyCollector{counts: routeCounts},
+		collectors.NewGoCollector(),
+	)
+	return m
+}
+
+// unmatchedRoute labels HTTP requests no route pattern matched, so stray
+// paths cannot grow the label set
+const unmatchedRoute = "unmatched"
+
+// observeHTTP records one HTTP API request served through route, the
+// pattern it matched such as "GET /neighbors/{ip}"
+func (m *serviceMetrics) observeHTTP(route string, status int, elapsed time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+	m.httpRequests.WithLabelValues(route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(route).Observe(elapsed.Seconds())
+}
+
+// routeFamilyDesc describes the per-family route gauge
+var routeFamilyDesc = prometheus.NewDesc(
+	"bgpdash_routes",
+	"Number of paths in the global RIB, learned or originated, by address family.",
+	[]string{"family"}, nil,
+)
+
+// routeFamilyCollector exports per-family route counts, asked of GoBGP on
+// every scrape so they cover every path in the RIB
+type routeFamilyCollector struct {
+	counts func() map[string]int
+}
+
+func (c *routeFamilyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- routeFamilyDesc
+}
+
+func (c *routeFamilyCollector) Collect(ch chan<- prometheus.Metric) {
+	for family, n := range c.counts() {
+		ch <- prometheus.MustNewConstMetric(routeFamilyDesc, prometheus.GaugeValue, float64(n), family)
+	}
+}
+
+// routeCounts returns the number of global RIB paths per address family,
+// leaving out families GoBGP keeps no table for; nothing is counted before
+// Start, as GoBGP only answers once it is serving
+func (s *BGPService) routeCounts() map[string]int {
+	s.mu.RLock()
+	started := s.runCtx != nil
+	s.mu.RUnlock()
+	counts := make(map[string]int)
+	if !started {
+		return counts
+	}
+	for name := range familyNames {
+		family, _ := parseFamily(name)
+		table, err := s.server.GetTable(s.context, &api.GetTableRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    family,
+		})
+		if err != nil {
+			continue
+		}
+		counts[name] = int(table.NumPath)
+	}
+	return counts
+}
+
+// Stats is a point-in-time snapshot of service internals
+type Stats struct {
+	Subscribers int // Channels currently registered through Subscribe
+	Goroutines  int // Goroutines in the whole process
+
+	Updates        uint64 // Updates dispatched since the service was created
+	SampledUpdates uint64 // Subscriber deliveries skipped by sampling
+	DroppedUpdates uint64 // Subscriber deliveries lost to a full queue
+	PausedDropped  uint64 // Updates discarded while delivery was paused
+	PausedBuffered int    // Updates held for delivery on ResumeUpdates
+	LongASPaths    uint64 // Updates dropped for an AS path over bgp.maxASPathLength
+
+	PeerStateChanges uint64 // Session state transitions, counting every flap
+}
+
+// Stats returns the current service statistics
+func (s *BGPService) Stats() Stats {
+	// Read before s.mu, so pauseMu and s.mu are never held together
+	buffered := s.pausedBuffered()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return Stats{
+		Subscribers: len(s.subscribers),
+		Goroutines:  runtime.NumGoroutine(),
+
+		Updates:        s.metrics.updates.Load(),
+		SampledUpdates: s.metrics.sampled.Load(),
+		DroppedUpdates: s.metrics.dropped.Load(),
+		PausedDropped:  s.metrics.pausedDropped.Load(),
+		PausedBuffered: buffered,
+		LongASPaths:    s.metrics.longASPaths.Load(),
+
+		PeerStateChanges: s.metrics.stateChanges.Load(),
+	}
+}
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"bytes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSubscriberCount verifies the Stats field and gauge follow subscribe/unsubscribe
+func TestSubscriberCount(t *testing.T) {
+	bgpService := NewBGPService()
+
+	_, unsubscribeFirst := bgpService.Subscribe()
+	_, unsubscribeSecond := bgpService.Subscribe()
+	if got := bgpService.Stats().Subscribers; got != 2 {
+		t.Errorf("Stats().Subscribers = %d, want 2", got)
+	}
+	if got := testutil.ToFloat64(bgpService.metrics.subscribers); got != 2 {
+		t.Errorf("subscribers gauge = %v, want 2", got)
+	}
+
+	unsubscribeFirst()
+	unsubscribeSecond()
+	if got := bgpService.Stats().Subscribers; got != 0 {
+		t.Errorf("Stats().Subscribers = %d after unsubscribe, want 0", got)
+	}
+	if got := testutil.ToFloat64(bgpService.metrics.subscribers); got != 0 {
+		t.Errorf("subscribers gauge = %v after unsubscribe, want 0", got)
+	}
+	if bgpService.Stats().Goroutines == 0 {
+		t.Error("Stats().Goroutines should never be 0")
+	}
+}
+
+// TestSubscriberDebugLog verifies subscribers coming and going are only
+// logged with output.debug set
+func TestSubscriberDebugLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, debug := range []bool{false, true} {
+		buf.Reset()
+		config := &Config{}
+		config.Output.Debug = debug
+		_, unsubscribe := NewBGPServiceWithConfig(config).Subscribe()
+		unsubscribe()
+		logged := strings.Contains(buf.String(), "DEBUG Subscriber added, 1 active") &&
+			strings.Contains(buf.String(), "DEBUG Subscriber removed, 0 active")
+		if logged != debug {
+			t.Errorf("with output.debug %v the log reads %q", debug, buf.String())
+		}
+	}
+}
+
+// TestMetricsEndpoint verifies the gauges are served in Prometheus format
+func TestMetricsEndpoint(t *testing.T) {
+	bgpService := NewBGPService()
+
+	rec := httptest.NewRecorder()
+	NewHTTPServer(bgpService).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	for _, metric := range []string{"bgpdash_subscribers", "go_goroutines"} {
+		if !strings.Contains(rec.Body.String(), metric) {
+			t.Errorf("/metrics output is missing %s", metric)
+		}
+	}
+}
+
+// TestMetricsEndpointDisabled verifies metrics.prometheus false removes /metrics
+func TestMetricsEndpointDisabled(t *testing.T) {
+	config := &Config{}
+	disabled := false
+	config.Metrics.Prometheus = &disabled
+
+	rec := httptest.NewRecorder()
+	NewHTTPServer(NewBGPServiceWithConfig(config)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+// TestRouteFamilyGauge verifies per-family route counts follow the global
+// RIB, locally originated routes included
+func TestRouteFamilyGauge(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	for _, spec := range []PathSpec{
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254"},
+		{Prefix: "10.0.1.0/24", NextHop: "192.0.2.254"},
+		{Prefix: "2001:db8::/32", NextHop: "2001:db8::1"},
+	} {
+		if err := bgpService.AddPath(spec); err != nil {
+			t.Fatalf("AddPath(%s) error = %v", spec.Prefix, err)
+		}
+	}
+	if err := bgpService.DeletePath("10.0.1.0/24"); err != nil {
+		t.Fatalf("DeletePath() error = %v", err)
+	}
+
+	want := `
+# HELP bgpdash_routes Number of paths in the global RIB, learned or originated, by address family.
+# TYPE bgpdash_routes gauge
+bgpdash_routes{family="ipv4-flowspec"} 0
+bgpdash_routes{family="ipv4-unicast"} 1
+bgpdash_routes{family="ipv6-flowspec"} 0
+bgpdash_routes{family="ipv6-unicast"} 1
+bgpdash_routes{family="l2vpn-evpn"} 0
+bgpdash_routes{family="l3vpn-ipv4-unicast"} 0
+bgpdash_routes{family="l3vpn-ipv6-unicast"} 0
+`
+	if err := testutil.GatherAndCompare(bgpService.metrics.registry, strings.NewReader(want), "bgpdash_routes"); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestHTTPRequestMetrics verifies requests are counted per route pattern and
+// status, with unknown paths folded into one label
+func TestHTTPRequestMetrics(t *testing.T) {
+	bgpService := NewBGPService()
+	httpServer := NewHTTPServer(bgpService)
+	for _, target := range []string{"/version", "/version", "/version", "/no-such-route"} {
+		httpServer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	requests := bgpService.metrics.httpRequests
+	if got := testutil.ToFloat64(requests.WithLabelValues("GET /version", "200")); got != 3 {
+		t.Errorf("GET /version requests = %v, want 3", got)
+	}
+	if got := testutil.ToFloat64(requests.WithLabelValues(unmatchedRoute, "404")); got != 1 {
+		t.Errorf("unmatched requests = %v, want 1", got)
+	}
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, metric := range []string{`bgpdash_http_requests_total{code="200",route="GET /version"} 3`, "bgpdash_http_request_duration_seconds_count"} {
+		if !strings.Contains(rec.Body.String(), metric) {
+			t.Errorf("/metrics output is missing %s", metric)
+		}
+	}
+}
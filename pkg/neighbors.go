@@ -0,0 +1,401 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/types/known/anypb"
+	"math"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// ErrNeighborNotFound is returned when an operation names a peer that is not configured
+var ErrNeighborNotFound = errors.New("neighbor not found")
+
+// ErrNeighborExists is returned when adding a peer whose address is already configured
+var ErrNeighborExists = errors.New("neighbor already exists")
+
+// ErrRouteRefreshUnsupported is returned by RouteRefresh when the peer did not
+// negotiate route refresh, or the requested family, on its current session
+var ErrRouteRefreshUnsupported = errors.New("route refresh not supported by neighbor")
+
+// ErrShutdownReasonTooLong is returned when a shutdown communication exceeds
+// maxShutdownCommunication bytes
+var ErrShutdownReasonTooLong = errors.New("shutdown reason too long")
+
+// maxShutdownCommunication is the longest Administrative Shutdown
+// Communication allowed by RFC 8203, in bytes of UTF-8
+const maxShutdownCommunication = 128
+
+// ErrTooManyNeighbors is returned when adding a peer would exceed bgp.maxNeighbors
+var ErrTooManyNeighbors = errors.New("too many neighbors")
+
+// ErrPeerNotAllowed is returned when a neighbor's address and ASN are not in
+// bgp.allowedPeers
+var ErrPeerNotAllowed = errors.New("peer not in allowlist")
+
+// ErrInvalidNeighbor is returned when a neighbor's settings are invalid, e.g.
+// an unknown address family or peer group
+var ErrInvalidNeighbor = errors.New("invalid neighbor")
+
+// AllowedPeerConfig is one permitted pair of bgp.allowedPeers
+type AllowedPeerConfig struct {
+	PeerIP string `yaml:"peerIP"`
+	ASN    int    `yaml:"asn"`
+}
+
+// validateAllowedPeers checks every bgp.allowedPeers entry holds an IP
+// address and a valid ASN, so a typo fails at load rather than rejecting
+// the peer it was meant for
+func validateAllowedPeers(allowed []AllowedPeerConfig) error {
+	for i, p := range allowed {
+		if _, err := netip.ParseAddr(p.PeerIP); err != nil {
+			return fmt.Errorf("bgp.allowedPeers[%d]: peerIP %q is not an IP address", i, p.PeerIP)
+		}
+		if p.ASN <= 0 || p.ASN > math.MaxUint32 {
+			return fmt.Errorf("bgp.allowedPeers[%d]: asn %d out of range 1-%d", i, p.ASN, uint32(math.MaxUint32))
+		}
+	}
+	return nil
+}
+
+// checkAllowedPeer fails with ErrPeerNotAllowed unless cfg's address and ASN
+// are listed in bgp.allowedPeers, or the list is empty
+// Addresses are compared parsed, so any spelling of an IPv6 address matches
+func (s *BGPService) checkAllowedPeer(cfg NeighborConfig) error {
+	allowed := s.config.BGP.AllowedPeers
+	if len(allowed) == 0 {
+		return nil
+	}
+	addr, err := netip.ParseAddr(cfg.PeerIP)
+	if err != nil {
+		return fmt.Errorf("%w: %q is not an IP address", ErrPeerNotAllowed, cfg.PeerIP)
+	}
+	var asns []string
+	for _, p := range allowed {
+		if allowedAddr, err := netip.ParseAddr(p.PeerIP); err != nil || allowedAddr.Unmap() != addr.Unmap() {
+			continue
+		}
+		if p.ASN == cfg.ASN {
+			return nil
+		}
+		asns = append(asns, fmt.Sprintf("AS%d", p.ASN))
+	}
+	if len(asns) == 0 {
+		return fmt.Errorf("%w: %s is not listed", ErrPeerNotAllowed, cfg.PeerIP)
+	}
+	return fmt.Errorf("%w: %s is AS%d, only %s allowed", ErrPeerNotAllowed, cfg.PeerIP, cfg.ASN, strings.Join(asns, ", "))
+}
+
+// NeighborInfo summarizes a configured peer and its session
+type NeighborInfo struct {
+	Address      string
+	ASN          uint32
+	SessionState string // e.g. "idle", "active", "established"
+	AdminState   string // "up", "down" or "pfx_ct" when shut by a prefix limit
+
+	// ReceivedPrefixes counts every prefix the peer sent us, while
+	// AcceptedPrefixes only counts those that passed the import policy
+	// A growing gap between the two usually means a leak is being filtered
+	ReceivedPrefixes uint64
+	AcceptedPrefixes uint64
+
+	// Messages exchanged with the peer, counted by GoBGP since it was configured
+	MessagesSent     MessageCounts
+	MessagesReceived MessageCounts
+}
+
+// MessageCounts tallies the BGP messages of each type in one direction
+type MessageCounts struct {
+	Open         uint64
+	Update       uint64
+	Notification uint64
+	Keepalive    uint64
+	Refresh      uint64
+	Discarded    uint64 // Received messages GoBGP dropped, e.g. while not established
+	Total        uint64
+
+	WithdrawUpdate uint64 // Updates carrying withdrawals
+	WithdrawPrefix uint64 // Prefixes withdrawn across those updates
+}
+
+// NeighborDetail is the full view of a single peer, like "show bgp neighbor"
+type NeighborDetail struct {
+	NeighborInfo
+	LocalCapabilities  []string // Capabilities we advertised, see NeighborCapabilities
+	RemoteCapabilities []string // Capabilities the peer advertised
+
+	// When the session last reached Established and last left it, to the
+	// second; both are zero while the session has never been established
+	// and LastDown stays zero until it first goes down
+	LastEstablished time.Time
+	LastDown        time.Time
+}
+
+// GetNeighbor returns the details of a configured peer
+func (s *BGPService) GetNeighbor(address string) (NeighborDetail, error) {
+	peer, err := s.getPeer(address)
+	if err != nil {
+		return NeighborDetail{}, err
+	}
+	detail := NeighborDetail{
+		NeighborInfo:       neighborInfoFromPeer(peer),
+		LocalCapabilities:  capabilityNames(peer.GetState().GetLocalCap()),
+		RemoteCapabilities: capabilityNames(peer.GetState().GetRemoteCap()),
+	}
+	// GoBGP also stamps Downtime when the peer is configured, which is not a
+	// session going down, so it only counts once it follows Uptime, or
+	// matches it to the second for a session no longer established
+	if timers := peer.GetTimers().GetState(); timers.GetUptime() != nil {
+		up := timers.GetUptime().AsTime()
+		detail.LastEstablished = up
+		established := peer.GetState().GetSessionState() == api.PeerState_ESTABLISHED
+		if down := timers.GetDowntime(); down != nil && (down.AsTime().After(up) || !established) {
+			detail.LastDown = down.AsTime()
+		}
+	}
+	return detail, nil
+}
+
+// messageCounts converts GoBGP's per-direction message counters
+func messageCounts(m *api.Message) MessageCounts {
+	return MessageCounts{
+		Open:           m.GetOpen(),
+		Update:         m.GetUpdate(),
+		Notification:   m.GetNotification(),
+		Keepalive:      m.GetKeepalive(),
+		Refresh:        m.GetRefresh(),
+		Discarded:      m.GetDiscarded(),
+		Total:          m.GetTotal(),
+		WithdrawUpdate: m.GetWithdrawUpdate(),
+		WithdrawPrefix: m.GetWithdrawPrefix(),
+	}
+}
+
+// ListNeighbors returns a summary of every configured peer
+func (s *BGPService) ListNeighbors() ([]NeighborInfo, error) {
+	var neighbors []NeighborInfo
+	err := s.server.ListPeer(s.context, &api.ListPeerRequest{}, func(p *api.Peer) {
+		neighbors = append(neighbors, neighborInfoFromPeer(p))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+// neighborInfoFromPeer builds a NeighborInfo from the GoBGP peer state
+// Prefix counters are summed across all negotiated address families
+func neighborInfoFromPeer(p *api.Peer) NeighborInfo {
+	info := NeighborInfo{
+		Address:      p.GetConf().GetNeighborAddress(),
+		ASN:          p.GetConf().GetPeerAsn(),
+		SessionState: strings.ToLower(p.GetState().GetSessionState().String()),
+		AdminState:   strings.ToLower(p.GetState().GetAdminState().String()),
+
+		MessagesSent:     messageCounts(p.GetState().GetMessages().GetSent()),
+		MessagesReceived: messageCounts(p.GetState().GetMessages().GetReceived()),
+	}
+	for _, afiSafi := range p.GetAfiSafis() {
+		info.ReceivedPrefixes += afiSafi.GetState().GetReceived()
+		info.AcceptedPrefixes += afiSafi.GetState().GetAccepted()
+	}
+	return info
+}
+
+// getPeer fetches the current GoBGP view of a single configured peer
+func (s *BGPService) getPeer(address string) (*api.Peer, error) {
+	var found *api.Peer
+	err := s.server.ListPeer(s.context, &api.ListPeerRequest{Address: address}, func(p *api.Peer) {
+		found = p
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNeighborNotFound, address)
+	}
+	return found, nil
+}
+
+// ResetNeighbor resets the session with a configured peer
+// A soft reset re-applies policy in both directions without tearing the
+// session down; a hard reset sends a NOTIFICATION and re-establishes it
+func (s *BGPService) ResetNeighbor(address string, soft bool) error {
+	if _, err := s.getPeer(address); err != nil {
+		return err
+	}
+
+	req := &api.ResetPeerRequest{Address: address, Soft: soft}
+	if soft {
+		req.Direction = api.ResetPeerRequest_BOTH
+	}
+	return s.server.ResetPeer(s.context, req)
+}
+
+// ShutdownNeighbor administratively disables (shutdown true) or re-enables a
+// configured peer, keeping its configuration, like "neighbor shutdown"
+// A disabled peer reports AdminState "down" and makes no connection attempts
+// A non-empty reason is sent to the peer as the RFC 8203 Administrative
+// Shutdown Communication so its logs show why; it is ignored when re-enabling
+func (s *BGPService) ShutdownNeighbor(address string, shutdown bool, reason string) error {
+	if shutdown {
+		if err := validateShutdownReason(reason); err != nil {
+			return err
+		}
+	}
+	if _, err := s.getPeer(address); err != nil {
+		return err
+	}
+
+	if shutdown {
+		return s.server.DisablePeer(s.context, &api.DisablePeerRequest{Address: address, Communication: reason})
+	}
+	return s.server.EnablePeer(s.context, &api.EnablePeerRequest{Address: address})
+}
+
+// RemoveNeighbor deletes a configured peer, tearing down its session
+// A non-empty reason is sent as the RFC 8203 Administrative Shutdown
+// Communication: the session is shut down with it before the peer is deleted
+func (s *BGPService) RemoveNeighbor(address string, reason string) error {
+	if err := validateShutdownReason(reason); err != nil {
+		return err
+	}
+
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+
+	if _, err := s.getPeer(address); err != nil {
+		return err
+	}
+	if reason != "" {
+		if err := s.server.DisablePeer(s.context, &api.DisablePeerRequest{Address: address, Communication: reason}); err != nil {
+			return err
+		}
+	}
+	if err := s.server.DeletePeer(s.context, &api.DeletePeerRequest{Address: address}); err != nil {
+		return err
+	}
+	return s.removeNeighborSettings(address)
+}
+
+// validateShutdownReason enforces the RFC 8203 length limit; GoBGP would
+// otherwise silently truncate the message
+func validateShutdownReason(reason string) error {
+	if len(reason) > maxShutdownCommunication {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrShutdownReasonTooLong, len(reason), maxShutdownCommunication)
+	}
+	return nil
+}
+
+// RouteRefresh re-requests the routes a peer sent for family, e.g. after an
+// import policy change
+// GoBGP keeps every received route in the peer's Adj-RIB-In, so the refresh
+// is served by an inbound soft reset that re-runs import policy over it
+// rather than by a ROUTE-REFRESH message on the wire; GoBGP applies it to
+// all of the peer's families
+// A peer without the capability is refused unless it is configured with
+// softReconfigInbound, in which case the retained routes are used alone
+func (s *BGPService) RouteRefresh(address string, family string) error {
+	f, err := parseFamily(family)
+	if err != nil {
+		return err
+	}
+	peer, err := s.getPeer(address)
+	if err != nil {
+		return err
+	}
+
+	refresh, negotiated := false, false
+	for _, name := range capabilityNames(peer.GetState().GetRemoteCap()) {
+		switch name {
+		case "route-refresh", "enhanced-route-refresh":
+			refresh = true
+		case "mp-" + familyName(f):
+			negotiated = true
+		}
+	}
+	if !refresh && !s.softReconfigInbound(address) {
+		return fmt.Errorf("%w: %s", ErrRouteRefreshUnsupported, address)
+	}
+	if !negotiated {
+		return fmt.Errorf("%w: %s did not negotiate %s", ErrRouteRefreshUnsupported, address, family)
+	}
+
+	return s.server.ResetPeer(s.context, &api.ResetPeerRequest{
+		Address:   address,
+		Soft:      true,
+		Direction: api.ResetPeerRequest_IN,
+	})
+}
+
+// NeighborCapabilities returns the capabilities we advertised to a peer (local)
+// and those the peer advertised to us (remote) as readable names such as
+// "mp-ipv4-unicast", "route-refresh", "graceful-restart" and "4-octet-as"
+// Both lists are empty until the session has reached OpenConfirm
+func (s *BGPService) NeighborCapabilities(address string) (local, remote []string, err error) {
+	peer, err := s.getPeer(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	return capabilityNames(peer.GetState().GetLocalCap()), capabilityNames(peer.GetState().GetRemoteCap()), nil
+}
+
+// capabilityNames converts the capability messages in a peer state into names
+func capabilityNames(caps []*anypb.Any) []string {
+	names := make([]string, 0, len(caps))
+	for _, c := range caps {
+		m, err := c.UnmarshalNew()
+		if err != nil {
+			names = append(names, "unrecognized")
+			continue
+		}
+
+		switch capability := m.(type) {
+		case *api.MultiProtocolCapability:
+			names = append(names, "mp-"+familyName(capability.GetFamily()))
+		case *api.RouteRefreshCapability:
+			names = append(names, "route-refresh")
+		case *api.RouteRefreshCiscoCapability:
+			names = append(names, "cisco-route-refresh")
+		case *api.EnhancedRouteRefreshCapability:
+			names = append(names, "enhanced-route-refresh")
+		case *api.CarryingLabelInfoCapability:
+			names = append(names, "carrying-label-info")
+		case *api.ExtendedNexthopCapability:
+			names = append(names, "extended-nexthop")
+		case *api.GracefulRestartCapability:
+			names = append(names, "graceful-restart")
+		case *api.LongLivedGracefulRestartCapability:
+			names = append(names, "long-lived-graceful-restart")
+		case *api.FourOctetASNCapability:
+			names = append(names, "4-octet-as")
+		case *api.AddPathCapability:
+			names = append(names, "add-path")
+		case *api.FqdnCapability:
+			names = append(names, "fqdn")
+		case *api.SoftwareVersionCapability:
+			names = append(names, "software-version")
+		case *api.UnknownCapability:
+			names = append(names, fmt.Sprintf("unknown-%d", capability.GetCode()))
+		default:
+			names = append(names, "unrecognized")
+		}
+	}
+	return names
+}
+
+// familyName returns the config name of a GoBGP family, e.g. "ipv4-unicast"
+// Families without a config name fall back to the lower-cased enum names
+func familyName(f *api.Family) string {
+	for name, known := range familyNames {
+		if known.afi == f.GetAfi() && known.safi == f.GetSafi() {
+			return name
+		}
+	}
+	afi := strings.TrimPrefix(f.GetAfi().String(), "AFI_")
+	safi := strings.TrimPrefix(f.GetSafi().String(), "SAFI_")
+	return strings.ToLower(afi + "-" + safi)
+}
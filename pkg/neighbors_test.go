@@ -0,0 +1,542 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCapabilityNames verifies a stubbed peer state is translated into readable names
+func TestCapabilityNames(t *testing.T) {
+	caps := []*anypb.Any{
+		mustAny(t, &api.MultiProtocolCapability{Family: &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST}}),
+		mustAny(t, &api.RouteRefreshCapability{}),
+		mustAny(t, &api.GracefulRestartCapability{Time: 90}),
+		mustAny(t, &api.FourOctetASNCapability{Asn: 65001}),
+		mustAny(t, &api.UnknownCapability{Code: 200}),
+	}
+
+	want := []string{"mp-ipv4-unicast", "route-refresh", "graceful-restart", "4-octet-as", "unknown-200"}
+	if got := capabilityNames(caps); !reflect.DeepEqual(got, want) {
+		t.Errorf("capabilityNames() = %v, want %v", got, want)
+	}
+}
+
+// TestNeighborInfoPrefixCounts verifies received and accepted counts are kept apart
+func TestNeighborInfoPrefixCounts(t *testing.T) {
+	peer := &api.Peer{
+		Conf:  &api.PeerConf{NeighborAddress: "192.0.2.1", PeerAsn: 65002},
+		State: &api.PeerState{SessionState: api.PeerState_ESTABLISHED},
+		AfiSafis: []*api.AfiSafi{
+			{State: &api.AfiSafiState{Received: 100, Accepted: 90}},
+			{State: &api.AfiSafiState{Received: 20, Accepted: 20}},
+		},
+	}
+
+	info := neighborInfoFromPeer(peer)
+	if info.ReceivedPrefixes != 120 || info.AcceptedPrefixes != 110 {
+		t.Errorf("received/accepted = %d/%d, want 120/110", info.ReceivedPrefixes, info.AcceptedPrefixes)
+	}
+	if info.SessionState != "established" {
+		t.Errorf("SessionState = %q, want established", info.SessionState)
+	}
+}
+
+// TestListNeighbors verifies configured peers are listed with both counters
+func TestListNeighbors(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("Failed to add neighbor: %v", err)
+	}
+
+	neighbors, err := bgpService.ListNeighbors()
+	if err != nil {
+		t.Fatalf("ListNeighbors() error = %v", err)
+	}
+	if len(neighbors) != 1 || neighbors[0].Address != "192.0.2.1" || neighbors[0].ASN != 65002 {
+		t.Fatalf("ListNeighbors() = %+v, want the configured peer", neighbors)
+	}
+	// Never established, so nothing can have been received or accepted
+	if neighbors[0].ReceivedPrefixes != 0 || neighbors[0].AcceptedPrefixes != 0 {
+		t.Errorf("counters = %d/%d, want 0/0", neighbors[0].ReceivedPrefixes, neighbors[0].AcceptedPrefixes)
+	}
+}
+
+// TestMaxNeighbors verifies peers beyond bgp.maxNeighbors are refused
+func TestMaxNeighbors(t *testing.T) {
+	config := &Config{}
+	config.BGP.MaxNeighbors = 2
+	bgpService, _ := newFakeService(t, config)
+
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2"} {
+		if err := bgpService.AddNeighbor(addr, 65002); err != nil {
+			t.Fatalf("AddNeighbor(%s) error = %v", addr, err)
+		}
+	}
+	if err := bgpService.AddNeighbor("192.0.2.3", 65002); !errors.Is(err, ErrTooManyNeighbors) {
+		t.Errorf("AddNeighbor(192.0.2.3) error = %v, want ErrTooManyNeighbors", err)
+	}
+}
+
+// TestAllowedPeers verifies only the address and ASN pairs in
+// bgp.allowedPeers can be added
+func TestAllowedPeers(t *testing.T) {
+	config := &Config{}
+	config.BGP.AllowedPeers = []AllowedPeerConfig{{PeerIP: "192.0.2.1", ASN: 65002}}
+	bgpService, fake := newFakeService(t, config)
+
+	if err := bgpService.AddNeighbor("192.0.2.1", 65003); !errors.Is(err, ErrPeerNotAllowed) {
+		t.Errorf("AddNeighbor with the wrong ASN error = %v, want ErrPeerNotAllowed", err)
+	}
+	if err := bgpService.AddNeighbor("192.0.2.2", 65002); !errors.Is(err, ErrPeerNotAllowed) {
+		t.Errorf("AddNeighbor with an unlisted address error = %v, want ErrPeerNotAllowed", err)
+	}
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor with the allowed pair error = %v", err)
+	}
+	if len(fake.addPeers) != 1 {
+		t.Errorf("AddPeer called %d times, want only for the allowed pair", len(fake.addPeers))
+	}
+
+	// Addresses are compared parsed rather than as written
+	config.BGP.AllowedPeers = append(config.BGP.AllowedPeers, AllowedPeerConfig{PeerIP: "2001:DB8:0::1", ASN: 65004})
+	if err := bgpService.AddNeighbor("2001:db8::1", 65004); err != nil {
+		t.Errorf("AddNeighbor with the allowed pair spelt differently error = %v", err)
+	}
+}
+
+// TestAddNeighborDuplicate verifies a second add of the same address is refused
+func TestAddNeighborDuplicate(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor error = %v", err)
+	}
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); !errors.Is(err, ErrNeighborExists) {
+		t.Errorf("second AddNeighbor error = %v, want ErrNeighborExists", err)
+	}
+	if len(fake.addPeers) != 1 {
+		t.Errorf("AddPeer called %d times, want 1", len(fake.addPeers))
+	}
+}
+
+// TestUpsertNeighbor verifies upserting an existing peer replaces its ASN
+// in place rather than deleting and re-adding the peer
+func TestUpsertNeighbor(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor error = %v", err)
+	}
+	if err := bgpService.UpsertNeighbor(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65010}); err != nil {
+		t.Fatalf("UpsertNeighbor error = %v", err)
+	}
+
+	neighbors, err := bgpService.ListNeighbors()
+	if err != nil {
+		t.Fatalf("ListNeighbors error = %v", err)
+	}
+	if len(neighbors) != 1 || neighbors[0].ASN != 65010 {
+		t.Errorf("neighbors = %+v, want 192.0.2.1 with ASN 65010", neighbors)
+	}
+	if len(fake.addPeers) != 1 {
+		t.Errorf("AddPeer called %d times, want the peer updated in place", len(fake.addPeers))
+	}
+}
+
+// establishedServer wraps a real GoBGP instance, reporting every peer as an
+// established session and recording deletions and resets instead of
+// performing them, so a live session's lifecycle can be observed
+type establishedServer struct {
+	BgpServer
+
+	mu      sync.Mutex
+	deletes int
+	resets  []*api.ResetPeerRequest
+}
+
+func (e *establishedServer) ListPeer(ctx context.Context, r *api.ListPeerRequest, fn func(*api.Peer)) error {
+	return e.BgpServer.ListPeer(ctx, r, func(p *api.Peer) {
+		p.State.SessionState = api.PeerState_ESTABLISHED
+		fn(p)
+	})
+}
+
+func (e *establishedServer) DeletePeer(ctx context.Context, r *api.DeletePeerRequest) error {
+	e.mu.Lock()
+	e.deletes++
+	e.mu.Unlock()
+	return e.BgpServer.DeletePeer(ctx, r)
+}
+
+func (e *establishedServer) ResetPeer(_ context.Context, r *api.ResetPeerRequest) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resets = append(e.resets, r)
+	return nil
+}
+
+// addPeerServer wraps a real GoBGP instance, recording the export policies
+// in place whenever a peer is added and failing AddPeer for failAddress
+type addPeerServer struct {
+	BgpServer
+	service     *BGPService
+	failAddress string
+	policies    [][]string
+}
+
+func (a *addPeerServer) AddPeer(ctx context.Context, r *api.AddPeerRequest) error {
+	assignment, err := a.service.globalAssignment(api.PolicyDirection_EXPORT)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, p := range assignment.Policies {
+		names = append(names, p.Name)
+	}
+	a.policies = append(a.policies, names)
+	if r.Peer.Conf.NeighborAddress == a.failAddress {
+		return errors.New("AddPeer failed")
+	}
+	return a.BgpServer.AddPeer(ctx, r)
+}
+
+// TestAddNeighborPolicyOrder verifies export policies are in place before
+// the peer is added, and removed again when adding it fails
+func TestAddNeighborPolicyOrder(t *testing.T) {
+	srv := &addPeerServer{BgpServer: server.NewBgpServer(), failAddress: "192.0.2.2"}
+	bgpService := NewBGPServiceWithServer(&Config{}, srv)
+	bgpService.listenPort = -1
+	srv.service = bgpService
+	if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Failed to start BGP service: %v", err)
+	}
+	t.Cleanup(bgpService.Stop)
+
+	self := true
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2"} {
+		err := bgpService.AddNeighborConfig(NeighborConfig{
+			PeerIP:           addr,
+			ASN:              65002,
+			NeighborTemplate: NeighborTemplate{NextHopSelf: &self},
+		})
+		if wantErr := addr == srv.failAddress; (err != nil) != wantErr {
+			t.Fatalf("AddNeighborConfig(%s) error = %v, want error %v", addr, err, wantErr)
+		}
+	}
+	if len(srv.policies) != 2 || !contains(srv.policies[0], nextHopSelfPolicy("192.0.2.1")) {
+		t.Fatalf("export policies when adding 192.0.2.1 = %v, want %s installed first", srv.policies, nextHopSelfPolicy("192.0.2.1"))
+	}
+	policies := assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT)
+	if contains(policies, nextHopSelfPolicy("192.0.2.2")) {
+		t.Errorf("export policies = %v, still including the failed neighbor's", policies)
+	}
+	if bgpService.hasNextHopSelf("192.0.2.2") {
+		t.Error("failed neighbor still recorded as next-hop-self")
+	}
+}
+
+// TestUpdateNeighbor verifies a policy change is applied to an established
+// peer with an inbound soft reset rather than by recreating the peer
+func TestUpdateNeighbor(t *testing.T) {
+	srv := &establishedServer{BgpServer: server.NewBgpServer()}
+	bgpService := NewBGPServiceWithServer(&Config{}, srv)
+	bgpService.listenPort = -1
+	if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Failed to start BGP service: %v", err)
+	}
+	t.Cleanup(bgpService.Stop)
+
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor error = %v", err)
+	}
+	ignore := true
+	if err := bgpService.UpdateNeighbor(NeighborConfig{
+		PeerIP:           "192.0.2.1",
+		ASN:              65002,
+		NeighborTemplate: NeighborTemplate{IgnoreMED: &ignore},
+	}); err != nil {
+		t.Fatalf("UpdateNeighbor error = %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.deletes != 0 {
+		t.Errorf("peer deleted %d times, want the session kept", srv.deletes)
+	}
+	if len(srv.resets) != 1 || !srv.resets[0].Soft || srv.resets[0].Direction != api.ResetPeerRequest_IN {
+		t.Errorf("resets = %v, want one inbound soft reset", srv.resets)
+	}
+	if _, ok := bgpService.inboundMEDSetting("192.0.2.1"); !ok {
+		t.Error("ignoreMED policy was not installed")
+	}
+
+	if err := bgpService.UpdateNeighbor(NeighborConfig{PeerIP: "192.0.2.9", ASN: 65002}); !errors.Is(err, ErrNeighborNotFound) {
+		t.Errorf("UpdateNeighbor for an unknown peer error = %v, want ErrNeighborNotFound", err)
+	}
+}
+
+// TestShutdownNeighbor verifies a peer can be shut down and re-enabled in place
+func TestShutdownNeighbor(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor error = %v", err)
+	}
+
+	// The FSM applies admin state changes asynchronously, so poll briefly
+	adminState := func(want string) string {
+		t.Helper()
+		var got string
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			neighbors, err := bgpService.ListNeighbors()
+			if err != nil || len(neighbors) != 1 {
+				t.Fatalf("ListNeighbors = %v, %v, want one neighbor", neighbors, err)
+			}
+			if got = neighbors[0].AdminState; got == want {
+				break
+			}
+		}
+		return got
+	}
+
+	if err := bgpService.ShutdownNeighbor("192.0.2.1", true, ""); err != nil {
+		t.Fatalf("ShutdownNeighbor(true) error = %v", err)
+	}
+	if got := adminState("down"); got != "down" {
+		t.Errorf("AdminState after shutdown = %q, want down", got)
+	}
+
+	if err := bgpService.ShutdownNeighbor("192.0.2.1", false, ""); err != nil {
+		t.Fatalf("ShutdownNeighbor(false) error = %v", err)
+	}
+	if got := adminState("up"); got != "up" {
+		t.Errorf("AdminState after re-enable = %q, want up", got)
+	}
+
+	if err := bgpService.ShutdownNeighbor("192.0.2.9", true, ""); !errors.Is(err, ErrNeighborNotFound) {
+		t.Errorf("ShutdownNeighbor(unknown) error = %v, want ErrNeighborNotFound", err)
+	}
+}
+
+// TestRemoveNeighborReason verifies a removal reason is sent as the shutdown
+// communication before the peer is deleted, and over-length reasons are refused
+func TestRemoveNeighborReason(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2"} {
+		if err := bgpService.AddNeighbor(addr, 65002); err != nil {
+			t.Fatalf("AddNeighbor(%s) error = %v", addr, err)
+		}
+	}
+
+	reason := "maintenance window, back at 02:00 UTC"
+	if err := bgpService.RemoveNeighbor("192.0.2.1", reason); err != nil {
+		t.Fatalf("RemoveNeighbor error = %v", err)
+	}
+	if len(fake.disables) != 1 || fake.disables[0].Address != "192.0.2.1" || fake.disables[0].Communication != reason {
+		t.Errorf("DisablePeer requests = %v, want one for 192.0.2.1 with %q", fake.disables, reason)
+	}
+	if _, err := bgpService.GetNeighbor("192.0.2.1"); !errors.Is(err, ErrNeighborNotFound) {
+		t.Errorf("GetNeighbor after removal error = %v, want ErrNeighborNotFound", err)
+	}
+
+	// 128 bytes is the RFC 8203 limit; one more is refused and the peer kept
+	tooLong := strings.Repeat("x", maxShutdownCommunication+1)
+	if err := bgpService.RemoveNeighbor("192.0.2.2", tooLong); !errors.Is(err, ErrShutdownReasonTooLong) {
+		t.Errorf("RemoveNeighbor(long reason) error = %v, want ErrShutdownReasonTooLong", err)
+	}
+	if err := bgpService.ShutdownNeighbor("192.0.2.2", true, tooLong); !errors.Is(err, ErrShutdownReasonTooLong) {
+		t.Errorf("ShutdownNeighbor(long reason) error = %v, want ErrShutdownReasonTooLong", err)
+	}
+	if _, err := bgpService.GetNeighbor("192.0.2.2"); err != nil {
+		t.Errorf("GetNeighbor after refused removal error = %v", err)
+	}
+	if len(fake.disables) != 1 {
+		t.Errorf("DisablePeer called %d times, want 1", len(fake.disables))
+	}
+
+	if err := bgpService.ShutdownNeighbor("192.0.2.2", true, strings.Repeat("x", maxShutdownCommunication)); err != nil {
+		t.Errorf("ShutdownNeighbor(128-byte reason) error = %v", err)
+	}
+	if err := bgpService.RemoveNeighbor("192.0.2.9", ""); !errors.Is(err, ErrNeighborNotFound) {
+		t.Errorf("RemoveNeighbor(unknown) error = %v, want ErrNeighborNotFound", err)
+	}
+}
+
+// TestRouteRefresh verifies a refresh is requested only from capable, known peers
+func TestRouteRefresh(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2"} {
+		if err := bgpService.AddNeighbor(addr, 65002); err != nil {
+			t.Fatalf("AddNeighbor(%s) error = %v", addr, err)
+		}
+	}
+	// Only 192.0.2.1 advertised route refresh on its session
+	fake.peers[0].State.RemoteCap = []*anypb.Any{
+		mustAny(t, &api.RouteRefreshCapability{}),
+		mustAny(t, &api.MultiProtocolCapability{Family: &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST}}),
+	}
+
+	if err := bgpService.RouteRefresh("192.0.2.1", "ipv4-unicast"); err != nil {
+		t.Fatalf("RouteRefresh error = %v", err)
+	}
+	if len(fake.resets) != 1 || !fake.resets[0].Soft || fake.resets[0].Direction != api.ResetPeerRequest_IN {
+		t.Errorf("ResetPeer requests = %v, want one inbound soft reset", fake.resets)
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		family  string
+		want    error
+	}{
+		{name: "Unknown peer", address: "192.0.2.9", family: "ipv4-unicast", want: ErrNeighborNotFound},
+		{name: "No capability", address: "192.0.2.2", family: "ipv4-unicast", want: ErrRouteRefreshUnsupported},
+		{name: "Family not negotiated", address: "192.0.2.1", family: "ipv6-unicast", want: ErrRouteRefreshUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := bgpService.RouteRefresh(tt.address, tt.family); !errors.Is(err, tt.want) {
+				t.Errorf("RouteRefresh error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if len(fake.resets) != 1 {
+		t.Errorf("ResetPeer called %d times, want 1", len(fake.resets))
+	}
+}
+
+// TestSoftReconfigInbound verifies soft resets work against a peer without route refresh
+func TestSoftReconfigInbound(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	enabled := true
+	cfg := NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002}
+	cfg.Families = []string{"ipv4-unicast"}
+	cfg.SoftReconfigInbound = &enabled
+	if err := bgpService.AddNeighborConfig(cfg); err != nil {
+		t.Fatalf("AddNeighborConfig error = %v", err)
+	}
+	// The peer negotiated the family but not route refresh
+	fake.peers[0].State.RemoteCap = []*anypb.Any{
+		mustAny(t, &api.MultiProtocolCapability{Family: &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST}}),
+	}
+
+	running := bgpService.RunningConfig().BGP.Neighbors
+	if len(running) != 1 || running[0].SoftReconfigInbound == nil || !*running[0].SoftReconfigInbound {
+		t.Errorf("running neighbors = %+v, want softReconfigInbound set", running)
+	}
+	if err := bgpService.RouteRefresh("192.0.2.1", "ipv4-unicast"); err != nil {
+		t.Errorf("RouteRefresh error = %v", err)
+	}
+	if err := bgpService.ResetNeighbor("192.0.2.1", true); err != nil {
+		t.Errorf("ResetNeighbor(soft) error = %v", err)
+	}
+	if len(fake.resets) != 2 || !fake.resets[0].Soft || !fake.resets[1].Soft {
+		t.Errorf("ResetPeer requests = %v, want two soft resets", fake.resets)
+	}
+}
+
+// TestGetNeighborMessages verifies GoBGP's message counters reach GetNeighbor
+func TestGetNeighborMessages(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	if err := bgpService.AddNeighbor("192.0.2.1", 65002); err != nil {
+		t.Fatalf("AddNeighbor error = %v", err)
+	}
+	fake.peers[0].State.Messages = &api.Messages{
+		Sent:     &api.Message{Open: 1, Update: 4, Keepalive: 120, Total: 125},
+		Received: &api.Message{Open: 1, Update: 900, Keepalive: 118, Notification: 1, WithdrawUpdate: 3, WithdrawPrefix: 12, Total: 1020},
+	}
+
+	detail, err := bgpService.GetNeighbor("192.0.2.1")
+	if err != nil {
+		t.Fatalf("GetNeighbor error = %v", err)
+	}
+	if got, want := detail.MessagesSent, (MessageCounts{Open: 1, Update: 4, Keepalive: 120, Total: 125}); got != want {
+		t.Errorf("MessagesSent = %+v, want %+v", got, want)
+	}
+	want := MessageCounts{Open: 1, Update: 900, Keepalive: 118, Notification: 1, WithdrawUpdate: 3, WithdrawPrefix: 12, Total: 1020}
+	if got := detail.MessagesReceived; got != want {
+		t.Errorf("MessagesReceived = %+v, want %+v", got, want)
+	}
+
+	if _, err := bgpService.GetNeighbor("192.0.2.9"); !errors.Is(err, ErrNeighborNotFound) {
+		t.Errorf("GetNeighbor(unknown) error = %v, want %v", err, ErrNeighborNotFound)
+	}
+}
+
+// TestGetNeighborTimestamps verifies session up/down times are reported,
+// LastDown only once the session went down after coming up, and both left
+// zero for a peer that has never been established
+func TestGetNeighborTimestamps(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		if err := bgpService.AddNeighbor(addr, 65002); err != nil {
+			t.Fatalf("AddNeighbor(%s) error = %v", addr, err)
+		}
+	}
+	// GoBGP stamps Downtime when a peer is configured, before any session
+	configured := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	up := configured.Add(time.Hour)
+	down := up.Add(time.Hour)
+	fake.peers[0].State = &api.PeerState{SessionState: api.PeerState_ESTABLISHED}
+	fake.peers[0].Timers = &api.Timers{State: &api.TimersState{
+		Uptime:   timestamppb.New(up),
+		Downtime: timestamppb.New(configured),
+	}}
+	fake.peers[1].State = &api.PeerState{SessionState: api.PeerState_ACTIVE}
+	fake.peers[1].Timers = &api.Timers{State: &api.TimersState{
+		Uptime:   timestamppb.New(up),
+		Downtime: timestamppb.New(down),
+	}}
+	fake.peers[2].Timers = &api.Timers{State: &api.TimersState{Downtime: timestamppb.New(configured)}}
+
+	for _, tt := range []struct {
+		address  string
+		up, down time.Time
+	}{
+		{"192.0.2.1", up, time.Time{}}, // Established since it was configured
+		{"192.0.2.2", up, down},
+		{"192.0.2.3", time.Time{}, time.Time{}}, // Never established
+	} {
+		detail, err := bgpService.GetNeighbor(tt.address)
+		if err != nil {
+			t.Fatalf("GetNeighbor(%s) error = %v", tt.address, err)
+		}
+		if !detail.LastEstablished.Equal(tt.up) || !detail.LastDown.Equal(tt.down) {
+			t.Errorf("%s: LastEstablished, LastDown = %v, %v, want %v, %v", tt.address, detail.LastEstablished, detail.LastDown, tt.up, tt.down)
+		}
+	}
+}
+
+// TestNeighborRole verifies a valid BGP role is kept for the neighbor and an
+// unknown one refused
+func TestNeighborRole(t *testing.T) {
+	bgpService, _ := newFakeService(t, &Config{})
+
+	if err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.1",
+		ASN:              65002,
+		NeighborTemplate: NeighborTemplate{Role: "customer"},
+	}); err != nil {
+		t.Fatalf("AddNeighborConfig error = %v", err)
+	}
+	if got := bgpService.RunningConfig().BGP.Neighbors; len(got) != 1 || got[0].Role != "customer" {
+		t.Errorf("running config neighbors = %+v, want role customer", got)
+	}
+
+	err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.2",
+		ASN:              65002,
+		NeighborTemplate: NeighborTemplate{Role: "upstream"},
+	})
+	if err == nil {
+		t.Error("AddNeighborConfig with role upstream succeeded, want an error")
+	}
+}
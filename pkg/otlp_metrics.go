@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"time"
+)
+
+// defaultOTLPInterval is how often metrics are pushed when no interval is set
+const defaultOTLPInterval = time.Minute
+
+// OTLPConfig enables pushing the service metrics to an OpenTelemetry
+// collector over OTLP/HTTP; the export is disabled when Endpoint is empty
+type OTLPConfig struct {
+	Endpoint string            `yaml:"endpoint"` // Collector host:port, e.g. "otel-collector:4318"
+	Insecure bool              `yaml:"insecure"` // Use plain HTTP instead of HTTPS
+	Interval time.Duration     `yaml:"interval"` // Time between pushes, 1m when 0
+	Headers  map[string]string `yaml:"headers"`  // Sent with every export, e.g. for authentication
+}
+
+// OTLPExporter periodically pushes a service's metrics to a collector
+// The instruments are the Prometheus ones served on GET /metrics, read
+// through the OpenTelemetry Prometheus bridge, so both outputs always agree
+type OTLPExporter struct {
+	provider *sdkmetric.MeterProvider
+}
+
+// NewOTLPExporter starts pushing service's metrics as configured by cfg
+func NewOTLPExporter(service *BGPService, cfg OTLPConfig) (*OTLPExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("otlp: endpoint is required")
+	}
+	opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlpmetrichttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlpmetrichttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := cfg.Interval
+	if interval == 0 {
+		interval = defaultOTLPInterval
+	}
+	reader := sdkmetric.NewPeriodicReader(exporter,
+		sdkmetric.WithInterval(interval),
+		sdkmetric.WithProducer(prometheus.NewMetricProducer(prometheus.WithGatherer(service.metrics.registry))),
+	)
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithResource(resource.NewSchemaless(attribute.String("service.name", "bgpdash"))),
+	)
+	return &OTLPExporter{provider: provider}, nil
+}
+
+// Shutdown pushes the current metrics one last time and stops the exporter
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return e.provider.Shutdown(ctx)
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"context"
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestOTLPExport verifies the service metrics reach an OTLP collector
+func TestOTLPExport(t *testing.T) {
+	var mu sync.Mutex
+	sums := make(map[string]float64)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req colmetricpb.ExportMetricsServiceRequest
+		if r.URL.Path != "/v1/metrics" || proto.Unmarshal(body, &req) != nil {
+			http.Error(w, "bad export", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					for _, dp := range m.GetSum().GetDataPoints() {
+						sums[m.Name] = dp.GetAsDouble()
+					}
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(nil)
+	}))
+	defer collector.Close()
+
+	bgpService := NewBGPService()
+	exporter, err := NewOTLPExporter(bgpService, OTLPConfig{
+		Endpoint: strings.TrimPrefix(collector.URL, "http://"),
+		Insecure: true,
+		Interval: time.Hour, // Only the final push on Shutdown
+	})
+	if err != nil {
+		t.Fatalf("NewOTLPExporter failed: %v", err)
+	}
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := exporter.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got, ok := sums["bgpdash_updates_total"]; !ok || got != 2 {
+		t.Errorf("bgpdash_updates_total = %v (exported %t), want 2", got, ok)
+	}
+}
@@ -0,0 +1,310 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"log"
+	"net"
+)
+
+// ErrInvalidPrefix is returned when a prefix is not valid CIDR notation
+var ErrInvalidPrefix = errors.New("invalid prefix")
+
+// PathSpec describes a route to originate from this speaker
+// Optional attributes are left out of the advertisement when nil
+type PathSpec struct {
+	Prefix    string  // CIDR, e.g. "10.0.0.0/24" or "2001:db8::/32"
+	NextHop   string  // Address advertised as the next hop, bgp.local.defaultNextHop for IPv4 when empty
+	LocalPref *uint32 // LOCAL_PREF, only meaningful towards iBGP peers
+	MED       *uint32 // MULTI_EXIT_DISC, steers inbound traffic from a neighbor AS
+
+	// Prepend makes the route less preferred by prepending the ASN presented
+	// to each eBGP neighbor this many times, 0 to maxPrepend, on top of the
+	// copy every eBGP advertisement carries; iBGP neighbors and the local
+	// RIB see the route unchanged, see setPrepend
+	Prepend int
+}
+
+// maxPrepend caps PathSpec.Prepend; longer paths only bloat peers' RIBs
+const maxPrepend = 10
+
+// AddPath originates a route into the global RIB, advertising it to peers
+// Adding the same prefix again replaces the previous attributes
+// In monitor-only mode it fails with ErrMonitorOnly
+func (s *BGPService) AddPath(spec PathSpec) error {
+	if s.config.BGP.MonitorOnly {
+		return ErrMonitorOnly
+	}
+	if spec.NextHop == "" {
+		if ip, _, err := net.ParseCIDR(spec.Prefix); err == nil && ip.To4() != nil {
+			spec.NextHop = s.config.BGP.Local.DefaultNextHop
+		}
+	}
+	path, err := buildPath(spec)
+	if err != nil {
+		return err
+	}
+	if spec.Prepend < 0 || spec.Prepend > maxPrepend {
+		return fmt.Errorf("prepend count %d out of range 0-%d", spec.Prepend, maxPrepend)
+	}
+
+	// The export policy goes first, so the first advertisement is prepended
+	_, ipNet, _ := net.ParseCIDR(spec.Prefix)
+	s.neighborMu.Lock()
+	previous := s.prepends[ipNet.String()]
+	err = s.setPrepend(ipNet.String(), spec.Prepend)
+	s.neighborMu.Unlock()
+	if err != nil {
+		return err
+	}
+	if _, err = s.server.AddPath(s.context, &api.AddPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Path:      path,
+	}); err != nil {
+		s.neighborMu.Lock()
+		if err := s.setPrepend(ipNet.String(), previous); err != nil {
+			log.Printf("Error restoring the prepend policy of %s: %v", ipNet, err)
+		}
+		s.neighborMu.Unlock()
+		return err
+	}
+	return s.refreshAggregates()
+}
+
+// DeletePath withdraws a route previously originated with AddPath
+// Deleting the summary of an aggregate removes the aggregate, as
+// RemoveAggregate does
+func (s *BGPService) DeletePath(prefix string) error {
+	family, nlri, err := prefixNLRI(prefix)
+	if err != nil {
+		return err
+	}
+	_, ipNet, _ := net.ParseCIDR(prefix)
+	s.aggregateMu.Lock()
+	_, isAggregate := s.aggregates[ipNet.String()]
+	if isAggregate {
+		err = s.removeAggregateLocked(ipNet.String())
+	}
+	s.aggregateMu.Unlock()
+	if isAggregate {
+		return err
+	}
+
+	if err := s.server.DeletePath(s.context, &api.DeletePathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    family,
+		Path: &api.Path{
+			Family:     family,
+			Nlri:       nlri,
+			IsWithdraw: true,
+		},
+	}); err != nil {
+		return err
+	}
+	s.neighborMu.Lock()
+	err = s.setPrepend(ipNet.String(), 0)
+	s.neighborMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.refreshAggregates()
+}
+
+// ListPaths returns every path in the global RIB for the unicast families
+func (s *BGPService) ListPaths() ([]BGPUpdateMessage, error) {
+	return s.listPaths(func(*api.Path) bool { return true })
+}
+
+// ListLocalPaths returns the paths this speaker originated, e.g. with
+// AddPath or from the static routes file, leaving out routes learned from peers
+func (s *BGPService) ListLocalPaths() ([]BGPUpdateMessage, error) {
+	return s.listPaths(isLocalPath)
+}
+
+// WithdrawAllLocal withdraws every path this speaker originated, e.g. before
+// a graceful shutdown; routes learned from peers are untouched
+// Static routes are re-originated by the next LoadStaticRoutes, and no
+// longer when a pending bgp.advertisementDelay ends; aggregates are removed
+// along with the suppression of their more-specifics
+func (s *BGPService) WithdrawAllLocal() error {
+	s.staticMu.Lock()
+	defer s.staticMu.Unlock()
+	if s.staticHold != nil {
+		s.staticHold()
+		s.staticHeld, s.staticHold = false, nil
+	}
+	s.aggregateMu.Lock()
+	defer s.aggregateMu.Unlock()
+	for supernet := range s.aggregates {
+		if err := s.removeAggregateLocked(supernet); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{"ipv4-unicast", "ipv6-unicast"} {
+		family, _ := parseFamily(name)
+		// Without a path GoBGP deletes every local path in the family
+		err := s.server.DeletePath(s.context, &api.DeletePathRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    family,
+		})
+		if err != nil {
+			return fmt.Errorf("withdrawing %s paths: %w", name, err)
+		}
+	}
+	clear(s.staticRoutes)
+
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	for prefix := range s.prepends {
+		if err := s.setPrepend(prefix, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isLocalPath reports whether p was originated by this speaker; GoBGP
+// leaves the neighbor address of local paths unset
+func isLocalPath(p *api.Path) bool {
+	return net.ParseIP(p.GetNeighborIp()) == nil
+}
+
+// listPaths returns the unicast global RIB paths for which keep is true
+func (s *BGPService) listPaths(keep func(*api.Path) bool) ([]BGPUpdateMessage, error) {
+	var updates []BGPUpdateMessage
+	for _, name := range []string{"ipv4-unicast", "ipv6-unicast"} {
+		family, _ := parseFamily(name)
+		err := s.server.ListPath(s.context, &api.ListPathRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    family,
+		}, func(d *api.Destination) {
+			for i, u := range s.parseDestination(d) {
+				if keep(d.Paths[i]) {
+					updates = append(updates, u)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return updates, nil
+}
+
+// LookupPrefix returns the RIB paths for cidr
+// With longestMatch set, the most specific RIB prefix covering cidr is used
+// when cidr itself is not present; an empty result means nothing matched
+func (s *BGPService) LookupPrefix(cidr string, longestMatch bool) ([]BGPUpdateMessage, error) {
+	family, _, err := prefixNLRI(cidr)
+	if err != nil {
+		return nil, err
+	}
+	_, ipNet, _ := net.ParseCIDR(cidr)
+
+	lookupType := api.TableLookupPrefix_EXACT
+	if longestMatch {
+		// SHORTER returns every covering prefix, we keep the longest below
+		lookupType = api.TableLookupPrefix_SHORTER
+	}
+
+	var best *api.Destination
+	bestLen := -1
+	err = s.server.ListPath(s.context, &api.ListPathRequest{
+		TableType: api.TableType_GLOBAL,
+		Family:    family,
+		Prefixes:  []*api.TableLookupPrefix{{Prefix: ipNet.String(), Type: lookupType}},
+	}, func(d *api.Destination) {
+		_, destNet, err := net.ParseCIDR(d.Prefix)
+		if err != nil {
+			return
+		}
+		if ones, _ := destNet.Mask.Size(); ones > bestLen {
+			best, bestLen = d, ones
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if best == nil {
+		return []BGPUpdateMessage{}, nil
+	}
+	return s.parseDestination(best), nil
+}
+
+// parseDestination parses every path to a prefix, marking the best one
+func (s *BGPService) parseDestination(d *api.Destination) []BGPUpdateMessage {
+	updates := make([]BGPUpdateMessage, 0, len(d.Paths))
+	for _, p := range d.Paths {
+		updates = append(updates, s.parsePath(p))
+	}
+	markBestPath(d.Paths, updates)
+	return updates
+}
+
+// buildPath translates a PathSpec into the GoBGP path to originate
+// Prepend is left to the export policy setPrepend installs
+func buildPath(spec PathSpec) (*api.Path, error) {
+	family, nlri, err := prefixNLRI(spec.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	if net.ParseIP(spec.NextHop) == nil {
+		return nil, fmt.Errorf("invalid next hop %q", spec.NextHop)
+	}
+	// GoBGP turns NEXT_HOP into MP_REACH_NLRI itself for non IPv4 families
+	attrs := []proto.Message{
+		&api.OriginAttribute{Origin: 0}, // IGP
+		&api.NextHopAttribute{NextHop: spec.NextHop},
+	}
+	if spec.LocalPref != nil {
+		attrs = append(attrs, &api.LocalPrefAttribute{LocalPref: *spec.LocalPref})
+	}
+	if spec.MED != nil {
+		attrs = append(attrs, &api.MultiExitDiscAttribute{Med: *spec.MED})
+	}
+	pattrs, err := marshalAttrs(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Path{
+		Family: family,
+		Nlri:   nlri,
+		Pattrs: pattrs,
+	}, nil
+}
+
+// marshalAttrs wraps path attributes in the Any messages GoBGP expects
+func marshalAttrs(attrs []proto.Message) ([]*anypb.Any, error) {
+	pattrs := make([]*anypb.Any, 0, len(attrs))
+	for _, attr := range attrs {
+		a, err := anypb.New(attr)
+		if err != nil {
+			return nil, err
+		}
+		pattrs = append(pattrs, a)
+	}
+	return pattrs, nil
+}
+
+// prefixNLRI parses a CIDR into its GoBGP family and NLRI
+func prefixNLRI(prefix string) (*api.Family, *anypb.Any, error) {
+	ip, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w %q: %w", ErrInvalidPrefix, prefix, err)
+	}
+	family, _ := parseFamily("ipv6-unicast")
+	if ip.To4() != nil {
+		family, _ = parseFamily("ipv4-unicast")
+	}
+	ones, _ := ipNet.Mask.Size()
+	nlri, err := anypb.New(&api.IPAddressPrefix{
+		Prefix:    ipNet.IP.String(),
+		PrefixLen: uint32(ones),
+	})
+	return family, nlri, err
+}
@@ -0,0 +1,321 @@
+package pkg
+
+import (
+	"context"
+	api "github.com/osrg/gobgp/v3/api"
+	"google.golang.org/protobuf/types/known/anypb"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// findPath returns the listed path for prefix or fails the test
+func findPath(t *testing.T, s *BGPService, prefix string) BGPUpdateMessage {
+	t.Helper()
+	_, want, _ := net.ParseCIDR(prefix)
+	paths, err := s.ListPaths()
+	if err != nil {
+		t.Fatalf("ListPaths() error = %v", err)
+	}
+	for _, p := range paths {
+		for _, n := range p.NLRI {
+			if n.Prefix.Equal(want.IP) {
+				return p
+			}
+		}
+	}
+	t.Fatalf("prefix %s not found in %d listed paths", prefix, len(paths))
+	return BGPUpdateMessage{}
+}
+
+// TestAddPathLocalPref verifies LOCAL_PREF is attached only when requested
+func TestAddPathLocalPref(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	localPref := uint32(200)
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254", LocalPref: &localPref}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.1.0/24", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+
+	if got := findPath(t, bgpService, "10.0.0.0/24").LocalPref; got == nil || *got != 200 {
+		t.Errorf("LocalPref = %v, want 200", got)
+	}
+	if got := findPath(t, bgpService, "10.0.1.0/24").LocalPref; got != nil {
+		t.Errorf("LocalPref = %d, want it omitted", *got)
+	}
+}
+
+// TestAddPathMED verifies MULTI_EXIT_DISC is attached only when requested
+func TestAddPathMED(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	med := uint32(50)
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254", MED: &med}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.1.0/24", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+
+	if got := findPath(t, bgpService, "10.0.0.0/24").MED; got == nil || *got != 50 {
+		t.Errorf("MED = %v, want 50", got)
+	}
+	if got := findPath(t, bgpService, "10.0.1.0/24").MED; got != nil {
+		t.Errorf("MED = %d, want it omitted", *got)
+	}
+}
+
+// TestAddPathPrepend verifies an eBGP peer receives the route with the
+// local ASN prepended on top of its own copy, while the RIB path and iBGP
+// neighbors are left alone, and out of range counts are refused
+func TestAddPathPrepend(t *testing.T) {
+	remote, port := newRemotePeer(t)
+	bgpService := newTestService(t, &Config{})
+	if err := bgpService.AddNeighborConfig(NeighborConfig{PeerIP: "127.0.0.1", ASN: 65002, PeerPort: port}); err != nil {
+		t.Fatalf("AddNeighborConfig() error = %v", err)
+	}
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254", Prepend: 3}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if got := findPath(t, bgpService, "10.0.0.0/24").ASPath; len(got) != 0 {
+		t.Errorf("RIB ASPath = %v, want it empty", got)
+	}
+
+	family, _ := parseFamily("ipv4-unicast")
+	var received []uint32
+	deadline := time.Now().Add(10 * time.Second)
+	for received == nil {
+		err := remote.ListPath(context.Background(), &api.ListPathRequest{
+			TableType: api.TableType_GLOBAL,
+			Family:    family,
+			Prefixes:  []*api.TableLookupPrefix{{Prefix: "10.0.0.0/24"}},
+		}, func(d *api.Destination) {
+			for _, attr := range d.Paths[0].Pattrs {
+				if m, err := attr.UnmarshalNew(); err == nil {
+					if asPath, ok := m.(*api.AsPathAttribute); ok {
+						received = asPath.Segments[0].Numbers
+					}
+				}
+			}
+		})
+		if err != nil {
+			t.Fatalf("ListPath() error = %v", err)
+		}
+		if received == nil && time.Now().After(deadline) {
+			t.Fatal("peer never received 10.0.0.0/24")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if want := []uint32{65001, 65001, 65001, 65001}; !reflect.DeepEqual(received, want) {
+		t.Errorf("AS path received by the eBGP peer = %v, want %v", received, want)
+	}
+
+	// An iBGP neighbor added later is kept out of the policy
+	if err := bgpService.AddNeighborConfig(NeighborConfig{PeerIP: "192.0.2.10", ASN: 65001}); err != nil {
+		t.Fatalf("AddNeighborConfig() error = %v", err)
+	}
+	policy := listPolicy(t, bgpService, prependPolicy("10.0.0.0/24"))
+	if policy == nil || len(policy.Statements) != 1 {
+		t.Fatalf("policy = %v, want one statement for AS 65001", policy)
+	}
+	var neighbors []string
+	err := bgpService.server.ListDefinedSet(bgpService.context, &api.ListDefinedSetRequest{
+		DefinedType: api.DefinedType_NEIGHBOR,
+		Name:        policy.Statements[0].Conditions.NeighborSet.Name,
+	}, func(set *api.DefinedSet) { neighbors = set.List })
+	if err != nil {
+		t.Fatalf("ListDefinedSet() error = %v", err)
+	}
+	if want := []string{"127.0.0.1/32"}; !reflect.DeepEqual(neighbors, want) {
+		t.Errorf("prepended towards %v, want %v", neighbors, want)
+	}
+
+	if err := bgpService.DeletePath("10.0.0.0/24"); err != nil {
+		t.Fatalf("DeletePath() error = %v", err)
+	}
+	if listPolicy(t, bgpService, prependPolicy("10.0.0.0/24")) != nil {
+		t.Error("prepend policy still installed after DeletePath")
+	}
+
+	for _, n := range []int{-1, 11} {
+		if err := bgpService.AddPath(PathSpec{Prefix: "10.0.1.0/24", NextHop: "192.0.2.254", Prepend: n}); err == nil {
+			t.Errorf("AddPath() with Prepend %d succeeded, want an error", n)
+		}
+	}
+}
+
+// TestListPathsBestPathReason verifies the path GoBGP selected is marked
+// best, with the attribute it won on as the reason
+func TestListPathsBestPathReason(t *testing.T) {
+	// peerPath builds a path to 10.0.0.0/24 from peer with the given attributes
+	peerPath := func(peer string, best bool, localPref uint32, asPath ...uint32) *api.Path {
+		return &api.Path{
+			Family:     &api.Family{Afi: api.Family_AFI_IP, Safi: api.Family_SAFI_UNICAST},
+			Nlri:       mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"}),
+			NeighborIp: peer,
+			Best:       best,
+			Pattrs: []*anypb.Any{
+				mustAny(t, &api.OriginAttribute{Origin: 0}),
+				mustAny(t, &api.LocalPrefAttribute{LocalPref: localPref}),
+				mustAny(t, &api.AsPathAttribute{Segments: []*api.AsSegment{{Type: asSequence, Numbers: asPath}}}),
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		paths []*api.Path
+		want  string
+	}{
+		{
+			name:  "Local preference",
+			paths: []*api.Path{peerPath("192.0.2.1", false, 100, 65002), peerPath("192.0.2.2", true, 200, 65003, 65010)},
+			want:  "higher local-pref (200 vs 100)",
+		},
+		{
+			name:  "AS path length",
+			paths: []*api.Path{peerPath("192.0.2.1", true, 100, 65002), peerPath("192.0.2.2", false, 100, 65003, 65010)},
+			want:  "shorter AS path (1 vs 2)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bgpService, fake := newFakeService(t, &Config{})
+			fake.mu.Lock()
+			fake.paths = tt.paths
+			fake.mu.Unlock()
+
+			paths, err := bgpService.ListPaths()
+			if err != nil {
+				t.Fatalf("ListPaths() error = %v", err)
+			}
+			if len(paths) != 2 {
+				t.Fatalf("ListPaths() returned %d paths, want 2", len(paths))
+			}
+			for i, p := range paths {
+				if p.Best != tt.paths[i].Best {
+					t.Errorf("path from %s Best = %v, want %v", p.FromPeer, p.Best, tt.paths[i].Best)
+				}
+				if p.Best && p.BestPathReason != tt.want {
+					t.Errorf("BestPathReason = %q, want %q", p.BestPathReason, tt.want)
+				}
+				if !p.Best && p.BestPathReason != "" {
+					t.Errorf("path from %s not best but has reason %q", p.FromPeer, p.BestPathReason)
+				}
+			}
+		})
+	}
+}
+
+// TestDeletePath verifies an originated route can be withdrawn again
+func TestDeletePath(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.AddPath(PathSpec{Prefix: "2001:db8::/32", NextHop: "2001:db8::1"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if err := bgpService.DeletePath("2001:db8::/32"); err != nil {
+		t.Fatalf("DeletePath() error = %v", err)
+	}
+
+	paths, err := bgpService.ListPaths()
+	if err != nil {
+		t.Fatalf("ListPaths() error = %v", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("ListPaths() returned %d paths after delete, want 0", len(paths))
+	}
+}
+
+// TestAddPathInvalid verifies malformed prefixes and next hops are rejected
+func TestAddPathInvalid(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0", NextHop: "192.0.2.254"}); err == nil {
+		t.Error("AddPath() should fail without a prefix length")
+	}
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24", NextHop: "bogus"}); err == nil {
+		t.Error("AddPath() should fail for an invalid next hop")
+	}
+}
+
+// TestAddPathDefaultNextHop verifies an empty next hop falls back to the configured default
+func TestAddPathDefaultNextHop(t *testing.T) {
+	config := &Config{}
+	config.BGP.Local.DefaultNextHop = "192.0.2.10"
+	bgpService, fake := newFakeService(t, config)
+
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24"}); err != nil {
+		t.Fatalf("AddPath() error = %v", err)
+	}
+	if got := findPath(t, bgpService, "10.0.0.0/24").NextHop.String(); got != "192.0.2.10" {
+		t.Errorf("NextHop = %q, want 192.0.2.10", got)
+	}
+	if len(fake.paths) != 1 {
+		t.Errorf("AddPath called %d times, want 1", len(fake.paths))
+	}
+
+	// The default is IPv4 only, so an IPv6 route still needs its own next hop
+	if err := bgpService.AddPath(PathSpec{Prefix: "2001:db8::/32"}); err == nil {
+		t.Error("AddPath() succeeded for IPv6 without a next hop")
+	}
+}
+
+// TestWithdrawAllLocal verifies originated paths are listed and withdrawn together
+func TestWithdrawAllLocal(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	for _, spec := range []PathSpec{
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254"},
+		{Prefix: "2001:db8::/32", NextHop: "2001:db8::1"},
+	} {
+		if err := bgpService.AddPath(spec); err != nil {
+			t.Fatalf("AddPath() error = %v", err)
+		}
+	}
+	local, err := bgpService.ListLocalPaths()
+	if err != nil {
+		t.Fatalf("ListLocalPaths() error = %v", err)
+	}
+	if len(local) != 2 {
+		t.Fatalf("ListLocalPaths() returned %d paths, want 2", len(local))
+	}
+
+	if err := bgpService.WithdrawAllLocal(); err != nil {
+		t.Fatalf("WithdrawAllLocal() error = %v", err)
+	}
+	if local, _ = bgpService.ListLocalPaths(); len(local) != 0 {
+		t.Errorf("ListLocalPaths() returned %d paths after withdrawing, want 0", len(local))
+	}
+	if paths, _ := bgpService.ListPaths(); len(paths) != 0 {
+		t.Errorf("ListPaths() returned %d paths after withdrawing, want 0", len(paths))
+	}
+}
+
+// TestListLocalPathsSkipsLearned verifies routes learned from a peer are not listed as local
+func TestListLocalPathsSkipsLearned(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	fake.paths = append(fake.paths,
+		&api.Path{NeighborIp: "192.0.2.1", Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "198.51.100.0"})},
+		&api.Path{NeighborIp: "<nil>", Nlri: mustAny(t, &api.IPAddressPrefix{PrefixLen: 24, Prefix: "10.0.0.0"})},
+	)
+
+	local, err := bgpService.ListLocalPaths()
+	if err != nil {
+		t.Fatalf("ListLocalPaths() error = %v", err)
+	}
+	// The fake lists its paths once per unicast family
+	for _, p := range local {
+		if p.FromPeer == "192.0.2.1" {
+			t.Errorf("ListLocalPaths() included a path learned from %s", p.FromPeer)
+		}
+	}
+	if len(local) == 0 {
+		t.Error("ListLocalPaths() left out the local path")
+	}
+}
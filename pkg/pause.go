@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"fmt"
+	"log"
+)
+
+// defaultPauseBuffer is the number of updates held while paused in buffer mode
+const defaultPauseBuffer = 10000
+
+// PauseMode chooses what happens to updates that arrive while delivery is paused
+type PauseMode string
+
+const (
+	PauseDrop   PauseMode = "drop"   // Discard them; consumers resume from live updates
+	PauseBuffer PauseMode = "buffer" // Hold them, up to subscribers.pauseBuffer, and deliver them on resume
+)
+
+// validatePauseMode rejects modes other than the known ones or empty
+func validatePauseMode(mode PauseMode) error {
+	switch mode {
+	case "", PauseDrop, PauseBuffer:
+		return nil
+	}
+	return fmt.Errorf("unknown pause mode %q, want %s or %s", mode, PauseDrop, PauseBuffer)
+}
+
+// PauseUpdates stops delivering updates to handlers, subscribers and the
+// recent updates, e.g. during maintenance; sessions stay up and the route
+// cache, sequence numbers and update counter keep tracking every update
+// Updates arriving meanwhile are dropped or buffered per subscribers.pauseMode,
+// and every one discarded is counted in Stats.PausedDropped
+func (s *BGPService) PauseUpdates() {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	if !s.paused {
+		s.paused = true
+		log.Printf("Update delivery paused")
+	}
+}
+
+// ResumeUpdates delivers any buffered updates in order, then resumes live delivery
+// The buffered updates are delivered before it returns unless another
+// goroutine is already delivering, which then delivers them too
+func (s *BGPService) ResumeUpdates() {
+	s.pauseMu.Lock()
+	if !s.paused {
+		s.pauseMu.Unlock()
+		return
+	}
+	log.Printf("Update delivery resumed, %d buffered updates to deliver", len(s.pauseBuffer))
+	// Queued behind anything still being delivered from before the pause
+	// and ahead of live updates, which can only be queued from now on
+	s.pending = append(s.pending, s.pauseBuffer...)
+	s.pauseBuffer = nil
+	s.paused = false
+	s.drainLocked()
+}
+
+// deliver fans update out unless delivery is paused, in which case it is
+// buffered or dropped
+func (s *BGPService) deliver(update BGPUpdateMessage) {
+	s.pauseMu.Lock()
+	if !s.paused {
+		s.pending = append(s.pending, update)
+		s.drainLocked()
+		return
+	}
+	defer s.pauseMu.Unlock()
+	limit := s.config.Subscribers.PauseBuffer
+	if limit <= 0 {
+		limit = defaultPauseBuffer
+	}
+	if s.config.Subscribers.PauseMode == PauseBuffer && len(s.pauseBuffer) < limit {
+		s.pauseBuffer = append(s.pauseBuffer, update)
+		return
+	}
+	s.metrics.pausedDropped.Add(1)
+}
+
+// drainLocked fans out the pending updates in order and unlocks pauseMu
+// Only one goroutine drains at a time, keeping the order; any other returns
+// at once, leaving its updates to the one draining
+// pauseMu is released around each batch so a slow handler does not hold
+// up pausing, resuming or Stats, and a handler may call any of them
+func (s *BGPService) drainLocked() {
+	if s.delivering {
+		s.pauseMu.Unlock()
+		return
+	}
+	s.delivering = true
+	for len(s.pending) > 0 {
+		batch := s.pending
+		s.pending = nil
+		s.pauseMu.Unlock()
+		for _, update := range batch {
+			s.fanOut(update)
+		}
+		s.pauseMu.Lock()
+	}
+	s.delivering = false
+	s.pauseMu.Unlock()
+}
+
+// fanOut hands update, trimmed to output.fields, to the recent updates,
+// every handler and every subscriber
+func (s *BGPService) fanOut(update BGPUpdateMessage) {
+	s.mu.RLock()
+	handlers := s.handlers
+	s.mu.RUnlock()
+
+	selected := selectFields(update, s.config.Output.Fields)
+	s.recent.add(selected)
+	for _, h := range handlers {
+		h.HandleUpdate(selected)
+	}
+	s.publish(update, selected)
+}
+
+// pausedBuffered returns the number of updates waiting for ResumeUpdates
+func (s *BGPService) pausedBuffered() int {
+	s.pauseMu.Lock()
+	defer s.pauseMu.Unlock()
+	return len(s.pauseBuffer)
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPauseUpdates verifies nothing is delivered while paused and that the
+// updates arriving meanwhile are dropped or replayed per subscribers.pauseMode
+func TestPauseUpdates(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          PauseMode
+		buffer        int
+		wantDelivered []string
+		wantDropped   uint64
+	}{
+		{name: "Drop", mode: PauseDrop, wantDropped: 3},
+		{name: "Default drops", mode: "", wantDropped: 3},
+		{name: "Buffer", mode: PauseBuffer, wantDelivered: []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}},
+		{name: "Buffer overflow", mode: PauseBuffer, buffer: 2, wantDelivered: []string{"192.0.2.1", "192.0.2.2"}, wantDropped: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			config.Subscribers.PauseMode = tt.mode
+			config.Subscribers.PauseBuffer = tt.buffer
+			bgpService, _ := newFakeService(t, config)
+			updates, unsubscribe := bgpService.Subscribe()
+			defer unsubscribe()
+
+			bgpService.PauseUpdates()
+			for _, peer := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+				bgpService.dispatch(BGPUpdateMessage{FromPeer: peer})
+			}
+			if len(updates) != 0 {
+				t.Fatalf("%d updates delivered while paused, want none", len(updates))
+			}
+			if recent := bgpService.RecentUpdates(0); len(recent) != 0 {
+				t.Errorf("%d recent updates recorded while paused, want none", len(recent))
+			}
+			stats := bgpService.Stats()
+			if stats.Updates != 3 || stats.PausedDropped != tt.wantDropped || stats.PausedBuffered != len(tt.wantDelivered) {
+				t.Errorf("Stats while paused = %+v, want 3 updates, %d dropped, %d buffered", stats, tt.wantDropped, len(tt.wantDelivered))
+			}
+
+			bgpService.ResumeUpdates()
+			for _, want := range tt.wantDelivered {
+				if got := receive(t, updates); got.FromPeer != want {
+					t.Errorf("resumed delivery from %s, want %s", got.FromPeer, want)
+				}
+			}
+			if len(updates) != 0 {
+				t.Errorf("%d extra updates delivered on resume", len(updates))
+			}
+
+			// Live delivery carries on after the buffered updates
+			bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.4"})
+			if got := receive(t, updates); got.FromPeer != "192.0.2.4" {
+				t.Errorf("live update from %s after resume, want 192.0.2.4", got.FromPeer)
+			}
+			if stats := bgpService.Stats(); stats.PausedBuffered != 0 || stats.PausedDropped != tt.wantDropped {
+				t.Errorf("Stats after resume = %+v, want nothing buffered and %d dropped", stats, tt.wantDropped)
+			}
+		})
+	}
+}
+
+// TestPauseModeInvalid verifies Start rejects an unknown pause mode
+func TestPauseModeInvalid(t *testing.T) {
+	config := &Config{}
+	config.Subscribers.PauseMode = "queue"
+	if err := NewBGPServiceWithServer(config, newFakeBgpServer()).Start("192.0.2.254", 65001); err == nil {
+		t.Error("Start() accepted pause mode \"queue\"")
+	}
+}
+
+// TestPauseNotBlockedByHandlers verifies a slow handler neither holds up
+// PauseUpdates and Stats nor deadlocks when it calls them itself
+func TestPauseNotBlockedByHandlers(t *testing.T) {
+	bgpService, _ := newFakeService(t, &Config{})
+	entered, release := make(chan struct{}), make(chan struct{})
+	bgpService.AddUpdateHandler(UpdateHandlerFunc(func(update BGPUpdateMessage) {
+		bgpService.Stats()
+		if update.FromPeer == "192.0.2.1" {
+			close(entered)
+			<-release
+		}
+	}))
+
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+	}()
+	<-entered
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bgpService.PauseUpdates()
+		bgpService.Stats()
+		bgpService.ResumeUpdates()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("PauseUpdates, Stats or ResumeUpdates blocked behind a handler")
+	}
+	close(release)
+	<-dispatched
+}
@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+)
+
+// familyNames maps the family names accepted in config to GoBGP AFI/SAFI pairs
+var familyNames = map[string]struct {
+	afi  api.Family_Afi
+	safi api.Family_Safi
+}{
+	"ipv4-unicast": {api.Family_AFI_IP, api.Family_SAFI_UNICAST},
+	"ipv6-unicast": {api.Family_AFI_IP6, api.Family_SAFI_UNICAST},
+
+	"l3vpn-ipv4-unicast": {api.Family_AFI_IP, api.Family_SAFI_MPLS_VPN},
+	"l3vpn-ipv6-unicast": {api.Family_AFI_IP6, api.Family_SAFI_MPLS_VPN},
+
+	"ipv4-flowspec": {api.Family_AFI_IP, api.Family_SAFI_FLOW_SPEC_UNICAST},
+	"ipv6-flowspec": {api.Family_AFI_IP6, api.Family_SAFI_FLOW_SPEC_UNICAST},
+
+	"l2vpn-evpn": {api.Family_AFI_L2VPN, api.Family_SAFI_EVPN},
+}
+
+// parseFamily converts a config family name into a GoBGP family
+// A fresh *api.Family is returned on every call since protobuf messages must not be shared
+func parseFamily(name string) (*api.Family, error) {
+	f, ok := familyNames[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown address family %q", name)
+	}
+	return &api.Family{Afi: f.afi, Safi: f.safi}, nil
+}
+
+// merge returns t with every unset field filled in from base
+func (t NeighborTemplate) merge(base NeighborTemplate) NeighborTemplate {
+	if len(t.Families) == 0 {
+		t.Families = base.Families
+	}
+	if t.HoldTime == 0 {
+		t.HoldTime = base.HoldTime
+	}
+	if t.KeepaliveInterval == 0 {
+		t.KeepaliveInterval = base.KeepaliveInterval
+	}
+	if t.SoftReconfigInbound == nil {
+		t.SoftReconfigInbound = base.SoftReconfigInbound
+	}
+	if t.Role == "" {
+		t.Role = base.Role
+	}
+	if t.SendCommunity == "" {
+		t.SendCommunity = base.SendCommunity
+	}
+	if t.IgnoreMED == nil {
+		t.IgnoreMED = base.IgnoreMED
+	}
+	if t.OverrideMED == nil {
+		t.OverrideMED = base.OverrideMED
+	}
+	if t.NextHopSelf == nil {
+		t.NextHopSelf = base.NextHopSelf
+	}
+	if t.GracefulRestart == nil {
+		t.GracefulRestart = base.GracefulRestart
+	}
+	if t.RestartTime == 0 {
+		t.RestartTime = base.RestartTime
+	}
+	if t.MaxPrefixes == 0 {
+		t.MaxPrefixes = base.MaxPrefixes
+	}
+	if t.AddPaths == (AddPathsConfig{}) {
+		t.AddPaths = base.AddPaths
+	}
+	if t.AllowASIn == 0 {
+		t.AllowASIn = base.AllowASIn
+	}
+	if t.AuthPassword == "" {
+		t.AuthPassword = base.AuthPassword
+	}
+	return t
+}
+
+// resolveNeighbor returns the effective neighbor config after applying its peer group
+// Neighbors without a peer group are returned unchanged
+func (s *BGPService) resolveNeighbor(cfg NeighborConfig) (NeighborConfig, error) {
+	if cfg.PeerGroup == "" {
+		return cfg, nil
+	}
+
+	for _, group := range s.config.BGP.PeerGroups {
+		if group.Name == cfg.PeerGroup {
+			cfg.NeighborTemplate = cfg.NeighborTemplate.merge(group.NeighborTemplate)
+			return cfg, nil
+		}
+	}
+	return cfg, fmt.Errorf("%w %s: unknown peer group %q", ErrInvalidNeighbor, cfg.PeerIP, cfg.PeerGroup)
+}
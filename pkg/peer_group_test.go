@@ -0,0 +1,143 @@
+package pkg
+
+import (
+	"context"
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+	"testing"
+)
+
+// TestPeerGroupInheritance verifies neighbors inherit group settings and can override them
+func TestPeerGroupInheritance(t *testing.T) {
+	config := &Config{}
+	config.BGP.PeerGroups = []PeerGroupConfig{
+		{
+			Name: "transit",
+			NeighborTemplate: NeighborTemplate{
+				Families:    []string{"ipv4-unicast", "ipv6-unicast"},
+				HoldTime:    90,
+				MaxPrefixes: 1000,
+			},
+		},
+	}
+	bgpService := NewBGPServiceWithConfig(config)
+
+	tests := []struct {
+		name         string
+		neighbor     NeighborConfig
+		wantHoldTime uint64
+	}{
+		{
+			name:         "Inherits group hold time",
+			neighbor:     NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002, PeerGroup: "transit"},
+			wantHoldTime: 90,
+		},
+		{
+			name: "Overrides group hold time",
+			neighbor: NeighborConfig{
+				PeerIP:           "192.0.2.2",
+				ASN:              65003,
+				PeerGroup:        "transit",
+				NeighborTemplate: NeighborTemplate{HoldTime: 30},
+			},
+			wantHoldTime: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := bgpService.resolveNeighbor(tt.neighbor)
+			if err != nil {
+				t.Fatalf("resolveNeighbor() error = %v", err)
+			}
+			peer, err := buildPeer(resolved)
+			if err != nil {
+				t.Fatalf("buildPeer() error = %v", err)
+			}
+
+			if got := peer.GetTimers().GetConfig().GetHoldTime(); got != tt.wantHoldTime {
+				t.Errorf("hold time = %d, want %d", got, tt.wantHoldTime)
+			}
+			if len(peer.AfiSafis) != 2 {
+				t.Fatalf("got %d AfiSafis, want 2 inherited from the group", len(peer.AfiSafis))
+			}
+			for _, afiSafi := range peer.AfiSafis {
+				if got := afiSafi.GetPrefixLimits().GetMaxPrefixes(); got != 1000 {
+					t.Errorf("max prefixes = %d, want 1000", got)
+				}
+			}
+		})
+	}
+}
+
+// TestPeerGroupUnknown verifies referencing a missing group is an error
+func TestPeerGroupUnknown(t *testing.T) {
+	bgpService := NewBGPService()
+	_, err := bgpService.resolveNeighbor(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002, PeerGroup: "missing"})
+	if err == nil {
+		t.Error("resolveNeighbor() should fail for an unknown peer group")
+	}
+}
+
+// TestDynamicNeighbors verifies dynamic neighbor ranges and their peer group reach GoBGP
+func TestDynamicNeighbors(t *testing.T) {
+	config := &Config{}
+	config.BGP.PeerGroups = []PeerGroupConfig{
+		{Name: "ix", NeighborTemplate: NeighborTemplate{Families: []string{"ipv4-unicast", "ipv6-unicast"}, HoldTime: 30}},
+	}
+	config.BGP.DynamicNeighbors = []DynamicNeighborConfig{
+		{Prefix: "192.0.2.0/24", PeerGroup: "ix"},
+		{Prefix: "2001:db8::1/64", PeerGroup: "ix"},
+	}
+	bgpService := newTestService(t, config)
+	gobgp := bgpService.server.(*server.BgpServer)
+
+	var prefixes []string
+	err := gobgp.ListDynamicNeighbor(context.Background(), &api.ListDynamicNeighborRequest{}, func(n *api.DynamicNeighbor) {
+		if n.PeerGroup != "ix" {
+			t.Errorf("dynamic neighbor %s peer group = %q, want ix", n.Prefix, n.PeerGroup)
+		}
+		prefixes = append(prefixes, n.Prefix)
+	})
+	if err != nil {
+		t.Fatalf("ListDynamicNeighbor error = %v", err)
+	}
+	if len(prefixes) != 2 || !contains(prefixes, "192.0.2.0/24") || !contains(prefixes, "2001:db8::/64") {
+		t.Errorf("dynamic neighbors = %v, want 192.0.2.0/24 and 2001:db8::/64", prefixes)
+	}
+
+	var groups []*api.PeerGroup
+	if err := gobgp.ListPeerGroup(context.Background(), &api.ListPeerGroupRequest{}, func(g *api.PeerGroup) {
+		groups = append(groups, g)
+	}); err != nil {
+		t.Fatalf("ListPeerGroup error = %v", err)
+	}
+	if len(groups) != 1 || groups[0].Conf.PeerGroupName != "ix" {
+		t.Fatalf("peer groups = %v, want only ix", groups)
+	}
+	if got := groups[0].Timers.GetConfig().GetHoldTime(); got != 30 {
+		t.Errorf("peer group hold time = %d, want 30", got)
+	}
+	if got := len(groups[0].AfiSafis); got != 2 {
+		t.Errorf("peer group families = %d, want 2", got)
+	}
+}
+
+// TestDynamicNeighborsInvalid verifies Start rejects bad prefixes and unknown peer groups
+func TestDynamicNeighborsInvalid(t *testing.T) {
+	for _, dyn := range []DynamicNeighborConfig{
+		{Prefix: "192.0.2.0", PeerGroup: "ix"},
+		{Prefix: "192.0.2.0/24", PeerGroup: "missing"},
+		{Prefix: "192.0.2.0/24"},
+	} {
+		config := &Config{}
+		config.BGP.PeerGroups = []PeerGroupConfig{{Name: "ix"}}
+		config.BGP.DynamicNeighbors = []DynamicNeighborConfig{dyn}
+		bgpService := NewBGPServiceWithConfig(config)
+		bgpService.listenPort = -1
+		if err := bgpService.Start("192.0.2.254", 65001); err == nil {
+			t.Errorf("Start with dynamic neighbor %+v succeeded, want an error", dyn)
+		}
+		bgpService.Stop()
+	}
+}
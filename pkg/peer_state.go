@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"context"
+	api "github.com/osrg/gobgp/v3/api"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PeerEventKind classifies a session state change for display
+type PeerEventKind string
+
+const (
+	PeerUp   PeerEventKind = "up"   // Session established
+	PeerDown PeerEventKind = "down" // Session lost, the peer's routes are withdrawn
+	// PeerRestarting is a session lost to a graceful restart; the peer's
+	// routes are kept as stale until it comes back or the restart time expires
+	PeerRestarting PeerEventKind = "restarting"
+)
+
+// PeerStateEvent reports a neighbor session moving to a new state
+type PeerStateEvent struct {
+	Peer  string        `json:"peer"`
+	Kind  PeerEventKind `json:"kind"`
+	State string        `json:"state"` // e.g. "idle", "active", "established"
+	Flaps int           `json:"flaps"` // Transitions folded into this event, 1 without debouncing
+	Time  time.Time     `json:"time"`  // When the last of those transitions was seen
+}
+
+// PeerStateHandler consumes session state changes
+// Handlers are registered with BGPService.AddPeerStateHandler
+type PeerStateHandler func(event PeerStateEvent)
+
+// AddPeerStateHandler registers a handler that receives session state changes
+// With bgp.stateDebounce set, a burst of transitions is reported once the
+// session has been quiet for that long, as a single event carrying the
+// settled state and the number of transitions in the burst
+func (s *BGPService) AddPeerStateHandler(h PeerStateHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateHandlers = append(s.stateHandlers, h)
+}
+
+// watchPeerState feeds session state changes into the debouncer until ctx
+// ends, closing established once the first neighbor is established
+func (s *BGPService) watchPeerState(ctx context.Context, established chan struct{}) {
+	var once sync.Once
+	err := s.server.WatchEvent(ctx, &api.WatchEventRequest{
+		Peer: &api.WatchEventRequest_Peer{},
+	}, func(r *api.WatchEventResponse) {
+		event := r.GetPeer()
+		if event.GetType() != api.WatchEventResponse_PeerEvent_STATE {
+			return
+		}
+		state := event.GetPeer().GetState()
+		if state.GetSessionState() == api.PeerState_ESTABLISHED {
+			once.Do(func() { close(established) })
+		}
+		s.peerStates.observe(PeerStateEvent{
+			Peer:  state.GetNeighborAddress(),
+			Kind:  s.peerEventKind(state),
+			State: strings.ToLower(state.GetSessionState().String()),
+			Time:  time.Now(),
+		})
+	})
+	if err != nil {
+		log.Printf("Error watching peer state: %v", err)
+	}
+}
+
+// peerEventKind tells a graceful restart apart from a hard down
+// Peer events do not carry the graceful restart state, so it is read from
+// the peer itself, which GoBGP has already marked restarting by then
+func (s *BGPService) peerEventKind(state *api.PeerState) PeerEventKind {
+	if state.GetSessionState() == api.PeerState_ESTABLISHED {
+		return PeerUp
+	}
+	peer, err := s.getPeer(state.GetNeighborAddress())
+	if err == nil && peer.GetGracefulRestart().GetPeerRestarting() {
+		return PeerRestarting
+	}
+	return PeerDown
+}
+
+// emitPeerState hands a settled state change to every registered handler
+func (s *BGPService) emitPeerState(event PeerStateEvent) {
+	if event.Kind == PeerRestarting {
+		log.Printf("Neighbor %s is restarting, routes preserved, after %d transition(s)", event.Peer, event.Flaps)
+	} else {
+		log.Printf("Neighbor %s is %s after %d transition(s)", event.Peer, event.State, event.Flaps)
+	}
+	s.mu.RLock()
+	handlers := append([]PeerStateHandler(nil), s.stateHandlers...)
+	s.mu.RUnlock()
+	for _, h := range handlers {
+		h(event)
+	}
+}
+
+// stateDebouncer holds back each peer's state changes until the peer has
+// been quiet for the configured period, then emits only the latest
+type stateDebouncer struct {
+	quiet   time.Duration            // 0 emits every transition immediately
+	emit    func(PeerStateEvent)     // Receives settled events
+	count   func()                   // Called for every transition, settled or not
+	mu      sync.Mutex               // Guards pending
+	pending map[string]*pendingState // Unsettled peers by address
+}
+
+// pendingState is a peer's latest unsettled state and the timer that settles it
+type pendingState struct {
+	event PeerStateEvent
+	timer *time.Timer
+}
+
+func newStateDebouncer(quiet time.Duration, emit func(PeerStateEvent), count func()) *stateDebouncer {
+	return &stateDebouncer{
+		quiet:   quiet,
+		emit:    emit,
+		count:   count,
+		pending: make(map[string]*pendingState),
+	}
+}
+
+// observe records a transition and restarts the peer's quiet period
+func (d *stateDebouncer) observe(event PeerStateEvent) {
+	d.count()
+	if d.quiet <= 0 {
+		event.Flaps = 1
+		d.emit(event)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	p := &pendingState{event: event}
+	p.event.Flaps = 1
+	if prev := d.pending[event.Peer]; prev != nil {
+		prev.timer.Stop()
+		p.event.Flaps += prev.event.Flaps
+	}
+	d.pending[event.Peer] = p
+	p.timer = time.AfterFunc(d.quiet, func() { d.settle(p) })
+}
+
+// settle emits p unless a later transition has replaced it
+func (d *stateDebouncer) settle(p *pendingState) {
+	d.mu.Lock()
+	if d.pending[p.event.Peer] != p {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, p.event.Peer)
+	d.mu.Unlock()
+	d.emit(p.event)
+}
+
+// stop discards unsettled events so nothing is emitted after Stop
+func (d *stateDebouncer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for peer, p := range d.pending {
+		p.timer.Stop()
+		delete(d.pending, peer)
+	}
+}
@@ -0,0 +1,73 @@
+package pkg
+
+import (
+	api "github.com/osrg/gobgp/v3/api"
+	"testing"
+	"time"
+)
+
+// TestPeerStateDebounce verifies a flap is reported once, with every transition counted
+func TestPeerStateDebounce(t *testing.T) {
+	config := &Config{}
+	config.BGP.StateDebounce = 50 * time.Millisecond
+	bgpService, fake := newFakeService(t, config)
+
+	events := make(chan PeerStateEvent, 4)
+	bgpService.AddPeerStateHandler(func(event PeerStateEvent) { events <- event })
+
+	fake.waitForPeerWatch(t)
+
+	fake.emitPeerState("192.0.2.1", api.PeerState_IDLE)
+	fake.emitPeerState("192.0.2.1", api.PeerState_ACTIVE)
+	fake.emitPeerState("192.0.2.1", api.PeerState_ESTABLISHED)
+
+	select {
+	case event := <-events:
+		if event.Peer != "192.0.2.1" || event.Kind != PeerUp || event.State != "established" || event.Flaps != 3 {
+			t.Errorf("event = %+v, want 192.0.2.1 established after 3 flaps", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the settled state")
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected second event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if got := bgpService.Stats().PeerStateChanges; got != 3 {
+		t.Errorf("PeerStateChanges = %d, want 3", got)
+	}
+}
+
+// TestPeerStateGracefulRestart verifies a restarting peer is reported apart from a hard down
+func TestPeerStateGracefulRestart(t *testing.T) {
+	bgpService, fake := newFakeService(t, &Config{})
+	for _, addr := range []string{"192.0.2.1", "192.0.2.2"} {
+		if err := bgpService.AddNeighbor(addr, 65002); err != nil {
+			t.Fatalf("AddNeighbor(%s) error = %v", addr, err)
+		}
+	}
+	// GoBGP marks the peer restarting before the session change is broadcast
+	fake.peers[0].GracefulRestart = &api.GracefulRestart{Enabled: true, PeerRestarting: true}
+
+	events := make(chan PeerStateEvent, 2)
+	bgpService.AddPeerStateHandler(func(event PeerStateEvent) { events <- event })
+	fake.waitForPeerWatch(t)
+
+	fake.emitPeerState("192.0.2.1", api.PeerState_IDLE)
+	fake.emitPeerState("192.0.2.2", api.PeerState_IDLE)
+
+	for _, want := range []PeerStateEvent{
+		{Peer: "192.0.2.1", Kind: PeerRestarting},
+		{Peer: "192.0.2.2", Kind: PeerDown},
+	} {
+		select {
+		case event := <-events:
+			if event.Peer != want.Peer || event.Kind != want.Kind {
+				t.Errorf("event = %+v, want %s %s", event, want.Peer, want.Kind)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for the %s event", want.Peer)
+		}
+	}
+}
@@ -0,0 +1,652 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// globalRib is the assignment name GoBGP uses for the global RIB
+// GoBGP only supports per-peer assignments for route-server clients, so every
+// per-neighbor policy is assigned globally and scoped with a neighbor set
+const globalRib = "global"
+
+// policySetTypes lists the defined-set types a neighbor policy may own
+var policySetTypes = []api.DefinedType{
+	api.DefinedType_PREFIX,
+	api.DefinedType_NEIGHBOR,
+}
+
+// monitorOnlyPolicy is the export policy installed in monitor-only mode
+const monitorOnlyPolicy = "monitor-only-deny-all"
+
+// ErrMonitorOnly is returned by operations that would advertise routes
+// while bgp.monitorOnly is set
+var ErrMonitorOnly = errors.New("route advertisement is disabled in monitor-only mode")
+
+// applyMonitorOnly rejects every route towards every neighbor, both with an
+// unconditional reject policy and a reject default, so nothing can leak
+// even if another export policy is added by mistake
+func (s *BGPService) applyMonitorOnly() error {
+	policy := &api.Policy{
+		Name: monitorOnlyPolicy,
+		Statements: []*api.Statement{{
+			Name:    monitorOnlyPolicy + "-reject",
+			Actions: &api.Actions{RouteAction: api.RouteAction_REJECT},
+		}},
+	}
+	if err := s.server.AddPolicy(s.context, &api.AddPolicyRequest{Policy: policy}); err != nil {
+		return fmt.Errorf("add policy %s: %w", policy.Name, err)
+	}
+	return s.server.SetPolicyAssignment(s.context, &api.SetPolicyAssignmentRequest{
+		Assignment: &api.PolicyAssignment{
+			Name:          globalRib,
+			Direction:     api.PolicyDirection_EXPORT,
+			Policies:      []*api.Policy{{Name: monitorOnlyPolicy}},
+			DefaultAction: api.RouteAction_REJECT,
+		},
+	})
+}
+
+// defaultRouteActions maps the bgp.defaultPolicy settings to GoBGP route
+// actions; empty keeps GoBGP's default, which accepts
+var defaultRouteActions = map[string]api.RouteAction{
+	"":       api.RouteAction_NONE,
+	"accept": api.RouteAction_ACCEPT,
+	"reject": api.RouteAction_REJECT,
+}
+
+// applyDefaultPolicies sets the default action of the global import and
+// export assignments from bgp.defaultPolicy, keeping any attached policies
+func (s *BGPService) applyDefaultPolicies() error {
+	for dir, setting := range map[api.PolicyDirection]string{
+		api.PolicyDirection_IMPORT: s.config.BGP.DefaultPolicy.Import,
+		api.PolicyDirection_EXPORT: s.config.BGP.DefaultPolicy.Export,
+	} {
+		if setting == "" {
+			continue
+		}
+		assignment, err := s.globalAssignment(dir)
+		if err != nil {
+			return err
+		}
+		var policies []*api.Policy
+		for _, p := range assignment.Policies {
+			policies = append(policies, &api.Policy{Name: p.Name})
+		}
+		if err := s.server.SetPolicyAssignment(s.context, &api.SetPolicyAssignmentRequest{
+			Assignment: &api.PolicyAssignment{
+				Name:          globalRib,
+				Direction:     dir,
+				Policies:      policies,
+				DefaultAction: defaultRouteActions[setting],
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetExportPolicy restricts the routes advertised to neighbor to allowedPrefixes
+// Matching prefixes are permitted and everything else towards that neighbor is
+// rejected; calling it again replaces the previous list
+func (s *BGPService) SetExportPolicy(neighbor string, allowedPrefixes []string) error {
+	if s.config.BGP.MonitorOnly {
+		return ErrMonitorOnly
+	}
+	policy, sets, err := prefixFilterPolicy("export-"+neighbor, neighbor, allowedPrefixes)
+	if err != nil {
+		return err
+	}
+	return s.applyNeighborPolicy(api.PolicyDirection_EXPORT, policy, sets)
+}
+
+// SetImportPolicy restricts the routes accepted from neighbor to allowedPrefixes
+// Matching prefixes are permitted into the RIB and everything else received
+// from that neighbor is rejected; calling it again replaces the previous list
+func (s *BGPService) SetImportPolicy(neighbor string, allowedPrefixes []string) error {
+	policy, sets, err := prefixFilterPolicy("import-"+neighbor, neighbor, allowedPrefixes)
+	if err != nil {
+		return err
+	}
+	return s.applyNeighborPolicy(api.PolicyDirection_IMPORT, policy, sets)
+}
+
+// SetIngressCommunity tags every route received from neighbor with
+// community, e.g. "65000:100", so routes can be told apart by ingress peer
+// Calling it again replaces the community; an empty community removes the tag
+func (s *BGPService) SetIngressCommunity(neighbor string, community string) error {
+	name := "ingress-community-" + neighbor
+	if community == "" {
+		return s.removeNeighborPolicy(api.PolicyDirection_IMPORT, name)
+	}
+	if err := validateCommunity(community); err != nil {
+		return err
+	}
+	neighborSet, err := neighborDefinedSet(name, neighbor)
+	if err != nil {
+		return err
+	}
+
+	// No route action, so routes carry on to any accept/reject policies
+	policy := &api.Policy{
+		Name: name,
+		Statements: []*api.Statement{{
+			Name: name + "-add",
+			Conditions: &api.Conditions{
+				NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+			},
+			Actions: &api.Actions{
+				Community: &api.CommunityAction{
+					Type:        api.CommunityAction_ADD,
+					Communities: []string{community},
+				},
+			},
+		}},
+	}
+	return s.applyNeighborPolicy(api.PolicyDirection_IMPORT, policy, []*api.DefinedSet{neighborSet})
+}
+
+// nextHopSelfPolicy names the export policy installed for a nextHopSelf peer
+func nextHopSelfPolicy(neighbor string) string {
+	return "next-hop-self-" + neighbor
+}
+
+// setNextHopSelf installs or removes the export policy rewriting the next hop
+// of routes advertised to neighbor to our local address
+// Peers that never had the policy are left alone, so neighbors without the
+// option cost no policy calls; s.neighborMu must be held
+func (s *BGPService) setNextHopSelf(neighbor string, enabled bool) error {
+	name := nextHopSelfPolicy(neighbor)
+	if !enabled {
+		if !s.nextHopSelf[neighbor] {
+			return nil
+		}
+		if err := s.removeNeighborPolicy(api.PolicyDirection_EXPORT, name); err != nil {
+			return err
+		}
+		delete(s.nextHopSelf, neighbor)
+		return nil
+	}
+
+	neighborSet, err := neighborDefinedSet(name, neighbor)
+	if err != nil {
+		return err
+	}
+	// No route action, so export filters such as SetExportPolicy still decide
+	policy := &api.Policy{
+		Name: name,
+		Statements: []*api.Statement{{
+			Name: name + "-rewrite",
+			Conditions: &api.Conditions{
+				NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+			},
+			Actions: &api.Actions{Nexthop: &api.NexthopAction{Self: true}},
+		}},
+	}
+	if err := s.applyNeighborPolicy(api.PolicyDirection_EXPORT, policy, []*api.DefinedSet{neighborSet}); err != nil {
+		return err
+	}
+	s.nextHopSelf[neighbor] = true
+	return nil
+}
+
+// hasNextHopSelf reports whether the peer was configured with nextHopSelf
+func (s *BGPService) hasNextHopSelf(neighbor string) bool {
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	return s.nextHopSelf[neighbor]
+}
+
+// setInboundMED installs the import policy replacing the MED of routes
+// received from neighbor with med, or removes it when med is nil
+// GoBGP cannot delete the attribute, so ignoreMED is carried out as MED 0,
+// the value best-path selection assumes for a missing MED
+// As with setNextHopSelf, s.neighborMu must be held
+func (s *BGPService) setInboundMED(neighbor string, med *uint32) error {
+	name := "inbound-med-" + neighbor
+	if med == nil {
+		if _, ok := s.inboundMED[neighbor]; !ok {
+			return nil
+		}
+		if err := s.removeNeighborPolicy(api.PolicyDirection_IMPORT, name); err != nil {
+			return err
+		}
+		delete(s.inboundMED, neighbor)
+		return nil
+	}
+
+	neighborSet, err := neighborDefinedSet(name, neighbor)
+	if err != nil {
+		return err
+	}
+	policy := &api.Policy{
+		Name: name,
+		Statements: []*api.Statement{{
+			Name: name + "-set",
+			Conditions: &api.Conditions{
+				NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+			},
+			Actions: &api.Actions{Med: &api.MedAction{Type: api.MedAction_REPLACE, Value: int64(*med)}},
+		}},
+	}
+	if err := s.applyNeighborPolicy(api.PolicyDirection_IMPORT, policy, []*api.DefinedSet{neighborSet}); err != nil {
+		return err
+	}
+	s.inboundMED[neighbor] = *med
+	return nil
+}
+
+// inboundMEDSetting returns the MED forced onto routes from the peer, if any
+func (s *BGPService) inboundMEDSetting(neighbor string) (uint32, bool) {
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	med, ok := s.inboundMED[neighbor]
+	return med, ok
+}
+
+// prependPolicy names the export policy carrying out PathSpec.Prepend for prefix
+func prependPolicy(prefix string) string {
+	return "prepend-" + prefix
+}
+
+// setPrepend records the prepend count of the originated prefix and
+// installs its export policy, or removes it for 0
+// As with setNextHopSelf, s.neighborMu must be held
+func (s *BGPService) setPrepend(prefix string, count int) error {
+	if count == 0 {
+		if _, ok := s.prepends[prefix]; !ok {
+			return nil
+		}
+		if err := s.removeNeighborPolicy(api.PolicyDirection_EXPORT, prependPolicy(prefix)); err != nil {
+			return err
+		}
+		delete(s.prepends, prefix)
+		return nil
+	}
+	if err := s.installPrepend(prefix, count); err != nil {
+		return err
+	}
+	s.prepends[prefix] = count
+	return nil
+}
+
+// installPrepend installs the export policy prepending count times, to
+// routes for prefix advertised to eBGP neighbors, the ASN presented to
+// each, grouped by that ASN; GoBGP adds its own copy on export afterwards
+// Only configured neighbors are matched, dynamic neighbors' addresses are
+// not known up front; without any eBGP neighbor the policy is removed,
+// as GoBGP matches an empty neighbor set against every peer
+// s.neighborMu must be held
+func (s *BGPService) installPrepend(prefix string, count int) error {
+	name := prependPolicy(prefix)
+	s.mu.RLock()
+	localASN := s.localASN
+	s.mu.RUnlock()
+	byASN := make(map[uint32][]string)
+	for address, asns := range s.loadPeerASNs() {
+		if !asns.isEBGP(localASN) {
+			continue
+		}
+		presented := asns.local
+		if presented == 0 {
+			presented = localASN
+		}
+		byASN[presented] = append(byASN[presented], address)
+	}
+	if len(byASN) == 0 {
+		return s.removeNeighborPolicy(api.PolicyDirection_EXPORT, name)
+	}
+
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return fmt.Errorf("invalid prefix %q: %w", prefix, err)
+	}
+	ones, _ := ipNet.Mask.Size()
+	prefixSet := &api.DefinedSet{
+		DefinedType: api.DefinedType_PREFIX,
+		Name:        name + "-prefix",
+		Prefixes:    []*api.Prefix{{IpPrefix: ipNet.String(), MaskLengthMin: uint32(ones), MaskLengthMax: uint32(ones)}},
+	}
+	sets := []*api.DefinedSet{prefixSet}
+	// No route action, so export filters such as SetExportPolicy still decide
+	policy := &api.Policy{Name: name}
+	for _, asn := range slices.Sorted(maps.Keys(byASN)) {
+		neighbors := byASN[asn]
+		slices.Sort(neighbors)
+		neighborSet, err := neighborDefinedSet(fmt.Sprintf("%s-as%d", name, asn), neighbors...)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, neighborSet)
+		policy.Statements = append(policy.Statements, &api.Statement{
+			Name: fmt.Sprintf("%s-as%d-prepend", name, asn),
+			Conditions: &api.Conditions{
+				PrefixSet:   &api.MatchSet{Type: api.MatchSet_ANY, Name: prefixSet.Name},
+				NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+			},
+			Actions: &api.Actions{AsPrepend: &api.AsPrependAction{Asn: asn, Repeat: uint32(count)}},
+		})
+	}
+	return s.applyNeighborPolicy(api.PolicyDirection_EXPORT, policy, sets)
+}
+
+// refreshPrepends reinstalls every prepend policy after a neighbor was
+// added, changed or removed, so each matches the current eBGP neighbors
+// As with setNextHopSelf, s.neighborMu must be held
+func (s *BGPService) refreshPrepends() error {
+	for prefix, count := range s.prepends {
+		if err := s.installPrepend(prefix, count); err != nil {
+			return fmt.Errorf("prepend policy of %s: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+// prependCount returns the Prepend the originated prefix was added with
+func (s *BGPService) prependCount(prefix string) int {
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	return s.prepends[prefix]
+}
+
+// communityKinds selects which community attributes a policy action touches
+type communityKinds struct {
+	standard, extended, large bool
+}
+
+// sendCommunityStrip maps each sendCommunity setting to the community types
+// stripped from routes advertised to the peer
+var sendCommunityStrip = map[string]communityKinds{
+	"":         {},
+	"all":      {},
+	"none":     {standard: true, extended: true, large: true},
+	"standard": {extended: true, large: true},
+	"extended": {standard: true, large: true},
+	"large":    {standard: true, extended: true},
+}
+
+// allExtCommunities matches every extended community subtype GoBGP can remove
+var allExtCommunities = []string{"rt:.*", "soo:.*", "encap:.*", "lb:.*"}
+
+// setSendCommunity installs the export policy stripping the community types
+// setting does not allow towards neighbor, or removes it for "all"
+// As with setNextHopSelf, s.neighborMu must be held
+func (s *BGPService) setSendCommunity(neighbor string, setting string) error {
+	name := "send-community-" + neighbor
+	strip := sendCommunityStrip[setting]
+	if strip == (communityKinds{}) {
+		if _, ok := s.sendCommunity[neighbor]; !ok {
+			return nil
+		}
+		if err := s.removeNeighborPolicy(api.PolicyDirection_EXPORT, name); err != nil {
+			return err
+		}
+		delete(s.sendCommunity, neighbor)
+		return nil
+	}
+
+	neighborSet, err := neighborDefinedSet(name, neighbor)
+	if err != nil {
+		return err
+	}
+	// GoBGP removes communities by regular expression; extended communities
+	// can only be matched per subtype, so the transitive subtypes it knows
+	// are listed and any other extended community is left in place
+	actions := &api.Actions{}
+	if strip.standard {
+		actions.Community = &api.CommunityAction{Type: api.CommunityAction_REMOVE, Communities: []string{".*"}}
+	}
+	if strip.extended {
+		actions.ExtCommunity = &api.CommunityAction{Type: api.CommunityAction_REMOVE, Communities: append([]string(nil), allExtCommunities...)}
+	}
+	if strip.large {
+		actions.LargeCommunity = &api.CommunityAction{Type: api.CommunityAction_REMOVE, Communities: []string{".*"}}
+	}
+	policy := &api.Policy{
+		Name: name,
+		Statements: []*api.Statement{{
+			Name: name + "-strip",
+			Conditions: &api.Conditions{
+				NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+			},
+			Actions: actions,
+		}},
+	}
+	if err := s.applyNeighborPolicy(api.PolicyDirection_EXPORT, policy, []*api.DefinedSet{neighborSet}); err != nil {
+		return err
+	}
+	s.sendCommunity[neighbor] = setting
+	return nil
+}
+
+// sendCommunitySetting returns the sendCommunity the peer was configured
+// with, empty when it receives all communities
+func (s *BGPService) sendCommunitySetting(neighbor string) string {
+	s.neighborMu.Lock()
+	defer s.neighborMu.Unlock()
+	return s.sendCommunity[neighbor]
+}
+
+// validateCommunity checks a standard community in "ASN:value" form
+func validateCommunity(community string) error {
+	asn, value, ok := strings.Cut(community, ":")
+	if ok {
+		_, errASN := strconv.ParseUint(asn, 10, 16)
+		_, errValue := strconv.ParseUint(value, 10, 16)
+		ok = errASN == nil && errValue == nil
+	}
+	if !ok {
+		return fmt.Errorf("invalid community %q, expected ASN:value with both parts at most 65535", community)
+	}
+	return nil
+}
+
+// prefixFilterPolicy builds a policy that accepts routes for neighbor matching
+// prefixes exactly and rejects all other routes for that neighbor
+// Routes for other neighbors fall through to the next policy untouched
+func prefixFilterPolicy(name, neighbor string, prefixes []string) (*api.Policy, []*api.DefinedSet, error) {
+	neighborSet, err := neighborDefinedSet(name, neighbor)
+	if err != nil {
+		return nil, nil, err
+	}
+	sets := []*api.DefinedSet{neighborSet}
+
+	// GoBGP prefix sets hold a single address family, so split v4 and v6
+	byFamily := map[string]*api.DefinedSet{}
+	for _, p := range prefixes {
+		ip, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid prefix %q: %w", p, err)
+		}
+		family := "v6"
+		if ip.To4() != nil {
+			family = "v4"
+		}
+		set, ok := byFamily[family]
+		if !ok {
+			set = &api.DefinedSet{DefinedType: api.DefinedType_PREFIX, Name: name + "-prefixes-" + family}
+			byFamily[family] = set
+		}
+		ones, _ := ipNet.Mask.Size()
+		set.Prefixes = append(set.Prefixes, &api.Prefix{
+			IpPrefix:      ipNet.String(),
+			MaskLengthMin: uint32(ones),
+			MaskLengthMax: uint32(ones),
+		})
+	}
+
+	policy := &api.Policy{Name: name}
+	for _, family := range []string{"v4", "v6"} {
+		set, ok := byFamily[family]
+		if !ok {
+			continue
+		}
+		sets = append(sets, set)
+		policy.Statements = append(policy.Statements, &api.Statement{
+			Name: name + "-permit-" + family,
+			Conditions: &api.Conditions{
+				NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+				PrefixSet:   &api.MatchSet{Type: api.MatchSet_ANY, Name: set.Name},
+			},
+			Actions: &api.Actions{RouteAction: api.RouteAction_ACCEPT},
+		})
+	}
+	policy.Statements = append(policy.Statements, &api.Statement{
+		Name: name + "-deny",
+		Conditions: &api.Conditions{
+			NeighborSet: &api.MatchSet{Type: api.MatchSet_ANY, Name: neighborSet.Name},
+		},
+		Actions: &api.Actions{RouteAction: api.RouteAction_REJECT},
+	})
+
+	return policy, sets, nil
+}
+
+// neighborDefinedSet returns the neighbor set scoping policy name to the
+// given peers, usually a single one
+func neighborDefinedSet(name string, neighbors ...string) (*api.DefinedSet, error) {
+	set := &api.DefinedSet{DefinedType: api.DefinedType_NEIGHBOR, Name: name + "-neighbor"}
+	for _, neighbor := range neighbors {
+		ip := net.ParseIP(neighbor)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid neighbor address %q", neighbor)
+		}
+		// GoBGP neighbor sets hold prefixes, so match the single host address
+		hostLen := "/128"
+		if ip.To4() != nil {
+			hostLen = "/32"
+		}
+		set.List = append(set.List, neighbor+hostLen)
+	}
+	return set, nil
+}
+
+// applyNeighborPolicy installs policy and its defined sets and attaches it to
+// the global assignment for dir, replacing any earlier policy of the same name
+// Policies that only modify routes are kept ahead of those that accept or
+// reject, since GoBGP stops evaluating at the first accept/reject decision
+func (s *BGPService) applyNeighborPolicy(dir api.PolicyDirection, policy *api.Policy, sets []*api.DefinedSet) error {
+	if err := s.removeNeighborPolicy(dir, policy.Name); err != nil {
+		return err
+	}
+
+	for _, set := range sets {
+		if err := s.server.AddDefinedSet(s.context, &api.AddDefinedSetRequest{DefinedSet: set}); err != nil {
+			return fmt.Errorf("add defined set %s: %w", set.Name, err)
+		}
+	}
+	if err := s.server.AddPolicy(s.context, &api.AddPolicyRequest{Policy: policy}); err != nil {
+		return fmt.Errorf("add policy %s: %w", policy.Name, err)
+	}
+
+	assignment, err := s.globalAssignment(dir)
+	if err != nil {
+		return err
+	}
+	var modifiers, terminals []*api.Policy
+	for _, p := range append(assignment.Policies, policy) {
+		if isTerminalPolicy(p) {
+			terminals = append(terminals, &api.Policy{Name: p.Name})
+		} else {
+			modifiers = append(modifiers, &api.Policy{Name: p.Name})
+		}
+	}
+	return s.server.SetPolicyAssignment(s.context, &api.SetPolicyAssignmentRequest{
+		Assignment: &api.PolicyAssignment{
+			Name:          globalRib,
+			Direction:     dir,
+			Policies:      append(modifiers, terminals...),
+			DefaultAction: assignment.DefaultAction,
+		},
+	})
+}
+
+// removeNeighborPolicy detaches and deletes the named policy along with the
+// defined sets it owns; it is a no-op when the policy does not exist
+func (s *BGPService) removeNeighborPolicy(dir api.PolicyDirection, name string) error {
+	assignment, err := s.globalAssignment(dir)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	var remaining []*api.Policy
+	for _, p := range assignment.Policies {
+		if p.Name == name {
+			found = true
+			continue
+		}
+		remaining = append(remaining, &api.Policy{Name: p.Name})
+	}
+	if !found {
+		return nil
+	}
+
+	if err := s.server.SetPolicyAssignment(s.context, &api.SetPolicyAssignmentRequest{
+		Assignment: &api.PolicyAssignment{
+			Name:          globalRib,
+			Direction:     dir,
+			Policies:      remaining,
+			DefaultAction: assignment.DefaultAction,
+		},
+	}); err != nil {
+		return err
+	}
+	if err := s.server.DeletePolicy(s.context, &api.DeletePolicyRequest{
+		Policy: &api.Policy{Name: name},
+		All:    true,
+	}); err != nil {
+		return fmt.Errorf("delete policy %s: %w", name, err)
+	}
+
+	// Defined sets are named after their policy, see prefixFilterPolicy
+	for _, setType := range policySetTypes {
+		var owned []*api.DefinedSet
+		err := s.server.ListDefinedSet(s.context, &api.ListDefinedSetRequest{DefinedType: setType}, func(set *api.DefinedSet) {
+			if strings.HasPrefix(set.Name, name+"-") {
+				owned = append(owned, set)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		for _, set := range owned {
+			if err := s.server.DeleteDefinedSet(s.context, &api.DeleteDefinedSetRequest{
+				DefinedSet: &api.DefinedSet{DefinedType: setType, Name: set.Name},
+				All:        true,
+			}); err != nil {
+				return fmt.Errorf("delete defined set %s: %w", set.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// globalAssignment returns the current global policy assignment for dir
+func (s *BGPService) globalAssignment(dir api.PolicyDirection) (*api.PolicyAssignment, error) {
+	assignment := &api.PolicyAssignment{Name: globalRib, Direction: dir}
+	err := s.server.ListPolicyAssignment(s.context, &api.ListPolicyAssignmentRequest{
+		Name:      globalRib,
+		Direction: dir,
+	}, func(a *api.PolicyAssignment) {
+		assignment = a
+	})
+	return assignment, err
+}
+
+// isTerminalPolicy reports whether any statement of p accepts or rejects routes
+func isTerminalPolicy(p *api.Policy) bool {
+	for _, st := range p.GetStatements() {
+		if action := st.GetActions().GetRouteAction(); action == api.RouteAction_ACCEPT || action == api.RouteAction_REJECT {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,389 @@
+package pkg
+
+import (
+	"errors"
+	api "github.com/osrg/gobgp/v3/api"
+	"reflect"
+	"testing"
+)
+
+// listPolicy returns the named policy or nil if it does not exist
+func listPolicy(t *testing.T, s *BGPService, name string) *api.Policy {
+	t.Helper()
+	var found *api.Policy
+	err := s.server.ListPolicy(s.context, &api.ListPolicyRequest{}, func(p *api.Policy) {
+		if p.Name == name {
+			found = p
+		}
+	})
+	if err != nil {
+		t.Fatalf("ListPolicy() error = %v", err)
+	}
+	return found
+}
+
+// assignedPolicies returns the names of the policies assigned globally in dir
+func assignedPolicies(t *testing.T, s *BGPService, dir api.PolicyDirection) []string {
+	t.Helper()
+	assignment, err := s.globalAssignment(dir)
+	if err != nil {
+		t.Fatalf("globalAssignment() error = %v", err)
+	}
+	var names []string
+	for _, p := range assignment.Policies {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// contains reports whether names includes name
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TestSetExportPolicy verifies the export policy is created and assigned
+func TestSetExportPolicy(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.SetExportPolicy("192.0.2.1", []string{"10.0.0.0/24", "2001:db8::/32"}); err != nil {
+		t.Fatalf("SetExportPolicy() error = %v", err)
+	}
+
+	policy := listPolicy(t, bgpService, "export-192.0.2.1")
+	if policy == nil {
+		t.Fatal("export policy was not created")
+	}
+	// One permit per address family plus the trailing deny
+	if len(policy.Statements) != 3 {
+		t.Errorf("policy has %d statements, want 3", len(policy.Statements))
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT); !contains(names, "export-192.0.2.1") {
+		t.Errorf("export assignment = %v, want it to include export-192.0.2.1", names)
+	}
+
+	// Replacing the list must not trip over the existing policy and sets
+	if err := bgpService.SetExportPolicy("192.0.2.1", []string{"10.1.0.0/16"}); err != nil {
+		t.Fatalf("SetExportPolicy() replace error = %v", err)
+	}
+	if policy := listPolicy(t, bgpService, "export-192.0.2.1"); len(policy.GetStatements()) != 2 {
+		t.Errorf("replaced policy has %d statements, want 2", len(policy.GetStatements()))
+	}
+}
+
+// TestSetExportPolicyInvalidPrefix verifies malformed CIDRs are rejected
+func TestSetExportPolicyInvalidPrefix(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.SetExportPolicy("192.0.2.1", []string{"10.0.0.0/33"}); err == nil {
+		t.Error("SetExportPolicy() should fail for an invalid prefix")
+	}
+}
+
+// TestSetImportPolicy verifies the import policy is created and scoped to the neighbor
+func TestSetImportPolicy(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.SetImportPolicy("192.0.2.1", []string{"10.0.0.0/24"}); err != nil {
+		t.Fatalf("SetImportPolicy() error = %v", err)
+	}
+
+	policy := listPolicy(t, bgpService, "import-192.0.2.1")
+	if policy == nil {
+		t.Fatal("import policy was not created")
+	}
+	for _, st := range policy.Statements {
+		if st.GetConditions().GetNeighborSet().GetName() != "import-192.0.2.1-neighbor" {
+			t.Errorf("statement %s is not scoped to the neighbor", st.Name)
+		}
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_IMPORT); !contains(names, "import-192.0.2.1") {
+		t.Errorf("import assignment = %v, want it to include import-192.0.2.1", names)
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT); contains(names, "import-192.0.2.1") {
+		t.Error("import policy must not be assigned in the export direction")
+	}
+}
+
+// TestSetImportPolicyInvalidPrefix verifies malformed CIDRs are rejected
+func TestSetImportPolicyInvalidPrefix(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.SetImportPolicy("192.0.2.1", []string{"not-a-prefix"}); err == nil {
+		t.Error("SetImportPolicy() should fail for an invalid prefix")
+	}
+}
+
+// TestMonitorOnly verifies origination is refused and a deny-all export policy is assigned
+func TestMonitorOnly(t *testing.T) {
+	config := &Config{}
+	config.BGP.MonitorOnly = true
+	bgpService := newTestService(t, config)
+
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254"}); !errors.Is(err, ErrMonitorOnly) {
+		t.Errorf("AddPath() error = %v, want %v", err, ErrMonitorOnly)
+	}
+	if err := bgpService.SetExportPolicy("192.0.2.1", []string{"10.0.0.0/24"}); !errors.Is(err, ErrMonitorOnly) {
+		t.Errorf("SetExportPolicy() error = %v, want %v", err, ErrMonitorOnly)
+	}
+
+	policy := listPolicy(t, bgpService, monitorOnlyPolicy)
+	if policy == nil || len(policy.Statements) != 1 || policy.Statements[0].GetActions().GetRouteAction() != api.RouteAction_REJECT {
+		t.Fatalf("policy %s = %v, want a single reject statement", monitorOnlyPolicy, policy)
+	}
+	if policy.Statements[0].GetConditions().GetPrefixSet() != nil || policy.Statements[0].GetConditions().GetNeighborSet() != nil {
+		t.Errorf("policy %s has conditions %v, want none", monitorOnlyPolicy, policy.Statements[0].GetConditions())
+	}
+	assignment, err := bgpService.globalAssignment(api.PolicyDirection_EXPORT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT); !contains(names, monitorOnlyPolicy) {
+		t.Errorf("export policies = %v, want %s", names, monitorOnlyPolicy)
+	}
+	if assignment.DefaultAction != api.RouteAction_REJECT {
+		t.Errorf("export default action = %v, want REJECT", assignment.DefaultAction)
+	}
+}
+
+// TestSetIngressCommunity verifies the community is added on import for the neighbor only
+func TestSetIngressCommunity(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.SetIngressCommunity("192.0.2.1", "65000:100"); err != nil {
+		t.Fatalf("SetIngressCommunity() error = %v", err)
+	}
+	name := "ingress-community-192.0.2.1"
+	policy := listPolicy(t, bgpService, name)
+	if policy == nil || len(policy.Statements) != 1 {
+		t.Fatalf("policy %s = %v, want one statement", name, policy)
+	}
+	st := policy.Statements[0]
+	if got := st.GetConditions().GetNeighborSet().GetName(); got != name+"-neighbor" {
+		t.Errorf("neighbor set = %q, want %s-neighbor", got, name)
+	}
+	action := st.GetActions().GetCommunity()
+	if action.GetType() != api.CommunityAction_ADD || len(action.GetCommunities()) != 1 || action.GetCommunities()[0] != "65000:100" {
+		t.Errorf("community action = %v, want ADD 65000:100", action)
+	}
+	if st.GetActions().GetRouteAction() != api.RouteAction_NONE {
+		t.Errorf("route action = %v, want NONE so later policies still decide", st.GetActions().GetRouteAction())
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_IMPORT); !contains(names, name) {
+		t.Errorf("import policies = %v, want %s", names, name)
+	}
+
+	// An empty community removes the tag again
+	if err := bgpService.SetIngressCommunity("192.0.2.1", ""); err != nil {
+		t.Fatalf("SetIngressCommunity(\"\") error = %v", err)
+	}
+	if listPolicy(t, bgpService, name) != nil {
+		t.Errorf("policy %s still exists after removal", name)
+	}
+
+	for _, bad := range []string{"65000", "65000:", "65536:1", "a:b", "1:2:3"} {
+		if err := bgpService.SetIngressCommunity("192.0.2.1", bad); err == nil {
+			t.Errorf("SetIngressCommunity(%q) succeeded, want an error", bad)
+		}
+	}
+}
+
+// TestNextHopSelf verifies nextHopSelf installs a next hop rewrite for the
+// neighbor and that upserting without it removes the policy again
+func TestNextHopSelf(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	enabled := true
+
+	if err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.1",
+		ASN:              65001,
+		NeighborTemplate: NeighborTemplate{NextHopSelf: &enabled},
+	}); err != nil {
+		t.Fatalf("AddNeighborConfig error = %v", err)
+	}
+	name := "next-hop-self-192.0.2.1"
+	policy := listPolicy(t, bgpService, name)
+	if policy == nil || len(policy.Statements) != 1 {
+		t.Fatalf("policy %s = %v, want one statement", name, policy)
+	}
+	st := policy.Statements[0]
+	if got := st.GetConditions().GetNeighborSet().GetName(); got != name+"-neighbor" {
+		t.Errorf("neighbor set = %q, want %s-neighbor", got, name)
+	}
+	if !st.GetActions().GetNexthop().GetSelf() {
+		t.Errorf("next hop action = %v, want self", st.GetActions().GetNexthop())
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT); !contains(names, name) {
+		t.Errorf("export policies = %v, want %s", names, name)
+	}
+	if got := bgpService.RunningConfig().BGP.Neighbors; len(got) != 1 || got[0].NextHopSelf == nil || !*got[0].NextHopSelf {
+		t.Errorf("running config neighbors = %+v, want nextHopSelf set", got)
+	}
+
+	if err := bgpService.UpsertNeighbor(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65001}); err != nil {
+		t.Fatalf("UpsertNeighbor error = %v", err)
+	}
+	if listPolicy(t, bgpService, name) != nil {
+		t.Errorf("policy %s still exists after nextHopSelf was dropped", name)
+	}
+}
+
+// TestSendCommunity verifies sendCommunity none strips every community type
+// towards the neighbor and that other settings keep their own type
+func TestSendCommunity(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	if err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.1",
+		ASN:              65001,
+		NeighborTemplate: NeighborTemplate{SendCommunity: "none"},
+	}); err != nil {
+		t.Fatalf("AddNeighborConfig error = %v", err)
+	}
+	name := "send-community-192.0.2.1"
+	policy := listPolicy(t, bgpService, name)
+	if policy == nil || len(policy.Statements) != 1 {
+		t.Fatalf("policy %s = %v, want one statement", name, policy)
+	}
+	actions := policy.Statements[0].GetActions()
+	for kind, action := range map[string]*api.CommunityAction{
+		"standard": actions.GetCommunity(),
+		"extended": actions.GetExtCommunity(),
+		"large":    actions.GetLargeCommunity(),
+	} {
+		if action.GetType() != api.CommunityAction_REMOVE || len(action.GetCommunities()) == 0 {
+			t.Errorf("%s community action = %v, want REMOVE of every community", kind, action)
+		}
+	}
+	if got := actions.GetExtCommunity().GetCommunities(); !reflect.DeepEqual(got, allExtCommunities) {
+		t.Errorf("extended communities removed = %v, want %v", got, allExtCommunities)
+	}
+	if actions.GetRouteAction() != api.RouteAction_NONE {
+		t.Errorf("route action = %v, want NONE so export filters still decide", actions.GetRouteAction())
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_EXPORT); !contains(names, name) {
+		t.Errorf("export policies = %v, want %s", names, name)
+	}
+
+	// standard keeps standard communities and strips the rest
+	if err := bgpService.UpsertNeighbor(NeighborConfig{
+		PeerIP:           "192.0.2.1",
+		ASN:              65001,
+		NeighborTemplate: NeighborTemplate{SendCommunity: "standard"},
+	}); err != nil {
+		t.Fatalf("UpsertNeighbor error = %v", err)
+	}
+	actions = listPolicy(t, bgpService, name).Statements[0].GetActions()
+	if actions.GetCommunity() != nil || actions.GetExtCommunity() == nil || actions.GetLargeCommunity() == nil {
+		t.Errorf("actions for standard = %v, want extended and large stripped only", actions)
+	}
+
+	// all, the default, removes the policy
+	if err := bgpService.UpsertNeighbor(NeighborConfig{PeerIP: "192.0.2.1", ASN: 65001}); err != nil {
+		t.Fatalf("UpsertNeighbor error = %v", err)
+	}
+	if listPolicy(t, bgpService, name) != nil {
+		t.Errorf("policy %s still exists with sendCommunity unset", name)
+	}
+
+	err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.2",
+		ASN:              65002,
+		NeighborTemplate: NeighborTemplate{SendCommunity: "some"},
+	})
+	if err == nil {
+		t.Error("AddNeighborConfig with sendCommunity \"some\" succeeded, want an error")
+	}
+}
+
+// TestInboundMED verifies ignoreMED zeroes and overrideMED replaces the MED
+// of routes from the configured neighbor only
+func TestInboundMED(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	ignore := true
+	override := uint32(50)
+
+	for _, cfg := range []NeighborConfig{
+		{PeerIP: "192.0.2.1", ASN: 65002, NeighborTemplate: NeighborTemplate{IgnoreMED: &ignore}},
+		{PeerIP: "192.0.2.2", ASN: 65003, NeighborTemplate: NeighborTemplate{OverrideMED: &override}},
+		{PeerIP: "192.0.2.3", ASN: 65004},
+	} {
+		if err := bgpService.AddNeighborConfig(cfg); err != nil {
+			t.Fatalf("AddNeighborConfig(%s) error = %v", cfg.PeerIP, err)
+		}
+	}
+
+	for neighbor, want := range map[string]int64{"192.0.2.1": 0, "192.0.2.2": 50} {
+		name := "inbound-med-" + neighbor
+		policy := listPolicy(t, bgpService, name)
+		if policy == nil || len(policy.Statements) != 1 {
+			t.Fatalf("policy %s = %v, want one statement", name, policy)
+		}
+		med := policy.Statements[0].GetActions().GetMed()
+		if med.GetType() != api.MedAction_REPLACE || med.GetValue() != want {
+			t.Errorf("%s MED action = %v, want replace with %d", neighbor, med, want)
+		}
+	}
+	if listPolicy(t, bgpService, "inbound-med-192.0.2.3") != nil {
+		t.Error("MED policy installed for a neighbor without MED options")
+	}
+	if names := assignedPolicies(t, bgpService, api.PolicyDirection_IMPORT); !contains(names, "inbound-med-192.0.2.1") {
+		t.Errorf("import policies = %v, want inbound-med-192.0.2.1", names)
+	}
+
+	err := bgpService.AddNeighborConfig(NeighborConfig{
+		PeerIP:           "192.0.2.4",
+		ASN:              65005,
+		NeighborTemplate: NeighborTemplate{IgnoreMED: &ignore, OverrideMED: &override},
+	})
+	if err == nil {
+		t.Error("AddNeighborConfig accepted both ignoreMED and overrideMED")
+	}
+
+	if err := bgpService.RemoveNeighbor("192.0.2.2", ""); err != nil {
+		t.Fatalf("RemoveNeighbor error = %v", err)
+	}
+	if listPolicy(t, bgpService, "inbound-med-192.0.2.2") != nil {
+		t.Error("MED policy left behind after the neighbor was removed")
+	}
+}
+
+// TestDefaultPolicy verifies bgp.defaultPolicy sets the default action of the
+// global assignments and that neighbor policies added later keep it
+func TestDefaultPolicy(t *testing.T) {
+	config := &Config{}
+	config.BGP.DefaultPolicy.Import = "reject"
+	bgpService := newTestService(t, config)
+
+	defaultAction := func(dir api.PolicyDirection) api.RouteAction {
+		t.Helper()
+		assignment, err := bgpService.globalAssignment(dir)
+		if err != nil {
+			t.Fatalf("globalAssignment() error = %v", err)
+		}
+		return assignment.DefaultAction
+	}
+	if got := defaultAction(api.PolicyDirection_IMPORT); got != api.RouteAction_REJECT {
+		t.Errorf("import default = %v, want REJECT", got)
+	}
+	if got := defaultAction(api.PolicyDirection_EXPORT); got == api.RouteAction_REJECT {
+		t.Error("export default is REJECT, want it left alone")
+	}
+
+	if err := bgpService.SetImportPolicy("192.0.2.1", []string{"10.0.0.0/8"}); err != nil {
+		t.Fatalf("SetImportPolicy() error = %v", err)
+	}
+	if got := defaultAction(api.PolicyDirection_IMPORT); got != api.RouteAction_REJECT {
+		t.Errorf("import default after SetImportPolicy = %v, want REJECT", got)
+	}
+
+	config = &Config{}
+	config.BGP.DefaultPolicy.Export = "deny"
+	if err := NewBGPServiceWithServer(config, newFakeBgpServer()).Start("192.0.2.254", 65001); err == nil {
+		t.Error("Start() accepted an invalid default policy")
+	}
+}
@@ -0,0 +1,109 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"net"
+	"sort"
+)
+
+// PrefixSID is the BGP Prefix-SID attribute (RFC 8669, RFC 9252)
+// Only the SRv6 service TLVs are decoded: GoBGP discards the SR-MPLS
+// label index and originator SRGB TLVs while reading the attribute, so
+// they never reach the service
+type PrefixSID struct {
+	SRv6L3Service []SRv6SID // SIDs for L3 services such as SRv6 L3VPN
+	SRv6L2Service []SRv6SID // SIDs for L2 services such as EVPN
+}
+
+// SRv6SID is one SRv6 SID information sub-TLV
+type SRv6SID struct {
+	SID              net.IP            // The SID, or its locator when transposition is used
+	EndpointBehavior uint32            // e.g. 0x12 for End.DT6, 0x13 for End.DT4
+	Structure        *SRv6SIDStructure // How the SID splits into locator, function and argument, if sent
+}
+
+// SRv6SIDStructure is the SID structure sub-sub-TLV, lengths in bits
+type SRv6SIDStructure struct {
+	LocatorBlockLength  uint8
+	LocatorNodeLength   uint8
+	FunctionLength      uint8
+	ArgumentLength      uint8
+	TranspositionLength uint8
+	TranspositionOffset uint8
+}
+
+// parsePrefixSID converts the Prefix-SID attribute
+func parsePrefixSID(a *api.PrefixSID) (*PrefixSID, error) {
+	psid := &PrefixSID{}
+	for _, tlv := range a.GetTlvs() {
+		msg, err := tlv.UnmarshalNew()
+		if err != nil {
+			return nil, err
+		}
+		switch t := msg.(type) {
+		case *api.SRv6L3ServiceTLV:
+			sids, err := parseSRv6SIDs(t.SubTlvs)
+			if err != nil {
+				return nil, err
+			}
+			psid.SRv6L3Service = append(psid.SRv6L3Service, sids...)
+		case *api.SRv6L2ServiceTLV:
+			sids, err := parseSRv6SIDs(t.SubTlvs)
+			if err != nil {
+				return nil, err
+			}
+			psid.SRv6L2Service = append(psid.SRv6L2Service, sids...)
+		}
+	}
+	return psid, nil
+}
+
+// parseSRv6SIDs extracts the SID information sub-TLVs of a service TLV
+// GoBGP keys sub-TLVs by type in a map, so they are read in type order
+func parseSRv6SIDs(subTLVs map[uint32]*api.SRv6TLV) ([]SRv6SID, error) {
+	var sids []SRv6SID
+	for _, key := range sortedKeys(subTLVs) {
+		for _, any := range subTLVs[key].GetTlv() {
+			msg, err := any.UnmarshalNew()
+			if err != nil {
+				return nil, err
+			}
+			info, ok := msg.(*api.SRv6InformationSubTLV)
+			if !ok {
+				continue
+			}
+			if len(info.Sid) != net.IPv6len {
+				return nil, fmt.Errorf("srv6 sid: length %d, want %d", len(info.Sid), net.IPv6len)
+			}
+			sid := SRv6SID{SID: net.IP(info.Sid), EndpointBehavior: info.EndpointBehavior}
+			for _, subKey := range sortedKeys(info.SubSubTlvs) {
+				for _, any := range info.SubSubTlvs[subKey].GetTlv() {
+					var s api.SRv6StructureSubSubTLV
+					if any.MessageIs(&s) && any.UnmarshalTo(&s) == nil {
+						sid.Structure = &SRv6SIDStructure{
+							LocatorBlockLength:  uint8(s.LocatorBlockLength),
+							LocatorNodeLength:   uint8(s.LocatorNodeLength),
+							FunctionLength:      uint8(s.FunctionLength),
+							ArgumentLength:      uint8(s.ArgumentLength),
+							TranspositionLength: uint8(s.TranspositionLength),
+							TranspositionOffset: uint8(s.TranspositionOffset),
+						}
+					}
+				}
+			}
+			sids = append(sids, sid)
+		}
+	}
+	return sids, nil
+}
+
+// sortedKeys returns the keys of a GoBGP TLV map in ascending order
+func sortedKeys(m map[uint32]*api.SRv6TLV) []uint32 {
+	keys := make([]uint32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,43 @@
+package pkg
+
+// prefixAlert is a handler registered with OnPrefixThreshold
+type prefixAlert struct {
+	threshold int
+	handler   func(neighbor string, count int)
+	above     bool // Count was over the threshold at the last update
+}
+
+// OnPrefixThreshold calls handler when the number of routes received from
+// neighbor rises above threshold, e.g. to alert on a route leak
+// The handler fires once per upward crossing: it is re-armed only after the
+// count falls back to the threshold or below, and a peer already above the
+// threshold when the handler is registered does not trigger it
+// Counts come from the received route cache and the handler runs
+// synchronously with update dispatch, like an UpdateHandler
+func (s *BGPService) OnPrefixThreshold(neighbor string, threshold int, handler func(neighbor string, count int)) {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	s.prefixAlerts[neighbor] = append(s.prefixAlerts[neighbor], &prefixAlert{
+		threshold: threshold,
+		handler:   handler,
+		above:     s.routes.peerCount(neighbor) > threshold,
+	})
+}
+
+// checkPrefixThresholds fires the alerts of neighbor that count has crossed
+func (s *BGPService) checkPrefixThresholds(neighbor string, count int) {
+	s.alertMu.Lock()
+	var fire []*prefixAlert
+	for _, alert := range s.prefixAlerts[neighbor] {
+		above := count > alert.threshold
+		if above && !alert.above {
+			fire = append(fire, alert)
+		}
+		alert.above = above
+	}
+	s.alertMu.Unlock()
+
+	for _, alert := range fire {
+		alert.handler(neighbor, count)
+	}
+}
@@ -0,0 +1,38 @@
+package pkg
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestOnPrefixThreshold verifies the handler fires once when a peer's route
+// count rises above the threshold and again only after it has dropped back
+func TestOnPrefixThreshold(t *testing.T) {
+	bgpService := NewBGPService()
+	var fired []int
+	bgpService.OnPrefixThreshold("192.0.2.1", 5, func(neighbor string, count int) {
+		if neighbor != "192.0.2.1" {
+			t.Errorf("handler called for %s, want 192.0.2.1", neighbor)
+		}
+		fired = append(fired, count)
+	})
+
+	for i := 0; i < 8; i++ {
+		bgpService.dispatch(testUpdate("192.0.2.1", fmt.Sprintf("10.0.%d.0/24", i), false))
+		bgpService.dispatch(testUpdate("192.0.2.2", fmt.Sprintf("10.1.%d.0/24", i), false))
+	}
+	// Re-announcing a known prefix does not change the count
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.0.0/24", false))
+	if len(fired) != 1 || fired[0] != 6 {
+		t.Fatalf("handler calls = %v, want one with count 6", fired)
+	}
+
+	// Dropping to the threshold re-arms the alert
+	for i := 0; i < 3; i++ {
+		bgpService.dispatch(testUpdate("192.0.2.1", fmt.Sprintf("10.0.%d.0/24", i), true))
+	}
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.100.0/24", false))
+	if len(fired) != 2 || fired[1] != 6 {
+		t.Errorf("handler calls = %v, want a second call with count 6", fired)
+	}
+}
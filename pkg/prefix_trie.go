@@ -0,0 +1,167 @@
+package pkg
+
+import "net/netip"
+
+// prefixTrie is a binary trie keyed on IP prefixes with one tree per
+// address family, so lookups cost O(prefix length) whatever the table size
+// Not safe for concurrent use; callers provide their own locking
+type prefixTrie[V any] struct {
+	v4, v6 *trieNode[V]
+	size   int
+}
+
+// trieNode is one bit position; set marks nodes that hold a stored prefix
+type trieNode[V any] struct {
+	children [2]*trieNode[V]
+	value    V
+	set      bool
+}
+
+func newPrefixTrie[V any]() *prefixTrie[V] {
+	return &prefixTrie[V]{v4: &trieNode[V]{}, v6: &trieNode[V]{}}
+}
+
+// Len returns the number of stored prefixes
+func (t *prefixTrie[V]) Len() int {
+	return t.size
+}
+
+// root returns the tree for p's address family
+func (t *prefixTrie[V]) root(p netip.Prefix) *trieNode[V] {
+	if p.Addr().Is4() {
+		return t.v4
+	}
+	return t.v6
+}
+
+// trieKey holds an address as 16 bytes; IPv4 addresses are v4-mapped,
+// so their bits start at offset 96
+type trieKey struct {
+	bytes  [16]byte
+	offset int
+}
+
+func newTrieKey(addr netip.Addr) trieKey {
+	k := trieKey{bytes: addr.As16()}
+	if addr.Is4() {
+		k.offset = 96
+	}
+	return k
+}
+
+// bit returns bit i of the address, counting from the most significant
+func (k *trieKey) bit(i int) int {
+	i += k.offset
+	return int(k.bytes[i/8]>>(7-i%8)) & 1
+}
+
+// Insert stores v under p, replacing any previous value
+func (t *prefixTrie[V]) Insert(p netip.Prefix, v V) {
+	p = p.Masked()
+	key := newTrieKey(p.Addr())
+	n := t.root(p)
+	for i := 0; i < p.Bits(); i++ {
+		b := key.bit(i)
+		if n.children[b] == nil {
+			n.children[b] = &trieNode[V]{}
+		}
+		n = n.children[b]
+	}
+	if !n.set {
+		t.size++
+	}
+	n.value, n.set = v, true
+}
+
+// Get returns the value stored under exactly p
+func (t *prefixTrie[V]) Get(p netip.Prefix) (V, bool) {
+	p = p.Masked()
+	key := newTrieKey(p.Addr())
+	n := t.root(p)
+	for i := 0; i < p.Bits() && n != nil; i++ {
+		n = n.children[key.bit(i)]
+	}
+	if n == nil || !n.set {
+		var zero V
+		return zero, false
+	}
+	return n.value, true
+}
+
+// LongestMatch returns the most specific stored prefix covering p, p included
+func (t *prefixTrie[V]) LongestMatch(p netip.Prefix) (netip.Prefix, V, bool) {
+	p = p.Masked()
+	key := newTrieKey(p.Addr())
+	var (
+		best     V
+		bestBits = -1
+	)
+	n := t.root(p)
+	for i := 0; n != nil; i++ {
+		if n.set {
+			best, bestBits = n.value, i
+		}
+		if i == p.Bits() {
+			break
+		}
+		n = n.children[key.bit(i)]
+	}
+	if bestBits < 0 {
+		return netip.Prefix{}, best, false
+	}
+	match, _ := p.Addr().Prefix(bestBits)
+	return match, best, true
+}
+
+// Delete removes p, pruning nodes that no longer lead to a stored prefix
+func (t *prefixTrie[V]) Delete(p netip.Prefix) bool {
+	p = p.Masked()
+	key := newTrieKey(p.Addr())
+	path := []*trieNode[V]{t.root(p)}
+	for i := 0; i < p.Bits(); i++ {
+		next := path[len(path)-1].children[key.bit(i)]
+		if next == nil {
+			return false
+		}
+		path = append(path, next)
+	}
+
+	n := path[len(path)-1]
+	if !n.set {
+		return false
+	}
+	var zero V
+	n.value, n.set = zero, false
+	t.size--
+
+	for i := len(path) - 1; i > 0; i-- {
+		n := path[i]
+		if n.set || n.children[0] != nil || n.children[1] != nil {
+			break
+		}
+		path[i-1].children[key.bit(i-1)] = nil
+	}
+	return true
+}
+
+// Walk calls fn for every stored prefix, IPv4 before IPv6, in address order
+func (t *prefixTrie[V]) Walk(fn func(netip.Prefix, V)) {
+	walkNode(t.v4, netip.IPv4Unspecified(), 0, fn)
+	walkNode(t.v6, netip.IPv6Unspecified(), 0, fn)
+}
+
+func walkNode[V any](n *trieNode[V], addr netip.Addr, depth int, fn func(netip.Prefix, V)) {
+	if n == nil {
+		return
+	}
+	if n.set {
+		fn(netip.PrefixFrom(addr, depth), n.value)
+	}
+	walkNode(n.children[0], addr, depth+1, fn)
+	if n.children[1] != nil {
+		b := addr.AsSlice()
+		b[depth/8] |= 1 << (7 - depth%8)
+		one, _ := netip.AddrFromSlice(b)
+		walkNode(n.children[1], one, depth+1, fn)
+	}
+}
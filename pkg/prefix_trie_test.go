@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestPrefixTrieLongestMatch verifies the most specific covering prefix wins
+func TestPrefixTrieLongestMatch(t *testing.T) {
+	trie := newPrefixTrie[string]()
+	for _, p := range []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.3.0/24", "2001:db8::/32", "2001:db8:1::/48"} {
+		trie.Insert(netip.MustParsePrefix(p), p)
+	}
+
+	tests := []struct {
+		lookup string
+		want   string // "" means no match
+	}{
+		{lookup: "10.1.2.128/25", want: "10.1.2.0/24"},
+		{lookup: "10.1.2.0/24", want: "10.1.2.0/24"},
+		{lookup: "10.1.4.0/24", want: "10.1.0.0/16"},
+		{lookup: "10.2.0.0/16", want: "10.0.0.0/8"},
+		{lookup: "192.0.2.0/24", want: "0.0.0.0/0"},
+		{lookup: "2001:db8:1:2::/64", want: "2001:db8:1::/48"},
+		{lookup: "2001:db9::/32", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lookup, func(t *testing.T) {
+			prefix, value, ok := trie.LongestMatch(netip.MustParsePrefix(tt.lookup))
+			if tt.want == "" {
+				if ok {
+					t.Errorf("LongestMatch = %s, want no match", prefix)
+				}
+				return
+			}
+			if !ok || value != tt.want || prefix.String() != tt.want {
+				t.Errorf("LongestMatch = %s (%q, %v), want %s", prefix, value, ok, tt.want)
+			}
+		})
+	}
+}
+
+// TestPrefixTrieDelete verifies deleted prefixes stop matching and empty branches are pruned
+func TestPrefixTrieDelete(t *testing.T) {
+	trie := newPrefixTrie[int]()
+	trie.Insert(netip.MustParsePrefix("10.0.0.0/8"), 8)
+	trie.Insert(netip.MustParsePrefix("10.1.2.0/24"), 24)
+
+	if !trie.Delete(netip.MustParsePrefix("10.1.2.0/24")) {
+		t.Fatal("Delete(10.1.2.0/24) = false, want true")
+	}
+	if trie.Delete(netip.MustParsePrefix("10.1.0.0/16")) {
+		t.Error("Delete(10.1.0.0/16) = true for a prefix never inserted")
+	}
+	if _, v, _ := trie.LongestMatch(netip.MustParsePrefix("10.1.2.0/24")); v != 8 {
+		t.Errorf("LongestMatch after delete = %d, want 8", v)
+	}
+	if trie.Len() != 1 {
+		t.Errorf("Len = %d, want 1", trie.Len())
+	}
+	// Nothing is stored below 10.0.0.0/8 any more, so its node must be a leaf
+	n, key := trie.v4, newTrieKey(netip.MustParseAddr("10.0.0.0"))
+	for i := 0; i < 8; i++ {
+		n = n.children[key.bit(i)]
+	}
+	if n.children != [2]*trieNode[int]{} {
+		t.Error("Delete left the empty 10.1.2.0/24 branch in place")
+	}
+
+	var walked []string
+	trie.Walk(func(p netip.Prefix, _ int) { walked = append(walked, p.String()) })
+	if len(walked) != 1 || walked[0] != "10.0.0.0/8" {
+		t.Errorf("Walk = %v, want [10.0.0.0/8]", walked)
+	}
+}
+
+// BenchmarkPrefixTrieLongestMatch looks up addresses in a table of 500k /24s
+func BenchmarkPrefixTrieLongestMatch(b *testing.B) {
+	trie := newPrefixTrie[int]()
+	for i := 0; i < 500_000; i++ {
+		addr := netip.AddrFrom4([4]byte{byte(i >> 16), byte(i >> 8), byte(i), 0})
+		trie.Insert(netip.PrefixFrom(addr, 24), i)
+	}
+	lookup := netip.MustParsePrefix("5.10.20.30/32")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, ok := trie.LongestMatch(lookup); !ok {
+			b.Fatal("no match")
+		}
+	}
+}
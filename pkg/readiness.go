@@ -0,0 +1,42 @@
+package pkg
+
+import "fmt"
+
+// sessionEstablished is the NeighborInfo.SessionState of an up session
+const sessionEstablished = "established"
+
+// Ready reports whether the service is ready to serve routes, and why not
+// Without bgp.primaryNeighbor any Established peer is enough; with it,
+// only that specific peer counts, so a standby never reports ready on
+// the strength of a secondary session alone
+func (s *BGPService) Ready() (bool, string, error) {
+	neighbors, err := s.ListNeighbors()
+	if err != nil {
+		return false, "", err
+	}
+	ready, reason := readiness(neighbors, s.config.BGP.PrimaryNeighbor)
+	return ready, reason, nil
+}
+
+// readiness applies the readiness rule to a neighbor list
+func readiness(neighbors []NeighborInfo, primary string) (bool, string) {
+	if primary != "" {
+		for _, n := range neighbors {
+			if n.Address != primary {
+				continue
+			}
+			if n.SessionState != sessionEstablished {
+				return false, fmt.Sprintf("primary neighbor %s is %s", primary, n.SessionState)
+			}
+			return true, ""
+		}
+		return false, fmt.Sprintf("primary neighbor %s is not configured", primary)
+	}
+
+	for _, n := range neighbors {
+		if n.SessionState == sessionEstablished {
+			return true, ""
+		}
+	}
+	return false, "no neighbor is established"
+}
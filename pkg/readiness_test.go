@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestReadiness covers the any-peer rule and the primary neighbor rule
+func TestReadiness(t *testing.T) {
+	primaryUp := []NeighborInfo{
+		{Address: "192.0.2.1", SessionState: "established"},
+		{Address: "192.0.2.2", SessionState: "established"},
+	}
+	primaryDown := []NeighborInfo{
+		{Address: "192.0.2.1", SessionState: "active"},
+		{Address: "192.0.2.2", SessionState: "established"},
+	}
+
+	tests := []struct {
+		name      string
+		neighbors []NeighborInfo
+		primary   string
+		want      bool
+	}{
+		{name: "Any peer up", neighbors: primaryDown, want: true},
+		{name: "No peer up", neighbors: []NeighborInfo{{Address: "192.0.2.1", SessionState: "idle"}}, want: false},
+		{name: "Primary up", neighbors: primaryUp, primary: "192.0.2.1", want: true},
+		{name: "Primary down with secondary up", neighbors: primaryDown, primary: "192.0.2.1", want: false},
+		{name: "Primary not configured", neighbors: primaryUp, primary: "192.0.2.9", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason := readiness(tt.neighbors, tt.primary)
+			if got != tt.want {
+				t.Errorf("readiness() = %v (%s), want %v", got, reason, tt.want)
+			}
+			if !got && reason == "" {
+				t.Error("readiness() gave no reason for not being ready")
+			}
+		})
+	}
+}
+
+// TestReadyzEndpoint verifies /readyz reports 503 while no session is up
+func TestReadyzEndpoint(t *testing.T) {
+	_, httpServer := newTestHTTPServer(t)
+
+	rec := httptest.NewRecorder()
+	httpServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"sync"
+)
+
+// defaultRecentUpdates is the number of updates kept when output.recentUpdates is 0
+const defaultRecentUpdates = 100
+
+// updateRing keeps the most recent updates in a fixed-size ring buffer
+// It is written by dispatch and read by HTTP handlers concurrently
+type updateRing struct {
+	mu   sync.RWMutex
+	buf  []BGPUpdateMessage
+	next int  // Slot the next update is written to
+	full bool // Set once the buffer has wrapped
+}
+
+func newUpdateRing(size int) *updateRing {
+	if size <= 0 {
+		size = defaultRecentUpdates
+	}
+	return &updateRing{buf: make([]BGPUpdateMessage, size)}
+}
+
+// add stores update, overwriting the oldest one once the ring is full
+func (r *updateRing) add(update BGPUpdateMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf[r.next] = update
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// last copies out up to n of the newest updates, oldest first; n <= 0 means all
+func (r *updateRing) last(n int) []BGPUpdateMessage {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.buf)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+	updates := make([]BGPUpdateMessage, n)
+	for i := range updates {
+		updates[i] = r.buf[(r.next-n+i+len(r.buf))%len(r.buf)]
+	}
+	return updates
+}
+
+// RecentUpdates returns up to n of the most recently dispatched updates,
+// oldest first, as they were delivered to handlers; n <= 0 returns all kept
+// The result is a copy and safe to use while updates keep arriving
+func (s *BGPService) RecentUpdates(n int) []BGPUpdateMessage {
+	return s.recent.last(n)
+}
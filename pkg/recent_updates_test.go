@@ -0,0 +1,85 @@
+package pkg
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestRecentUpdates verifies the ring keeps the newest updates, oldest first
+func TestRecentUpdates(t *testing.T) {
+	cfg := &Config{}
+	cfg.Output.RecentUpdates = 3
+	bgpService := NewBGPServiceWithConfig(cfg)
+
+	if got := bgpService.RecentUpdates(0); len(got) != 0 {
+		t.Fatalf("RecentUpdates before any update = %v, want none", got)
+	}
+	for i := 1; i <= 5; i++ {
+		bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1", Timestamp: int64(i)})
+	}
+
+	timestamps := func(updates []BGPUpdateMessage) []int64 {
+		var ts []int64
+		for _, u := range updates {
+			ts = append(ts, u.Timestamp)
+		}
+		return ts
+	}
+	if got := timestamps(bgpService.RecentUpdates(0)); fmt.Sprint(got) != "[3 4 5]" {
+		t.Errorf("RecentUpdates(0) = %v, want [3 4 5]", got)
+	}
+	if got := timestamps(bgpService.RecentUpdates(2)); fmt.Sprint(got) != "[4 5]" {
+		t.Errorf("RecentUpdates(2) = %v, want [4 5]", got)
+	}
+	if got := timestamps(bgpService.RecentUpdates(10)); fmt.Sprint(got) != "[3 4 5]" {
+		t.Errorf("RecentUpdates(10) = %v, want [3 4 5]", got)
+	}
+}
+
+// TestRecentUpdatesConcurrent dispatches updates while several readers poll
+// the shared state; run with -race to catch unguarded access
+func TestRecentUpdatesConcurrent(t *testing.T) {
+	cfg := &Config{}
+	cfg.Output.RecentUpdates = 16
+	bgpService := NewBGPServiceWithConfig(cfg)
+	const updates = 500
+
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				for _, u := range bgpService.RecentUpdates(0) {
+					_ = u.FromPeer
+				}
+				bgpService.Stats()
+				bgpService.RoutesByOriginAS()
+				if _, err := bgpService.LookupReceived("10.0.0.0/8", true); err != nil {
+					t.Errorf("LookupReceived error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < updates; i++ {
+		bgpService.dispatch(testUpdate("192.0.2.1", fmt.Sprintf("10.%d.%d.0/24", i/256, i%256), i%3 == 0))
+	}
+	close(done)
+	readers.Wait()
+
+	if got := bgpService.Stats().Updates; got != updates {
+		t.Errorf("Stats().Updates = %d, want %d", got, updates)
+	}
+	if got := len(bgpService.RecentUpdates(0)); got != 16 {
+		t.Errorf("len(RecentUpdates(0)) = %d, want 16", got)
+	}
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// SetReplaySpeedup controls how fast ReplayUpdates plays back a recording
+// A factor of 1 honors the recorded gaps, 10 plays back ten times faster
+// and 0 (or any negative value) dispatches records back to back
+func (s *BGPService) SetReplaySpeedup(factor float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replaySpeedup = factor
+}
+
+// ReplayUpdates reads NDJSON BGPUpdateMessage records (as produced by
+// NDJSONWriter) and dispatches each one exactly as if it had been received
+// from a live session, so dashboards can be exercised without a peer
+// The gap between consecutive records is derived from their Timestamp
+// fields and scaled by the factor set with SetReplaySpeedup
+func (s *BGPService) ReplayUpdates(r io.Reader) error {
+	s.mu.RLock()
+	speedup := s.replaySpeedup
+	s.mu.RUnlock()
+
+	dec := json.NewDecoder(r)
+	var previous *BGPUpdateMessage
+	for record := 1; ; record++ {
+		var update BGPUpdateMessage
+		if err := dec.Decode(&update); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("replay record %d: %w", record, err)
+		}
+
+		if previous != nil && speedup > 0 {
+			if gap := update.Timestamp - previous.Timestamp; gap > 0 {
+				time.Sleep(time.Duration(float64(time.Duration(gap)*time.Second) / speedup))
+			}
+		}
+
+		s.dispatch(update)
+		previous = &update
+	}
+}
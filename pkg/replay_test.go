@@ -0,0 +1,54 @@
+package pkg
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+// TestReplayUpdates records two updates with NDJSONWriter and verifies that
+// replaying the recording delivers both to a registered handler
+func TestReplayUpdates(t *testing.T) {
+	var recording bytes.Buffer
+	writer := NewNDJSONWriter(&recording)
+
+	first := BGPUpdateMessage{FromPeer: "192.0.2.1", Timestamp: 100, ASPath: [][]uint32{{65002, 65010}}}
+	first.NLRI = append(first.NLRI, struct {
+		PrefixLength uint8
+		Prefix       net.IP
+	}{PrefixLength: 24, Prefix: net.ParseIP("10.0.0.0")})
+	second := BGPUpdateMessage{FromPeer: "192.0.2.1", Timestamp: 101, IsWithdraw: true}
+	writer.HandleUpdate(first)
+	writer.HandleUpdate(second)
+
+	bgpService := NewBGPService()
+	// Keep the one second recorded gap from slowing the test down
+	bgpService.SetReplaySpeedup(100)
+
+	var received []BGPUpdateMessage
+	bgpService.AddUpdateHandler(UpdateHandlerFunc(func(update BGPUpdateMessage) {
+		received = append(received, update)
+	}))
+
+	if err := bgpService.ReplayUpdates(&recording); err != nil {
+		t.Fatalf("ReplayUpdates() error = %v", err)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("handler received %d updates, want 2", len(received))
+	}
+	if got := received[0].NLRI; len(got) != 1 || !got[0].Prefix.Equal(net.ParseIP("10.0.0.0")) || got[0].PrefixLength != 24 {
+		t.Errorf("first update NLRI = %v, want 10.0.0.0/24", got)
+	}
+	if !received[1].IsWithdraw {
+		t.Error("second update should be a withdraw")
+	}
+}
+
+// TestReplayUpdatesMalformed verifies that a broken record is reported
+func TestReplayUpdatesMalformed(t *testing.T) {
+	bgpService := NewBGPService()
+	if err := bgpService.ReplayUpdates(bytes.NewBufferString("{not json}\n")); err == nil {
+		t.Error("ReplayUpdates() should fail on malformed input")
+	}
+}
@@ -0,0 +1,228 @@
+package pkg
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// routeCache holds the latest update per route as received through the
+// watch, indexed by a prefix trie for fast longest-prefix match
+// A route is a prefix from one peer within one route distinguisher, under
+// one ADD-PATH identifier
+// With bestOnly set, for the best watch filter, it holds one update per
+// prefix and route distinguisher instead: GoBGP reports each new best path
+// but never withdraws the one it replaces, so every update replaces
+// whatever the prefix had
+type routeCache struct {
+	mu       sync.RWMutex
+	bestOnly bool
+	trie     *prefixTrie[map[string]BGPUpdateMessage] // Prefix -> routeKey -> update
+	peers    map[string]int                           // Cached routes per peer
+}
+
+func newRouteCache(bestOnly bool) *routeCache {
+	return &routeCache{
+		bestOnly: bestOnly,
+		trie:     newPrefixTrie[map[string]BGPUpdateMessage](),
+		peers:    make(map[string]int),
+	}
+}
+
+// routeKey tells apart the routes cached for one prefix: by peer, route
+// distinguisher and path identifier, or by route distinguisher alone in
+// bestOnly mode
+func (c *routeCache) routeKey(update BGPUpdateMessage) string {
+	if c.bestOnly {
+		return update.RouteDistinguisher
+	}
+	return update.FromPeer + "|" + update.RouteDistinguisher + "|" + strconv.FormatUint(uint64(update.PathID), 10)
+}
+
+// routeFamily names the address family of a cached route as in familyNames
+func routeFamily(prefix netip.Prefix, update BGPUpdateMessage) string {
+	family := "ipv4-unicast"
+	if prefix.Addr().Is6() {
+		family = "ipv6-unicast"
+	}
+	if update.RouteDistinguisher != "" {
+		family = "l3vpn-" + family
+	}
+	return family
+}
+
+// apply records an announcement or removes a withdrawn route and returns
+// the number of routes now cached from the update's peer
+func (c *routeCache) apply(update BGPUpdateMessage) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, n := range update.NLRI {
+		addr, ok := netip.AddrFromSlice(n.Prefix)
+		if !ok {
+			continue
+		}
+		prefix := netip.PrefixFrom(addr.Unmap(), int(n.PrefixLength))
+		if !prefix.IsValid() {
+			continue
+		}
+
+		key := c.routeKey(update)
+		byRoute, _ := c.trie.Get(prefix)
+		if old, ok := byRoute[key]; ok {
+			// In bestOnly mode the replaced route may be another peer's
+			if c.peers[old.FromPeer]--; c.peers[old.FromPeer] == 0 {
+				delete(c.peers, old.FromPeer)
+			}
+		}
+		if update.IsWithdraw {
+			delete(byRoute, key)
+			if len(byRoute) == 0 {
+				c.trie.Delete(prefix)
+			}
+			continue
+		}
+		if byRoute == nil {
+			byRoute = make(map[string]BGPUpdateMessage)
+			c.trie.Insert(prefix, byRoute)
+		}
+		byRoute[key] = update
+		c.peers[update.FromPeer]++
+	}
+	return c.peers[update.FromPeer]
+}
+
+// peerCount returns the number of routes cached from peer
+func (c *routeCache) peerCount(peer string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.peers[peer]
+}
+
+// lookup returns the cached updates for prefix, or for the most specific
+// cached prefix covering it when longestMatch is set, ordered by peer,
+// route distinguisher and path identifier
+func (c *routeCache) lookup(prefix netip.Prefix, longestMatch bool) []BGPUpdateMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var byRoute map[string]BGPUpdateMessage
+	if longestMatch {
+		_, byRoute, _ = c.trie.LongestMatch(prefix)
+	} else {
+		byRoute, _ = c.trie.Get(prefix)
+	}
+
+	updates := make([]BGPUpdateMessage, 0, len(byRoute))
+	for _, u := range byRoute {
+		updates = append(updates, u)
+	}
+	sort.Slice(updates, func(i, j int) bool {
+		a, b := updates[i], updates[j]
+		if a.FromPeer != b.FromPeer {
+			return a.FromPeer < b.FromPeer
+		}
+		if a.RouteDistinguisher != b.RouteDistinguisher {
+			return a.RouteDistinguisher < b.RouteDistinguisher
+		}
+		return a.PathID < b.PathID
+	})
+	return updates
+}
+
+// LookupReceived is LookupPrefix served from the cache of routes received
+// from peers rather than from the GoBGP RIB; locally originated paths are
+// not included
+// Lookups walk a prefix trie, so they cost O(prefix length) even with full tables
+func (s *BGPService) LookupReceived(cidr string, longestMatch bool) ([]BGPUpdateMessage, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidPrefix, cidr, err)
+	}
+	return s.routes.lookup(prefix, longestMatch), nil
+}
+
+// originCounts returns the number of cached prefixes each AS originates
+// A prefix is counted once per distinct origin, however many peers sent it
+func (c *routeCache) originCounts() map[uint32]int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	counts := make(map[uint32]int)
+	c.trie.Walk(func(_ netip.Prefix, byRoute map[string]BGPUpdateMessage) {
+		seen := make(map[uint32]bool, 1)
+		for _, u := range byRoute {
+			if u.OriginAS != 0 && !seen[u.OriginAS] {
+				seen[u.OriginAS] = true
+				counts[u.OriginAS]++
+			}
+		}
+	})
+	return counts
+}
+
+// RoutesByOriginAS counts received prefixes by the AS that originated them
+// Prefixes with no single origin, such as aggregates ending in an AS_SET,
+// are left out
+func (s *BGPService) RoutesByOriginAS() map[uint32]int {
+	return s.routes.originCounts()
+}
+
+// ungroupedKey files routes that have nothing to group by, such as routes
+// without communities or with no single origin AS
+const ungroupedKey = "none"
+
+// routeGroupKeys maps the groupings GroupRoutes supports to the keys a
+// route is filed under; a route is listed once under every key it returns
+var routeGroupKeys = map[string]func(BGPUpdateMessage) []string{
+	"origin-as": func(u BGPUpdateMessage) []string {
+		if u.OriginAS == 0 {
+			return nil
+		}
+		return []string{strconv.FormatUint(uint64(u.OriginAS), 10)}
+	},
+	"peer":      func(u BGPUpdateMessage) []string { return []string{u.FromPeer} },
+	"community": func(u BGPUpdateMessage) []string { return u.CommunityStrings },
+}
+
+// group files every cached route under the keys returned by keys, in
+// prefix order and then by peer within a prefix
+func (c *routeCache) group(keys func(BGPUpdateMessage) []string) map[string][]BGPUpdateMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	groups := make(map[string][]BGPUpdateMessage)
+	c.trie.Walk(func(_ netip.Prefix, byRoute map[string]BGPUpdateMessage) {
+		ids := make([]string, 0, len(byRoute))
+		for id := range byRoute {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids) // routeKey starts with the peer
+		for _, id := range ids {
+			u := byRoute[id]
+			routeKeys := keys(u)
+			if len(routeKeys) == 0 {
+				routeKeys = []string{ungroupedKey}
+			}
+			for _, key := range routeKeys {
+				groups[key] = append(groups[key], u)
+			}
+		}
+	})
+	return groups
+}
+
+// GroupRoutes groups the routes received from peers by origin-as, peer or
+// community, e.g. to color dashboard tables; the keys are the AS number,
+// peer address or community string
+// A route with several communities is listed under each of them, and routes
+// with no value to group by are listed under "none"
+func (s *BGPService) GroupRoutes(by string) (map[string][]BGPUpdateMessage, error) {
+	keys, ok := routeGroupKeys[by]
+	if !ok {
+		return nil, fmt.Errorf("invalid grouping %q, expected origin-as, peer or community", by)
+	}
+	return s.routes.group(keys), nil
+}
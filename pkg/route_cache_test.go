@@ -0,0 +1,170 @@
+package pkg
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// testUpdate returns a minimal announcement of prefix from peer
+func testUpdate(peer, prefix string, withdraw bool) BGPUpdateMessage {
+	_, ipNet, _ := net.ParseCIDR(prefix)
+	length, _ := ipNet.Mask.Size()
+	u := BGPUpdateMessage{FromPeer: peer, IsWithdraw: withdraw}
+	u.NLRI = append(u.NLRI, struct {
+		PrefixLength uint8
+		Prefix       net.IP
+	}{PrefixLength: uint8(length), Prefix: ipNet.IP})
+	return u
+}
+
+// TestLookupReceived verifies exact and longest-match lookups over received routes
+func TestLookupReceived(t *testing.T) {
+	bgpService := NewBGPService()
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.0.0/8", false))
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.1.0.0/16", false))
+	bgpService.dispatch(testUpdate("192.0.2.2", "10.1.0.0/16", false))
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.1.2.0/24", false))
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.1.2.0/24", true)) // Withdrawn again
+
+	got, err := bgpService.LookupReceived("10.1.2.0/25", true)
+	if err != nil {
+		t.Fatalf("LookupReceived error = %v", err)
+	}
+	if len(got) != 2 || got[0].FromPeer != "192.0.2.1" || got[1].FromPeer != "192.0.2.2" {
+		t.Errorf("longest match = %+v, want 10.1.0.0/16 from both peers", got)
+	}
+
+	if got, _ := bgpService.LookupReceived("10.1.2.0/24", false); len(got) != 0 {
+		t.Errorf("exact match of withdrawn prefix = %+v, want none", got)
+	}
+	if _, err := bgpService.LookupReceived("10.1.2.0/33", false); !errors.Is(err, ErrInvalidPrefix) {
+		t.Errorf("LookupReceived error = %v, want ErrInvalidPrefix", err)
+	}
+}
+
+// TestRouteCacheRouteKeys verifies routes to one prefix from one peer are
+// kept apart by route distinguisher and ADD-PATH identifier
+func TestRouteCacheRouteKeys(t *testing.T) {
+	bgpService := NewBGPService()
+	route := func(rd string, pathID uint32, withdraw bool) BGPUpdateMessage {
+		u := testUpdate("192.0.2.1", "10.1.0.0/16", withdraw)
+		u.RouteDistinguisher, u.PathID = rd, pathID
+		return u
+	}
+	bgpService.dispatch(route("", 1, false))
+	bgpService.dispatch(route("", 2, false))
+	bgpService.dispatch(route("65000:100", 1, false))
+	bgpService.dispatch(route("65000:200", 1, false))
+	bgpService.dispatch(route("65000:200", 1, true))
+
+	got, err := bgpService.LookupReceived("10.1.0.0/16", false)
+	if err != nil {
+		t.Fatalf("LookupReceived error = %v", err)
+	}
+	var keys []string
+	for _, u := range got {
+		keys = append(keys, u.RouteDistinguisher+"/"+strconv.Itoa(int(u.PathID)))
+	}
+	if want := []string{"/1", "/2", "65000:100/1"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("cached routes = %v, want %v", keys, want)
+	}
+	if n := bgpService.routes.peerCount("192.0.2.1"); n != 3 {
+		t.Errorf("routes from 192.0.2.1 = %d, want 3", n)
+	}
+}
+
+// TestRoutesByOriginAS verifies prefixes are counted once per originating AS
+func TestRoutesByOriginAS(t *testing.T) {
+	bgpService := NewBGPService()
+	announce := func(peer, prefix string, origin uint32) {
+		u := testUpdate(peer, prefix, false)
+		u.ASPath = [][]uint32{{65100, origin}}
+		u.OriginAS = origin
+		bgpService.dispatch(u)
+	}
+	announce("192.0.2.1", "10.0.0.0/24", 64501)
+	announce("192.0.2.2", "10.0.0.0/24", 64501) // Same prefix via another peer
+	announce("192.0.2.1", "10.0.1.0/24", 64501)
+	announce("192.0.2.1", "10.0.2.0/24", 64502)
+	announce("192.0.2.1", "10.0.3.0/24", 64502)
+	announce("192.0.2.1", "2001:db8::/32", 64503)
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.3.0/24", true))
+
+	want := map[uint32]int{64501: 2, 64502: 1, 64503: 1}
+	if got := bgpService.RoutesByOriginAS(); !reflect.DeepEqual(got, want) {
+		t.Errorf("RoutesByOriginAS() = %v, want %v", got, want)
+	}
+}
+
+// TestGroupRoutes verifies routes are filed under the peer that sent them
+// and that an unknown grouping is refused
+func TestGroupRoutes(t *testing.T) {
+	bgpService := NewBGPService()
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.0.0/24", false))
+	bgpService.dispatch(testUpdate("192.0.2.2", "10.0.0.0/24", false))
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.0.1.0/24", false))
+	bgpService.dispatch(testUpdate("192.0.2.2", "2001:db8::/32", false))
+	bgpService.dispatch(testUpdate("192.0.2.3", "10.0.2.0/24", false))
+	bgpService.dispatch(testUpdate("192.0.2.3", "10.0.2.0/24", true)) // Withdrawn again
+
+	groups, err := bgpService.GroupRoutes("peer")
+	if err != nil {
+		t.Fatalf("GroupRoutes() error = %v", err)
+	}
+	prefixes := make(map[string][]string)
+	for peer, routes := range groups {
+		for _, u := range routes {
+			if u.FromPeer != peer {
+				t.Errorf("route from %s grouped under %s", u.FromPeer, peer)
+			}
+			n := u.NLRI[0]
+			prefixes[peer] = append(prefixes[peer], (&net.IPNet{IP: n.Prefix, Mask: net.CIDRMask(int(n.PrefixLength), len(n.Prefix)*8)}).String())
+		}
+	}
+	want := map[string][]string{
+		"192.0.2.1": {"10.0.0.0/24", "10.0.1.0/24"},
+		"192.0.2.2": {"10.0.0.0/24", "2001:db8::/32"},
+	}
+	if !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("GroupRoutes(peer) prefixes = %v, want %v", prefixes, want)
+	}
+
+	if _, err := bgpService.GroupRoutes("next-hop"); err == nil {
+		t.Error("GroupRoutes(next-hop) succeeded, want an error")
+	}
+}
+
+// TestRouteCacheBestWatch verifies that with the best watch filter a new
+// best path from another peer replaces the previous one, which GoBGP never
+// withdraws
+func TestRouteCacheBestWatch(t *testing.T) {
+	config := &Config{}
+	config.Watch.Filter = WatchBest
+	bgpService := NewBGPServiceWithServer(config, newFakeBgpServer())
+
+	bgpService.dispatch(testUpdate("192.0.2.1", "10.1.0.0/16", false))
+	bgpService.dispatch(testUpdate("192.0.2.2", "10.1.0.0/16", false)) // Best moves to the second peer
+
+	got, err := bgpService.LookupReceived("10.1.0.0/16", false)
+	if err != nil {
+		t.Fatalf("LookupReceived error = %v", err)
+	}
+	if len(got) != 1 || got[0].FromPeer != "192.0.2.2" {
+		t.Errorf("LookupReceived = %+v, want only the best path from 192.0.2.2", got)
+	}
+	if n := bgpService.routes.peerCount("192.0.2.1"); n != 0 {
+		t.Errorf("routes from the former best peer = %d, want 0", n)
+	}
+	if groups, _ := bgpService.GroupRoutes("peer"); len(groups) != 1 || len(groups["192.0.2.2"]) != 1 {
+		t.Errorf("GroupRoutes(peer) = %v, want one route from 192.0.2.2", groups)
+	}
+
+	// The last path going away is reported as a withdrawal
+	bgpService.dispatch(testUpdate("192.0.2.2", "10.1.0.0/16", true))
+	if got, _ := bgpService.LookupReceived("10.1.0.0/16", false); len(got) != 0 {
+		t.Errorf("LookupReceived after withdrawal = %+v, want none", got)
+	}
+}
@@ -0,0 +1,66 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"net"
+)
+
+// hostInterface is the part of a network interface router ID selection uses
+type hostInterface struct {
+	Name  string
+	Flags net.Flags
+	Addrs []net.Addr
+}
+
+// listInterfaces returns the host's interfaces with their addresses
+func listInterfaces() ([]hostInterface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]hostInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, hostInterface{Name: iface.Name, Flags: iface.Flags, Addrs: addrs})
+	}
+	return hosts, nil
+}
+
+// selectRouterID picks the highest IPv4 address of an interface that is up,
+// preferring loopbacks as routers classically do since they never go down
+// Loopback (127/8) and link-local (169.254/16) addresses are never chosen
+func selectRouterID(ifaces []hostInterface) (string, error) {
+	var best, bestLoopback net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		for _, addr := range iface.Addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			ip := ipNet.IP.To4()
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			if best == nil || bytes.Compare(ip, best) > 0 {
+				best = ip
+			}
+			if iface.Flags&net.FlagLoopback != 0 && (bestLoopback == nil || bytes.Compare(ip, bestLoopback) > 0) {
+				bestLoopback = ip
+			}
+		}
+	}
+	if bestLoopback != nil {
+		return bestLoopback.String(), nil
+	}
+	if best != nil {
+		return best.String(), nil
+	}
+	return "", errors.New("no router ID configured and no interface has a usable IPv4 address")
+}
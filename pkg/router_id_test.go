@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"net"
+	"testing"
+)
+
+// ifaceAddrs builds interface addresses from CIDR strings
+func ifaceAddrs(t *testing.T, cidrs ...string) []net.Addr {
+	t.Helper()
+	addrs := make([]net.Addr, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		ip, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, &net.IPNet{IP: ip, Mask: ipNet.Mask})
+	}
+	return addrs
+}
+
+// TestSelectRouterID verifies the highest eligible IPv4 address wins, loopbacks first
+func TestSelectRouterID(t *testing.T) {
+	lo := hostInterface{Name: "lo", Flags: net.FlagUp | net.FlagLoopback, Addrs: ifaceAddrs(t, "127.0.0.1/8", "::1/128")}
+	eth0 := hostInterface{Name: "eth0", Flags: net.FlagUp, Addrs: ifaceAddrs(t, "192.0.2.10/24", "169.254.1.1/16", "2001:db8::1/64")}
+	eth1 := hostInterface{Name: "eth1", Flags: net.FlagUp, Addrs: ifaceAddrs(t, "198.51.100.7/24")}
+	down := hostInterface{Name: "eth2", Addrs: ifaceAddrs(t, "203.0.113.1/24")}
+	loopback := hostInterface{Name: "lo1", Flags: net.FlagUp | net.FlagLoopback, Addrs: ifaceAddrs(t, "10.255.0.1/32", "10.255.0.2/32")}
+
+	tests := []struct {
+		name   string
+		ifaces []hostInterface
+		want   string
+	}{
+		{name: "Highest address", ifaces: []hostInterface{lo, eth0, eth1, down}, want: "198.51.100.7"},
+		{name: "Loopback preferred", ifaces: []hostInterface{lo, eth0, eth1, loopback}, want: "10.255.0.2"},
+		{name: "Nothing eligible", ifaces: []hostInterface{lo, down}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectRouterID(tt.ifaces)
+			if got != tt.want || (err != nil) != (tt.want == "") {
+				t.Errorf("selectRouterID() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestStartSelectsRouterID verifies Start falls back to an interface address
+func TestStartSelectsRouterID(t *testing.T) {
+	fake := newFakeBgpServer()
+	bgpService := NewBGPServiceWithServer(&Config{}, fake)
+	bgpService.interfaces = func() ([]hostInterface, error) {
+		return []hostInterface{{Name: "eth0", Flags: net.FlagUp, Addrs: ifaceAddrs(t, "192.0.2.10/24")}}, nil
+	}
+	if err := bgpService.Start("", 65001); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(bgpService.Stop)
+
+	if got := fake.started.GetGlobal().GetRouterId(); got != "192.0.2.10" {
+		t.Errorf("RouterId = %q, want 192.0.2.10", got)
+	}
+}
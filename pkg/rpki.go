@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"fmt"
+	api "github.com/osrg/gobgp/v3/api"
+	"strings"
+)
+
+// rtrPort is the IANA port for RPKI-to-router (RFC 8210) caches
+const rtrPort = 323
+
+// originValidationPolicy names the import policy enforcing RPKI results
+const originValidationPolicy = "rpki-origin-validation"
+
+// RPKIConfig connects GoBGP to RPKI caches so every route is given an origin
+// validation state (RFC 6811), and optionally acts on that state
+type RPKIConfig struct {
+	Servers []RPKIServerConfig `yaml:"servers"`
+
+	// DropInvalid rejects invalid routes on import, so they never reach the RIB
+	DropInvalid bool `yaml:"dropInvalid"`
+
+	// NotFoundLocalPref, when set, is the LOCAL_PREF given to routes no ROA
+	// covers, so they lose to valid routes for the same prefix
+	NotFoundLocalPref uint32 `yaml:"notFoundLocalPref"`
+}
+
+// RPKIServerConfig is an RPKI cache GoBGP fetches ROAs from over RTR
+type RPKIServerConfig struct {
+	Address string `yaml:"address"` // Cache address, e.g. "192.0.2.60"
+	Port    uint16 `yaml:"port"`    // 323 when 0
+}
+
+// enforcing reports whether routes are dropped or deprioritized by their
+// validation state rather than only labelled with it
+func (c RPKIConfig) enforcing() bool {
+	return c.DropInvalid || c.NotFoundLocalPref != 0
+}
+
+// validateRPKI checks every cache has an address and that enforcement has
+// caches to validate against
+// Configured neighbors and peer groups are checked with checkRPKIFamilies
+// here too, so a bad config fails Start rather than the first neighbor added
+func validateRPKI(cfg *Config) error {
+	for i, server := range cfg.RPKI.Servers {
+		if server.Address == "" {
+			return fmt.Errorf("rpki.servers[%d]: address is required", i)
+		}
+	}
+	if !cfg.RPKI.enforcing() {
+		return nil
+	}
+	if len(cfg.RPKI.Servers) == 0 {
+		return fmt.Errorf("rpki: dropInvalid and notFoundLocalPref need at least one server")
+	}
+	templates := []NeighborTemplate{cfg.BGP.Remote.NeighborTemplate}
+	for _, n := range cfg.BGP.Neighbors {
+		templates = append(templates, n.NeighborTemplate)
+	}
+	for _, g := range cfg.BGP.PeerGroups {
+		templates = append(templates, g.NeighborTemplate)
+	}
+	for _, t := range templates {
+		if err := checkRPKIFamilies(cfg.RPKI, t.Families); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRPKIFamilies refuses l3vpn families while origin validation is
+// enforced: GoBGP only holds ROAs for the unicast families and its policy
+// engine crashes evaluating an RPKI condition on an l3vpn route
+// Every way of adding a neighbor or peer group goes through this check
+func checkRPKIFamilies(cfg RPKIConfig, families []string) error {
+	if !cfg.enforcing() {
+		return nil
+	}
+	for _, family := range families {
+		if strings.HasPrefix(family, "l3vpn-") {
+			return fmt.Errorf("rpki: origin validation policy cannot be combined with family %s", family)
+		}
+	}
+	return nil
+}
+
+// addRPKIServers starts fetching ROAs from every cache under rpki.servers
+// GoBGP keeps reconnecting to a cache that is down; until it answers, routes
+// are not-found rather than invalid
+func (s *BGPService) addRPKIServers() error {
+	for _, server := range s.config.RPKI.Servers {
+		port := uint32(server.Port)
+		if port == 0 {
+			port = rtrPort
+		}
+		if err := s.server.AddRpki(s.context, &api.AddRpkiRequest{
+			Address: server.Address,
+			Port:    port,
+		}); err != nil {
+			return fmt.Errorf("adding RPKI server %s: %w", server.Address, err)
+		}
+	}
+	return nil
+}
+
+// applyOriginValidation installs the import policy that drops invalid
+// routes and lowers the LOCAL_PREF of not-found ones, as configured
+// It is installed on Start ahead of any runtime import policy, so an
+// accepting prefix filter cannot let an invalid route through
+// Every statement also matches a catch-all prefix set, since GoBGP fails
+// evaluating an RPKI condition on a family it holds no ROAs for
+func (s *BGPService) applyOriginValidation() error {
+	cfg := s.config.RPKI
+	if !cfg.enforcing() {
+		return nil
+	}
+	sets := []*api.DefinedSet{
+		{
+			DefinedType: api.DefinedType_PREFIX,
+			Name:        originValidationPolicy + "-ipv4",
+			Prefixes:    []*api.Prefix{{IpPrefix: "0.0.0.0/0", MaskLengthMin: 0, MaskLengthMax: 32}},
+		},
+		{
+			DefinedType: api.DefinedType_PREFIX,
+			Name:        originValidationPolicy + "-ipv6",
+			Prefixes:    []*api.Prefix{{IpPrefix: "::/0", MaskLengthMin: 0, MaskLengthMax: 128}},
+		},
+	}
+	policy := &api.Policy{Name: originValidationPolicy}
+	for _, set := range sets {
+		if cfg.DropInvalid {
+			policy.Statements = append(policy.Statements, &api.Statement{
+				Name: set.Name + "-drop-invalid",
+				Conditions: &api.Conditions{
+					PrefixSet:  &api.MatchSet{Type: api.MatchSet_ANY, Name: set.Name},
+					RpkiResult: int32(api.Validation_STATE_INVALID),
+				},
+				Actions: &api.Actions{RouteAction: api.RouteAction_REJECT},
+			})
+		}
+		if cfg.NotFoundLocalPref != 0 {
+			// No route action, so the remaining import policies still decide
+			policy.Statements = append(policy.Statements, &api.Statement{
+				Name: set.Name + "-not-found",
+				Conditions: &api.Conditions{
+					PrefixSet:  &api.MatchSet{Type: api.MatchSet_ANY, Name: set.Name},
+					RpkiResult: int32(api.Validation_STATE_NOT_FOUND),
+				},
+				Actions: &api.Actions{LocalPref: &api.LocalPrefAction{Value: cfg.NotFoundLocalPref}},
+			})
+		}
+	}
+	return s.applyNeighborPolicy(api.PolicyDirection_IMPORT, policy, sets)
+}
@@ -0,0 +1,141 @@
+package pkg
+
+import (
+	"context"
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/packet/rtr"
+	"github.com/osrg/gobgp/v3/pkg/server"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveROAs runs an RTR cache on a loopback port that answers the first
+// reset query with a single ROA, 10.1.0.0/16 up to /24 originated by 65099
+func serveROAs(t *testing.T) RPKIServerConfig {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		query := make([]byte, rtr.RTR_RESET_QUERY_LEN)
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		for _, msg := range []rtr.RTRMessage{
+			rtr.NewRTRCacheResponse(1),
+			rtr.NewRTRIPPrefix(net.ParseIP("10.1.0.0").To4(), 16, 24, 65099, 1),
+			rtr.NewRTREndOfData(1, 1),
+		} {
+			b, _ := msg.Serialize()
+			if _, err := conn.Write(b); err != nil {
+				return
+			}
+		}
+		io.Copy(io.Discard, conn) // Hold the session open until the test ends
+	}()
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return RPKIServerConfig{Address: "127.0.0.1", Port: uint16(p)}
+}
+
+// TestOriginValidationDropInvalid verifies invalid routes never reach the
+// global RIB and not-found ones are installed with the lowered LOCAL_PREF
+func TestOriginValidationDropInvalid(t *testing.T) {
+	config := &Config{}
+	config.RPKI = RPKIConfig{
+		Servers:           []RPKIServerConfig{serveROAs(t)},
+		DropInvalid:       true,
+		NotFoundLocalPref: 50,
+	}
+	bgpService := newTestService(t, config)
+
+	// Policies are applied as paths arrive, so wait for the ROA first
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var roas int
+		bgpService.server.(*server.BgpServer).ListRpkiTable(context.Background(), &api.ListRpkiTableRequest{}, func(*api.Roa) { roas++ })
+		if roas > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no ROA received from the RTR cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 10.1.0.0/24 is covered by the ROA for another origin, 10.2.0.0/24 by none
+	for _, prefix := range []string{"10.1.0.0/24", "10.2.0.0/24"} {
+		if err := bgpService.AddPath(PathSpec{Prefix: prefix, NextHop: "192.0.2.1"}); err != nil {
+			t.Fatalf("AddPath(%s) error = %v", prefix, err)
+		}
+	}
+
+	if paths, _ := bgpService.ListPaths(); len(paths) != 1 {
+		t.Fatalf("ListPaths() returned %d paths, want only the not-found one", len(paths))
+	}
+	if lp := findPath(t, bgpService, "10.2.0.0/24").LocalPref; lp == nil || *lp != 50 {
+		t.Errorf("not-found LocalPref = %v, want 50", lp)
+	}
+}
+
+// TestOriginValidationInvalid verifies Start refuses enforcement without a
+// cache or alongside l3vpn families
+func TestOriginValidationInvalid(t *testing.T) {
+	noServer := &Config{}
+	noServer.RPKI.DropInvalid = true
+
+	l3vpn := &Config{}
+	l3vpn.RPKI = RPKIConfig{Servers: []RPKIServerConfig{{Address: "192.0.2.60"}}, NotFoundLocalPref: 50}
+	l3vpn.BGP.Neighbors = []NeighborConfig{{PeerIP: "192.0.2.1", ASN: 65002}}
+	l3vpn.BGP.Neighbors[0].Families = []string{"l3vpn-ipv4-unicast"}
+
+	noAddress := &Config{}
+	noAddress.RPKI.Servers = []RPKIServerConfig{{Port: 3323}}
+
+	for name, config := range map[string]*Config{"no server": noServer, "l3vpn": l3vpn, "no address": noAddress} {
+		if err := NewBGPServiceWithServer(config, newFakeBgpServer()).Start("192.0.2.254", 65001); err == nil {
+			t.Errorf("%s: Start succeeded, want an error", name)
+		}
+	}
+}
+
+// TestOriginValidationRuntimeL3VPN verifies neighbors added after Start are
+// refused l3vpn families while invalid routes are dropped
+func TestOriginValidationRuntimeL3VPN(t *testing.T) {
+	config := &Config{}
+	config.RPKI = RPKIConfig{Servers: []RPKIServerConfig{serveROAs(t)}, DropInvalid: true}
+	bgpService := newTestService(t, config)
+
+	neighbor := NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002}
+	neighbor.Families = []string{"ipv4-unicast", "l3vpn-ipv4-unicast"}
+	if err := bgpService.AddNeighborConfig(neighbor); err == nil {
+		t.Error("AddNeighborConfig with an l3vpn family succeeded, want an error")
+	}
+	if err := bgpService.UpsertNeighbor(neighbor); err == nil {
+		t.Error("UpsertNeighbor with an l3vpn family succeeded, want an error")
+	}
+	if neighbors, _ := bgpService.ListNeighbors(); len(neighbors) != 0 {
+		t.Errorf("ListNeighbors() = %+v, want no neighbor added", neighbors)
+	}
+
+	neighbor.Families = []string{"ipv4-unicast"}
+	if err := bgpService.AddNeighborConfig(neighbor); err != nil {
+		t.Fatalf("AddNeighborConfig error = %v", err)
+	}
+	neighbor.Families = []string{"l3vpn-ipv4-unicast"}
+	if err := bgpService.UpdateNeighbor(neighbor); err == nil {
+		t.Error("UpdateNeighbor to an l3vpn family succeeded, want an error")
+	}
+}
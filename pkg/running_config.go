@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	api "github.com/osrg/gobgp/v3/api"
+	"log"
+	"sort"
+)
+
+// RunningConfig reconstructs the configuration the service is running with,
+// like a router's "show running-config"
+// The global settings come from Start and the neighbors from the live peer
+// table, so neighbors added at runtime are included; every neighbor is
+// listed with its resolved settings under bgp.neighbors, sorted by address,
+// or under bgp.remote if it is the configured remote
+// Sections the service does not change at runtime are copied from the
+// loaded config
+func (s *BGPService) RunningConfig() Config {
+	running := *s.config
+	running.BGP.PeerGroups = append([]PeerGroupConfig(nil), s.config.BGP.PeerGroups...)
+	running.BGP.DynamicNeighbors = append([]DynamicNeighborConfig(nil), s.config.BGP.DynamicNeighbors...)
+	running.BGP.Remote = NeighborConfig{}
+	running.BGP.Neighbors = nil
+
+	s.mu.RLock()
+	localASN := s.localASN
+	running.BGP.Local.RouterID = s.routerID
+	s.mu.RUnlock()
+	running.BGP.Local.ASN = int(localASN)
+
+	err := s.server.ListPeer(s.context, &api.ListPeerRequest{}, func(p *api.Peer) {
+		if p.GetConf().GetPeerGroup() != "" {
+			return // Created by a dynamic neighbor range, which bgp.dynamicNeighbors already covers
+		}
+		cfg := neighborConfigFromPeer(p, localASN)
+		if s.softReconfigInbound(cfg.PeerIP) {
+			enabled := true
+			cfg.SoftReconfigInbound = &enabled
+		}
+		if s.hasNextHopSelf(cfg.PeerIP) {
+			enabled := true
+			cfg.NextHopSelf = &enabled
+		}
+		if med, ok := s.inboundMEDSetting(cfg.PeerIP); ok && med == 0 {
+			enabled := true
+			cfg.IgnoreMED = &enabled
+		} else if ok {
+			cfg.OverrideMED = &med
+		}
+		cfg.SendCommunity = s.sendCommunitySetting(cfg.PeerIP)
+		cfg.Role = s.neighborRole(cfg.PeerIP)
+		if cfg.PeerIP == s.config.BGP.Remote.PeerIP {
+			running.BGP.Remote = cfg
+			return
+		}
+		running.BGP.Neighbors = append(running.BGP.Neighbors, cfg)
+	})
+	if err != nil {
+		log.Printf("Error listing peers for running config: %v", err)
+	}
+	// GoBGP lists peers in no particular order; sort so the output diffs cleanly
+	sort.Slice(running.BGP.Neighbors, func(i, j int) bool {
+		return running.BGP.Neighbors[i].PeerIP < running.BGP.Neighbors[j].PeerIP
+	})
+	return running
+}
+
+// neighborConfigFromPeer is the inverse of buildPeer
+// A local ASN equal to the global one is left out, as it would be in a file
+func neighborConfigFromPeer(p *api.Peer, localASN uint32) NeighborConfig {
+	conf := p.GetConf()
+	cfg := NeighborConfig{
+		PeerIP: conf.GetNeighborAddress(),
+		ASN:    int(conf.GetPeerAsn()),
+	}
+	cfg.AuthPassword = conf.GetAuthPassword()
+	cfg.AllowASIn = int(conf.GetAllowOwnAsn())
+	if conf.GetLocalAsn() != localASN {
+		cfg.LocalAS = int(conf.GetLocalAsn())
+	}
+
+	if port := p.GetTransport().GetRemotePort(); port != 0 && port != bgpPort {
+		cfg.PeerPort = uint16(port)
+	}
+
+	if gr := p.GetGracefulRestart(); gr != nil {
+		enabled := gr.GetEnabled()
+		cfg.GracefulRestart = &enabled
+		cfg.RestartTime = gr.GetRestartTime()
+	}
+	if timers := p.GetTimers().GetConfig(); timers != nil {
+		cfg.HoldTime = timers.GetHoldTime()
+		cfg.KeepaliveInterval = timers.GetKeepaliveInterval()
+	}
+
+	for _, afiSafi := range p.GetAfiSafis() {
+		cfg.Families = append(cfg.Families, familyName(afiSafi.GetConfig().GetFamily()))
+		if limit := afiSafi.GetPrefixLimits().GetMaxPrefixes(); limit > 0 {
+			cfg.MaxPrefixes = limit
+		}
+		if addPaths := afiSafi.GetAddPaths().GetConfig(); addPaths != nil {
+			cfg.AddPaths = AddPathsConfig{Receive: addPaths.GetReceive(), SendMax: addPaths.GetSendMax()}
+		}
+	}
+	return cfg
+}
+
+// redactedSecret replaces secret values in Redacted output
+const redactedSecret = "REDACTED"
+
+// Redacted returns a copy of c with every secret value replaced, safe to
+// show or store; references to secret files are kept
+func (c Config) Redacted() Config {
+	c.BGP.Remote.NeighborTemplate = c.BGP.Remote.NeighborTemplate.redacted()
+	c.BGP.Neighbors = append([]NeighborConfig(nil), c.BGP.Neighbors...)
+	for i := range c.BGP.Neighbors {
+		c.BGP.Neighbors[i].NeighborTemplate = c.BGP.Neighbors[i].NeighborTemplate.redacted()
+	}
+	c.BGP.PeerGroups = append([]PeerGroupConfig(nil), c.BGP.PeerGroups...)
+	for i := range c.BGP.PeerGroups {
+		c.BGP.PeerGroups[i].NeighborTemplate = c.BGP.PeerGroups[i].NeighborTemplate.redacted()
+	}
+	return c
+}
+
+func (t NeighborTemplate) redacted() NeighborTemplate {
+	if t.AuthPassword != "" {
+		t.AuthPassword = redactedSecret
+	}
+	return t
+}
@@ -0,0 +1,34 @@
+package pkg
+
+import "testing"
+
+// TestRunningConfig verifies neighbors added at runtime appear alongside configured ones
+func TestRunningConfig(t *testing.T) {
+	config := &Config{}
+	config.BGP.Remote = NeighborConfig{PeerIP: "192.0.2.1", ASN: 65002}
+	bgpService := newTestService(t, config)
+	if err := bgpService.AddNeighborConfig(config.BGP.Remote); err != nil {
+		t.Fatalf("Failed to add configured neighbor: %v", err)
+	}
+	if err := bgpService.AddNeighborConfig(NeighborConfig{PeerIP: "192.0.2.2", ASN: 65003, LocalAS: 64512, NeighborTemplate: NeighborTemplate{MaxPrefixes: 100}}); err != nil {
+		t.Fatalf("Failed to add runtime neighbor: %v", err)
+	}
+
+	running := bgpService.RunningConfig()
+	if running.BGP.Local.RouterID != "192.0.2.254" || running.BGP.Local.ASN != 65001 {
+		t.Errorf("Local = %+v, want 192.0.2.254 AS65001", running.BGP.Local)
+	}
+	if running.BGP.Remote.PeerIP != "192.0.2.1" || running.BGP.Remote.ASN != 65002 || running.BGP.Remote.LocalAS != 0 {
+		t.Errorf("Remote = %+v, want 192.0.2.1 AS65002", running.BGP.Remote)
+	}
+	if len(running.BGP.Neighbors) != 1 {
+		t.Fatalf("Neighbors = %+v, want the runtime neighbor", running.BGP.Neighbors)
+	}
+	n := running.BGP.Neighbors[0]
+	if n.PeerIP != "192.0.2.2" || n.ASN != 65003 || n.LocalAS != 64512 || n.MaxPrefixes != 100 {
+		t.Errorf("Neighbors[0] = %+v, want 192.0.2.2 AS65003 local AS64512 max 100", n)
+	}
+	if len(n.Families) != 1 || n.Families[0] != "ipv4-unicast" {
+		t.Errorf("Families = %v, want [ipv4-unicast]", n.Families)
+	}
+}
@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rateMeter estimates the update rate over one-second windows
+type rateMeter struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	count       int     // Updates in the current window
+	last        float64 // Rate measured over the previous window
+	now         func() time.Time
+}
+
+func newRateMeter() *rateMeter {
+	return &rateMeter{now: time.Now}
+}
+
+// mark records one update and returns the current rate in updates/second
+// The rate is the higher of the previous window and the current count, so
+// a burst is recognised within the window it starts in
+func (m *rateMeter) mark() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if elapsed := now.Sub(m.windowStart); elapsed >= time.Second {
+		if elapsed < 2*time.Second {
+			m.last = float64(m.count) / elapsed.Seconds()
+		} else {
+			m.last = 0 // Idle for more than a window
+		}
+		m.windowStart, m.count = now, 0
+	}
+	m.count++
+	return max(m.last, float64(m.count))
+}
+
+// sampler decides which updates a sampled subscriber receives
+type sampler struct {
+	rate      int           // Deliver 1 in rate updates while sampling
+	threshold float64       // Updates/second above which sampling starts
+	seen      atomic.Uint64 // Updates offered while sampling
+}
+
+// keep reports whether an update should be delivered at the given service rate
+func (s *sampler) keep(rate float64) bool {
+	if s == nil || s.rate <= 1 || rate <= s.threshold {
+		return true
+	}
+	return s.seen.Add(1)%uint64(s.rate) == 1
+}
@@ -0,0 +1,34 @@
+package pkg
+
+import "testing"
+
+// TestSubscriberSampling verifies a sampled subscriber gets about 1 in N of a
+// burst while other subscribers and the counters see every update
+func TestSubscriberSampling(t *testing.T) {
+	bgpService := NewBGPService()
+	full, unsubscribeFull := bgpService.Subscribe()
+	defer unsubscribeFull()
+	sampled, unsubscribeSampled := bgpService.SubscribeWithOptions(SubscribeOptions{SampleRate: 10, SampleThreshold: 50})
+	defer unsubscribeSampled()
+
+	const burst = 1000
+	for i := 0; i < burst; i++ {
+		bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+	}
+
+	if got := len(full); got != burst {
+		t.Errorf("unsampled subscriber received %d updates, want %d", got, burst)
+	}
+	// The first 50 pass before the rate crosses the threshold, then 1 in 10 of the rest
+	if got := len(sampled); got < 130 || got > 150 {
+		t.Errorf("sampled subscriber received %d updates, want about 145", got)
+	}
+
+	stats := bgpService.Stats()
+	if stats.Updates != burst {
+		t.Errorf("Stats().Updates = %d, want %d", stats.Updates, burst)
+	}
+	if want := uint64(burst - len(sampled)); stats.SampledUpdates != want {
+		t.Errorf("Stats().SampledUpdates = %d, want %d", stats.SampledUpdates, want)
+	}
+}
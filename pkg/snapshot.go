@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotPrefix and snapshotSuffix frame every snapshot file name
+// The timestamp in between sorts lexically in time order
+const (
+	snapshotPrefix     = "rib-"
+	snapshotSuffix     = ".json"
+	snapshotTimeLayout = "20060102T150405.000Z"
+)
+
+// SnapshotConfig enables periodic dumps of the RIB to JSON files
+// Snapshots are disabled unless both Interval and Dir are set
+type SnapshotConfig struct {
+	Interval time.Duration `yaml:"interval"` // Time between snapshots, e.g. "15m"
+	Dir      string        `yaml:"dir"`      // Directory the snapshot files are written to
+	Retain   int           `yaml:"retain"`   // Newest snapshots kept, 0 keeps all
+}
+
+// WriteSnapshot writes the current RIB to a timestamped JSON file in dir
+// and returns its path; the file holds a JSON array of BGPUpdateMessage
+func (s *BGPService) WriteSnapshot(dir string) (string, error) {
+	paths, err := s.ListPaths()
+	if err != nil {
+		return "", err
+	}
+	if paths == nil {
+		paths = []BGPUpdateMessage{} // An empty RIB is "[]", not "null"
+	}
+	data, err := json.Marshal(paths)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := filepath.Join(dir, snapshotPrefix+time.Now().UTC().Format(snapshotTimeLayout)+snapshotSuffix)
+
+	// Write to a temporary file first so readers never see a partial snapshot
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return name, nil
+}
+
+// pruneSnapshots deletes all but the newest retain snapshots in dir
+func pruneSnapshots(dir string, retain int) error {
+	if retain <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, snapshotPrefix+"*"+snapshotSuffix))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	for len(files) > retain {
+		if err := os.Remove(files[0]); err != nil {
+			return fmt.Errorf("removing old snapshot: %w", err)
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// runSnapshots writes a snapshot every cfg.Interval until ctx is cancelled
+func (s *BGPService) runSnapshots(ctx context.Context, cfg SnapshotConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			name, err := s.WriteSnapshot(cfg.Dir)
+			if err != nil {
+				log.Printf("Error writing RIB snapshot: %v", err)
+				continue
+			}
+			log.Printf("Wrote RIB snapshot %s", name)
+			if err := pruneSnapshots(cfg.Dir, cfg.Retain); err != nil {
+				log.Printf("Error pruning RIB snapshots: %v", err)
+			}
+		}
+	}
+}
+
+// SnapshotDiff is what changed in the RIB between two snapshots
+// A route is a prefix as received from one peer, so the same prefix can
+// be added from one peer and withdrawn from another
+type SnapshotDiff struct {
+	Added     []BGPUpdateMessage // Routes only in the new snapshot
+	Withdrawn []BGPUpdateMessage // Routes only in the old snapshot
+	Changed   []RouteChange      // Routes in both whose attributes differ
+}
+
+// RouteChange holds both versions of a route whose attributes changed
+type RouteChange struct {
+	Old BGPUpdateMessage
+	New BGPUpdateMessage
+}
+
+// ReadSnapshot reads a snapshot file written by WriteSnapshot
+func ReadSnapshot(name string) ([]BGPUpdateMessage, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var paths []BGPUpdateMessage
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %w", name, err)
+	}
+	return paths, nil
+}
+
+// DiffSnapshots compares two snapshots, e.g. from before and after a
+// maintenance window, keeping the order routes have in the snapshots
+// Fields that change without the route changing, such as its age, the
+// sequence number, the time it was listed and whether it was the best
+// path, are not compared
+func DiffSnapshots(old, new []BGPUpdateMessage) SnapshotDiff {
+	before := make(map[string]BGPUpdateMessage, len(old))
+	for _, u := range old {
+		before[snapshotRouteKey(u)] = u
+	}
+
+	var diff SnapshotDiff
+	seen := make(map[string]bool, len(new))
+	for _, u := range new {
+		key := snapshotRouteKey(u)
+		seen[key] = true
+		prev, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, u)
+		case !sameAttributes(prev, u):
+			diff.Changed = append(diff.Changed, RouteChange{Old: prev, New: u})
+		}
+	}
+	for _, u := range old {
+		if !seen[snapshotRouteKey(u)] {
+			diff.Withdrawn = append(diff.Withdrawn, u)
+		}
+	}
+	return diff
+}
+
+// snapshotRouteKey identifies a route across snapshots by its prefixes,
+// route distinguisher, the peer it came from and its ADD-PATH path ID
+func snapshotRouteKey(u BGPUpdateMessage) string {
+	var b strings.Builder
+	for _, n := range u.NLRI {
+		fmt.Fprintf(&b, "%s/%d,", n.Prefix, n.PrefixLength)
+	}
+	fmt.Fprintf(&b, "%s|%s|%d", u.RouteDistinguisher, u.FromPeer, u.PathID)
+	return b.String()
+}
+
+// sameAttributes compares two versions of a route in the JSON form
+// snapshots are stored in, so a route read back from a file equals the
+// live one; the fields DiffSnapshots ignores are cleared first
+func sameAttributes(a, b BGPUpdateMessage) bool {
+	for _, u := range []*BGPUpdateMessage{&a, &b} {
+		u.Sequence = 0
+		u.Timestamp = 0
+		u.ReceivedAt = time.Time{}
+		u.Best, u.BestPathReason = false, ""
+	}
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(aj, bj)
+}
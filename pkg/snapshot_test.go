@@ -0,0 +1,163 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestSnapshotWritten verifies the snapshot timer writes a JSON file holding the RIB
+func TestSnapshotWritten(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{Snapshot: SnapshotConfig{Interval: 20 * time.Millisecond, Dir: dir, Retain: 2}}
+	bgpService := newTestService(t, config)
+	if err := bgpService.AddPath(PathSpec{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254"}); err != nil {
+		t.Fatalf("AddPath error = %v", err)
+	}
+
+	// The first tick may land before AddPath, so wait for a snapshot holding the path
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for a snapshot holding 10.0.0.0/24")
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		files, _ := filepath.Glob(filepath.Join(dir, "rib-*.json"))
+		if len(files) == 0 {
+			continue
+		}
+		data, err := os.ReadFile(files[len(files)-1])
+		if err != nil {
+			continue // Pruned or replaced between Glob and ReadFile
+		}
+		var paths []BGPUpdateMessage
+		if err := json.Unmarshal(data, &paths); err != nil {
+			t.Fatalf("Snapshot is not valid JSON: %v", err)
+		}
+		if len(paths) == 1 && paths[0].NLRI[0].Prefix.String() == "10.0.0.0" {
+			return
+		}
+	}
+}
+
+// TestWriteSnapshotContents verifies a snapshot holds exactly the RIB paths
+func TestWriteSnapshotContents(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+	for _, prefix := range []string{"10.0.0.0/24", "2001:db8::/32"} {
+		nextHop := "192.0.2.254"
+		if prefix == "2001:db8::/32" {
+			nextHop = "2001:db8::1"
+		}
+		if err := bgpService.AddPath(PathSpec{Prefix: prefix, NextHop: nextHop}); err != nil {
+			t.Fatalf("AddPath(%s) error = %v", prefix, err)
+		}
+	}
+
+	name, err := bgpService.WriteSnapshot(t.TempDir())
+	if err != nil {
+		t.Fatalf("WriteSnapshot error = %v", err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	var paths []BGPUpdateMessage
+	if err := json.Unmarshal(data, &paths); err != nil {
+		t.Fatalf("Snapshot is not valid JSON: %v", err)
+	}
+	if len(paths) != 2 {
+		t.Errorf("snapshot holds %d paths, want 2", len(paths))
+	}
+}
+
+// TestPruneSnapshots verifies only the newest snapshots are retained
+func TestPruneSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"rib-20240101T000000.000Z.json", "rib-20240102T000000.000Z.json", "rib-20240103T000000.000Z.json"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("[]"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := pruneSnapshots(dir, 2); err != nil {
+		t.Fatalf("pruneSnapshots error = %v", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "rib-*.json"))
+	if len(files) != 2 || filepath.Base(files[0]) != names[1] {
+		t.Errorf("remaining snapshots = %v, want the newest two", files)
+	}
+}
+
+// TestDiffSnapshots verifies added, withdrawn and changed routes are told
+// apart, and that a snapshot read back from disk equals the one written
+func TestDiffSnapshots(t *testing.T) {
+	withMED := func(u BGPUpdateMessage, med uint32) BGPUpdateMessage {
+		u.MED = &med
+		return u
+	}
+	old := []BGPUpdateMessage{
+		testUpdate("192.0.2.1", "10.0.0.0/24", false),
+		withMED(testUpdate("192.0.2.1", "10.0.1.0/24", false), 10),
+		testUpdate("192.0.2.1", "10.0.2.0/24", false),
+		testUpdate("192.0.2.2", "10.0.2.0/24", false),
+	}
+	unchanged := testUpdate("192.0.2.1", "10.0.2.0/24", false)
+	unchanged.Sequence, unchanged.ReceivedAt = 42, time.Now() // Not attribute changes
+	new := []BGPUpdateMessage{
+		withMED(testUpdate("192.0.2.1", "10.0.1.0/24", false), 20),
+		unchanged,
+		testUpdate("192.0.2.1", "10.0.3.0/24", false),
+		testUpdate("192.0.2.2", "10.0.2.0/24", false),
+	}
+
+	diff := DiffSnapshots(old, new)
+	if len(diff.Added) != 1 || diff.Added[0].NLRI[0].Prefix.String() != "10.0.3.0" {
+		t.Errorf("Added = %+v, want 10.0.3.0/24", diff.Added)
+	}
+	if len(diff.Withdrawn) != 1 || diff.Withdrawn[0].NLRI[0].Prefix.String() != "10.0.0.0" {
+		t.Errorf("Withdrawn = %+v, want 10.0.0.0/24", diff.Withdrawn)
+	}
+	if len(diff.Changed) != 1 || *diff.Changed[0].Old.MED != 10 || *diff.Changed[0].New.MED != 20 {
+		t.Errorf("Changed = %+v, want 10.0.1.0/24 with MED 10 -> 20", diff.Changed)
+	}
+
+	name := filepath.Join(t.TempDir(), "rib.json")
+	data, _ := json.Marshal(new)
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSnapshot(name)
+	if err != nil {
+		t.Fatalf("ReadSnapshot() error = %v", err)
+	}
+	if diff := DiffSnapshots(new, read); len(diff.Added)+len(diff.Withdrawn)+len(diff.Changed) != 0 {
+		t.Errorf("DiffSnapshots(snapshot, read back) = %+v, want no differences", diff)
+	}
+}
+
+// TestDiffSnapshotsAddPath verifies ADD-PATH paths a peer sends for one
+// prefix are compared by path ID rather than as a single route
+func TestDiffSnapshotsAddPath(t *testing.T) {
+	withPath := func(id, med uint32) BGPUpdateMessage {
+		u := testUpdate("192.0.2.1", "10.0.0.0/24", false)
+		u.PathID, u.MED = id, &med
+		return u
+	}
+	old := []BGPUpdateMessage{withPath(1, 10), withPath(2, 20)}
+	new := []BGPUpdateMessage{withPath(1, 10), withPath(3, 20)}
+
+	diff := DiffSnapshots(old, new)
+	if len(diff.Added) != 1 || diff.Added[0].PathID != 3 {
+		t.Errorf("Added = %+v, want path 3", diff.Added)
+	}
+	if len(diff.Withdrawn) != 1 || diff.Withdrawn[0].PathID != 2 {
+		t.Errorf("Withdrawn = %+v, want path 2", diff.Withdrawn)
+	}
+	if len(diff.Changed) != 0 {
+		t.Errorf("Changed = %+v, want none", diff.Changed)
+	}
+}
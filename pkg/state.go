@@ -0,0 +1,83 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// stateVersion identifies the ExportState format; bump it on incompatible changes
+const stateVersion = 1
+
+// serviceState is the document written by ExportState
+type serviceState struct {
+	Version   int              `json:"version"`
+	Neighbors []NeighborConfig `json:"neighbors"`
+	Paths     []PathSpec       `json:"paths"`
+}
+
+// ExportState serializes the configured neighbors, with their resolved
+// settings as RunningConfig reports them, and the locally originated paths
+// to JSON, so ImportState can bring a restarted service back to the same state
+// Paths keep only what PathSpec carries, so an aggregate is restored as a
+// plain route; dynamic neighbors come from the config and are not included
+// The output includes neighbor passwords and must be stored accordingly
+func (s *BGPService) ExportState() ([]byte, error) {
+	running := s.RunningConfig()
+	state := serviceState{Version: stateVersion, Neighbors: running.BGP.Neighbors}
+	if running.BGP.Remote.PeerIP != "" {
+		state.Neighbors = append([]NeighborConfig{running.BGP.Remote}, state.Neighbors...)
+	}
+
+	local, err := s.ListLocalPaths()
+	if err != nil {
+		return nil, fmt.Errorf("listing local paths: %w", err)
+	}
+	for _, update := range local {
+		nextHop := update.NextHop
+		if len(nextHop) == 0 {
+			nextHop = update.MPReachNLRI.NextHop
+		}
+		for _, n := range update.NLRI {
+			bits := net.IPv6len * 8
+			if n.Prefix.To4() != nil {
+				bits = net.IPv4len * 8
+			}
+			prefix := net.IPNet{IP: n.Prefix, Mask: net.CIDRMask(int(n.PrefixLength), bits)}
+			state.Paths = append(state.Paths, PathSpec{
+				Prefix:    prefix.String(),
+				NextHop:   nextHop.String(),
+				LocalPref: update.LocalPref,
+				MED:       update.MED,
+				Prepend:   s.prependCount(prefix.String()),
+			})
+		}
+	}
+	return json.MarshalIndent(state, "", "  ")
+}
+
+// ImportState re-applies a document written by ExportState, typically on a
+// freshly started service: every neighbor is configured, replacing one of
+// the same address, and every path originated again
+// It stops at the first neighbor or path that fails, leaving the ones
+// before it in place
+func (s *BGPService) ImportState(b []byte) error {
+	var state serviceState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return fmt.Errorf("decoding state: %w", err)
+	}
+	if state.Version != stateVersion {
+		return fmt.Errorf("unsupported state version %d, want %d", state.Version, stateVersion)
+	}
+	for _, neighbor := range state.Neighbors {
+		if err := s.UpsertNeighbor(neighbor); err != nil {
+			return fmt.Errorf("restoring neighbor %s: %w", neighbor.PeerIP, err)
+		}
+	}
+	for _, spec := range state.Paths {
+		if err := s.AddPath(spec); err != nil {
+			return fmt.Errorf("restoring path %s: %w", spec.Prefix, err)
+		}
+	}
+	return nil
+}
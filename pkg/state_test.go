@@ -0,0 +1,80 @@
+package pkg
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestExportImportState verifies neighbors and local paths exported from
+// one service are recreated on another
+func TestExportImportState(t *testing.T) {
+	src := newTestService(t, &Config{})
+	holdTime, med := uint64(30), uint32(20)
+	nextHopSelf := true
+	for _, n := range []NeighborConfig{
+		{PeerIP: "192.0.2.1", ASN: 65002},
+		{PeerIP: "192.0.2.2", ASN: 65003, PeerPort: 1179, NeighborTemplate: NeighborTemplate{
+			Families:    []string{"ipv4-unicast", "ipv6-unicast"},
+			HoldTime:    holdTime,
+			NextHopSelf: &nextHopSelf,
+		}},
+	} {
+		if err := src.AddNeighborConfig(n); err != nil {
+			t.Fatalf("AddNeighborConfig(%s) error = %v", n.PeerIP, err)
+		}
+	}
+	for _, spec := range []PathSpec{
+		{Prefix: "10.0.0.0/24", NextHop: "192.0.2.254", MED: &med},
+		{Prefix: "2001:db8::/32", NextHop: "2001:db8::1", Prepend: 2},
+	} {
+		if err := src.AddPath(spec); err != nil {
+			t.Fatalf("AddPath(%s) error = %v", spec.Prefix, err)
+		}
+	}
+
+	state, err := src.ExportState()
+	if err != nil {
+		t.Fatalf("ExportState() error = %v", err)
+	}
+	dst := newTestService(t, &Config{})
+	if err := dst.ImportState(state); err != nil {
+		t.Fatalf("ImportState() error = %v", err)
+	}
+
+	if got, want := dst.RunningConfig().BGP.Neighbors, src.RunningConfig().BGP.Neighbors; !reflect.DeepEqual(got, want) {
+		t.Errorf("imported neighbors = %+v, want %+v", got, want)
+	}
+	if got, want := localPrefixes(t, dst), localPrefixes(t, src); len(want) != 2 || !reflect.DeepEqual(got, want) {
+		t.Errorf("imported local paths = %v, want %v", got, want)
+	}
+	restored, err := dst.LookupPrefix("10.0.0.0/24", false)
+	if err != nil || len(restored) != 1 || restored[0].MED == nil || *restored[0].MED != med {
+		t.Errorf("restored 10.0.0.0/24 = %+v (error %v), want MED %d", restored, err, med)
+	}
+
+	if got := dst.prependCount("2001:db8::/32"); got != 2 {
+		t.Errorf("restored Prepend of 2001:db8::/32 = %d, want 2", got)
+	}
+
+	if err := dst.ImportState([]byte(`{"version": 99}`)); err == nil {
+		t.Error("ImportState() accepted an unknown version")
+	}
+}
+
+// localPrefixes returns the sorted prefixes of s's local paths with their next hops
+func localPrefixes(t *testing.T, s *BGPService) []string {
+	t.Helper()
+	local, err := s.ListLocalPaths()
+	if err != nil {
+		t.Fatalf("ListLocalPaths() error = %v", err)
+	}
+	var prefixes []string
+	for _, u := range local {
+		for _, n := range u.NLRI {
+			prefixes = append(prefixes, n.Prefix.String()+" via "+u.NextHop.String()+u.MPReachNLRI.NextHop.String())
+		}
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
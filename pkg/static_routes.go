@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// parseStaticRoutes reads one route per line as "CIDR [next-hop]"
+// Blank lines and lines starting with # are ignored; a missing next hop
+// falls back to bgp.local.defaultNextHop when the route is added
+// Routes are keyed by their canonical prefix, so 10.0.0.1/24 and
+// 10.0.0.0/24 are the same route
+func parseStaticRoutes(r io.Reader) (map[string]PathSpec, error) {
+	routes := make(map[string]PathSpec)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: want \"CIDR [next-hop]\", got %d fields", line, len(fields))
+		}
+
+		_, ipNet, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w %q", line, ErrInvalidPrefix, fields[0])
+		}
+		spec := PathSpec{Prefix: ipNet.String()}
+		if len(fields) == 2 {
+			if net.ParseIP(fields[1]) == nil {
+				return nil, fmt.Errorf("line %d: invalid next hop %q", line, fields[1])
+			}
+			spec.NextHop = fields[1]
+		}
+		if _, dup := routes[spec.Prefix]; dup {
+			return nil, fmt.Errorf("line %d: duplicate prefix %s", line, spec.Prefix)
+		}
+		routes[spec.Prefix] = spec
+	}
+	return routes, scanner.Err()
+}
+
+// LoadStaticRoutes originates the routes listed in bgp.staticRoutesFile
+// Calling it again, e.g. on SIGHUP, reconciles the RIB with the file:
+// new routes are added, changed next hops replaced and routes no longer
+// listed withdrawn; a file that fails to parse changes nothing
+// While bgp.advertisementDelay holds the routes back the file is only
+// validated, as it is read again when the delay ends
+func (s *BGPService) LoadStaticRoutes() error {
+	routes, err := s.readStaticRoutes()
+	if err != nil || routes == nil {
+		return err
+	}
+	s.staticMu.Lock()
+	defer s.staticMu.Unlock()
+	if s.staticHeld {
+		return nil
+	}
+	return s.applyStaticRoutes(routes)
+}
+
+// readStaticRoutes parses bgp.staticRoutesFile, returning nil routes when
+// none is configured
+func (s *BGPService) readStaticRoutes() (map[string]PathSpec, error) {
+	filename := s.config.BGP.StaticRoutesFile
+	if filename == "" {
+		return nil, nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	routes, err := parseStaticRoutes(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return routes, nil
+}
+
+// applyStaticRoutes reconciles the RIB with routes; s.staticMu must be held
+// When a route cannot be added or withdrawn, the changes already made are
+// rolled back so the RIB keeps the routes originated before
+func (s *BGPService) applyStaticRoutes(routes map[string]PathSpec) (err error) {
+	previous := maps.Clone(s.staticRoutes)
+	defer func() {
+		if err != nil {
+			s.restoreStaticRoutes(previous)
+		}
+	}()
+	for prefix, spec := range routes {
+		if old, ok := s.staticRoutes[prefix]; ok && old == spec {
+			continue
+		}
+		if err := s.AddPath(spec); err != nil {
+			return fmt.Errorf("static route %s: %w", prefix, err)
+		}
+		s.staticRoutes[prefix] = spec
+	}
+	for prefix := range s.staticRoutes {
+		if _, ok := routes[prefix]; ok {
+			continue
+		}
+		if err := s.DeletePath(prefix); err != nil {
+			return fmt.Errorf("withdrawing static route %s: %w", prefix, err)
+		}
+		delete(s.staticRoutes, prefix)
+	}
+	log.Printf("Originating %d static routes from %s", len(s.staticRoutes), s.config.BGP.StaticRoutesFile)
+	return nil
+}
+
+// restoreStaticRoutes brings the static routes back to previous after a
+// failed reload, logging what cannot be restored; s.staticMu must be held
+func (s *BGPService) restoreStaticRoutes(previous map[string]PathSpec) {
+	for prefix := range s.staticRoutes {
+		if _, ok := previous[prefix]; ok {
+			continue
+		}
+		if err := s.DeletePath(prefix); err != nil {
+			log.Printf("Error rolling back static route %s: %v", prefix, err)
+		}
+	}
+	for prefix, spec := range previous {
+		if current, ok := s.staticRoutes[prefix]; ok && current == spec {
+			continue
+		}
+		if err := s.AddPath(spec); err != nil {
+			log.Printf("Error rolling back static route %s: %v", prefix, err)
+		}
+	}
+	s.staticRoutes = previous
+}
+
+// holdStaticRoutes originates the static routes once established is closed
+// by a neighbor coming up or delay has passed, whichever comes first
+// It gives up when ctx is cancelled, by Stop or WithdrawAllLocal
+func (s *BGPService) holdStaticRoutes(ctx context.Context, delay time.Duration, established <-chan struct{}) {
+	log.Printf("Holding static routes for up to %v until a neighbor is established", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+		log.Printf("Advertisement delay of %v elapsed", delay)
+	case <-established:
+		log.Printf("A neighbor is established, ending the advertisement delay")
+	}
+
+	routes, err := s.readStaticRoutes()
+	s.staticMu.Lock()
+	defer s.staticMu.Unlock()
+	// Checked under the lock, so a WithdrawAllLocal racing the end of the
+	// delay is not undone
+	if ctx.Err() != nil {
+		return
+	}
+	s.staticHeld, s.staticHold = false, nil
+	if err == nil {
+		err = s.applyStaticRoutes(routes)
+	}
+	if err != nil {
+		log.Printf("Error loading static routes: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"errors"
+	api "github.com/osrg/gobgp/v3/api"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStaticRoutesOnStart verifies routes in the static routes file are originated by Start
+func TestStaticRoutesOnStart(t *testing.T) {
+	config := &Config{}
+	config.BGP.StaticRoutesFile = writeFile(t, t.TempDir(), "static.routes", `
+# Blackholes and anycast
+192.0.2.0/24     198.51.100.1
+2001:db8:ff::/48 2001:db8::1
+`)
+	bgpService := newTestService(t, config)
+
+	if got := findPath(t, bgpService, "192.0.2.0/24").NextHop.String(); got != "198.51.100.1" {
+		t.Errorf("192.0.2.0/24 next hop = %s, want 198.51.100.1", got)
+	}
+	findPath(t, bgpService, "2001:db8:ff::/48")
+}
+
+// TestStaticRoutesReload verifies a reload adds new routes and withdraws removed ones
+func TestStaticRoutesReload(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{}
+	config.BGP.StaticRoutesFile = writeFile(t, dir, "static.routes", "10.0.0.0/24 192.0.2.1\n10.0.1.0/24 192.0.2.1\n")
+	bgpService := newTestService(t, config)
+
+	writeFile(t, dir, "static.routes", "10.0.1.0/24 192.0.2.1\n10.0.2.0/24 192.0.2.1\n")
+	if err := bgpService.LoadStaticRoutes(); err != nil {
+		t.Fatalf("LoadStaticRoutes() error = %v", err)
+	}
+
+	paths, err := bgpService.ListPaths()
+	if err != nil {
+		t.Fatalf("ListPaths() error = %v", err)
+	}
+	var got []string
+	for _, p := range paths {
+		for _, n := range p.NLRI {
+			got = append(got, n.Prefix.String())
+		}
+	}
+	sort.Strings(got)
+	if strings.Join(got, " ") != "10.0.1.0 10.0.2.0" {
+		t.Errorf("originated prefixes = %v, want 10.0.1.0 and 10.0.2.0", got)
+	}
+
+	// A broken file leaves the current routes alone
+	writeFile(t, dir, "static.routes", "10.0.3.0/33\n")
+	if err := bgpService.LoadStaticRoutes(); !errors.Is(err, ErrInvalidPrefix) {
+		t.Errorf("LoadStaticRoutes() error = %v, want %v", err, ErrInvalidPrefix)
+	}
+	findPath(t, bgpService, "10.0.2.0/24")
+
+	// So does a route GoBGP refuses: IPv4 needs a next hop and there is no
+	// default, whatever was changed before it is rolled back
+	writeFile(t, dir, "static.routes", "10.0.1.0/24 192.0.2.9\n10.0.4.0/24 192.0.2.1\n10.0.5.0/24\n")
+	if err := bgpService.LoadStaticRoutes(); err == nil {
+		t.Fatal("LoadStaticRoutes() succeeded with a route lacking a next hop")
+	}
+	got = nil
+	paths, _ = bgpService.ListPaths()
+	for _, p := range paths {
+		got = append(got, p.NLRI[0].Prefix.String()+" via "+p.NextHop.String())
+	}
+	sort.Strings(got)
+	if want := "10.0.1.0 via 192.0.2.1, 10.0.2.0 via 192.0.2.1"; strings.Join(got, ", ") != want {
+		t.Errorf("routes after a failed reload = %v, want %s", got, want)
+	}
+}
+
+// TestStaticRoutesStartFailure verifies Start refuses a broken file before
+// GoBGP starts, and stops GoBGP again when a route cannot be originated
+func TestStaticRoutesStartFailure(t *testing.T) {
+	for _, tt := range []struct {
+		name, routes string
+		started      bool
+	}{
+		{"invalid file", "10.0.0.0/33\n", false},
+		{"route refused", "10.0.0.0/24\n", true}, // No next hop and no default
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{}
+			config.BGP.StaticRoutesFile = writeFile(t, t.TempDir(), "static.routes", tt.routes)
+			fake := newFakeBgpServer()
+			bgpService := NewBGPServiceWithServer(config, fake)
+			if err := bgpService.Start("192.0.2.254", 65001); err == nil {
+				t.Fatal("Start() succeeded")
+			}
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if started := fake.started != nil; started != tt.started {
+				t.Errorf("GoBGP started = %v, want %v", started, tt.started)
+			}
+			if tt.started && !fake.stopped {
+				t.Error("GoBGP left running after Start failed")
+			}
+		})
+	}
+}
+
+// TestAdvertisementDelay verifies static routes are held after Start until
+// the delay elapses or, with a long delay, until a neighbor is established,
+// while the file is still validated by Start and WithdrawAllLocal ends the hold
+func TestAdvertisementDelay(t *testing.T) {
+	pathCount := func(fake *fakeBgpServer) int {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return len(fake.paths)
+	}
+	waitForPaths := func(t *testing.T, fake *fakeBgpServer) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); pathCount(fake) == 0; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("static route was never originated")
+			}
+		}
+	}
+	newDelayed := func(t *testing.T, delay time.Duration) (*BGPService, *fakeBgpServer) {
+		config := &Config{}
+		config.BGP.StaticRoutesFile = writeFile(t, t.TempDir(), "static.routes", "10.0.0.0/24 192.0.2.1\n")
+		config.BGP.AdvertisementDelay = delay
+		return newFakeService(t, config)
+	}
+
+	t.Run("delay elapses", func(t *testing.T) {
+		start := time.Now()
+		_, fake := newDelayed(t, 50*time.Millisecond)
+		if n := pathCount(fake); n != 0 {
+			t.Fatalf("%d paths originated by Start, want none during the delay", n)
+		}
+		waitForPaths(t, fake)
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("static route originated after %v, before the 50ms delay", elapsed)
+		}
+	})
+
+	t.Run("peer establishes", func(t *testing.T) {
+		bgpService, fake := newDelayed(t, time.Hour)
+		// A reload during the delay must not advertise early either
+		if err := bgpService.LoadStaticRoutes(); err != nil {
+			t.Fatalf("LoadStaticRoutes() error = %v", err)
+		}
+		fake.waitForPeerWatch(t)
+		fake.emitPeerState("192.0.2.1", api.PeerState_ACTIVE)
+		time.Sleep(10 * time.Millisecond)
+		if n := pathCount(fake); n != 0 {
+			t.Fatalf("%d paths originated before any neighbor was established", n)
+		}
+		fake.emitPeerState("192.0.2.1", api.PeerState_ESTABLISHED)
+		waitForPaths(t, fake)
+	})
+
+	t.Run("restarted", func(t *testing.T) {
+		held := func(bgpService *BGPService) bool {
+			bgpService.staticMu.Lock()
+			defer bgpService.staticMu.Unlock()
+			return bgpService.staticHeld
+		}
+		bgpService, fake := newDelayed(t, time.Hour)
+		fake.waitForPeerWatch(t)
+		fake.emitPeerState("192.0.2.1", api.PeerState_ESTABLISHED)
+		waitForPaths(t, fake)
+
+		bgpService.Stop()
+		if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+			t.Fatalf("Start() after Stop error = %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if !held(bgpService) {
+			t.Fatal("static routes released by the restart, before any neighbor was established")
+		}
+		fake.emitPeerState("192.0.2.1", api.PeerState_ESTABLISHED)
+		for deadline := time.Now().Add(time.Second); held(bgpService); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("static routes still held after a neighbor was established")
+			}
+		}
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		config := &Config{}
+		config.BGP.StaticRoutesFile = writeFile(t, t.TempDir(), "static.routes", "10.0.0.0/33\n")
+		config.BGP.AdvertisementDelay = time.Hour
+		bgpService := NewBGPServiceWithServer(config, newFakeBgpServer())
+		t.Cleanup(bgpService.Stop)
+		if err := bgpService.Start("192.0.2.254", 65001); !errors.Is(err, ErrInvalidPrefix) {
+			t.Errorf("Start() error = %v, want %v during the delay", err, ErrInvalidPrefix)
+		}
+	})
+
+	t.Run("withdrawn during delay", func(t *testing.T) {
+		bgpService, fake := newDelayed(t, 50*time.Millisecond)
+		if err := bgpService.WithdrawAllLocal(); err != nil {
+			t.Fatalf("WithdrawAllLocal() error = %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if n := pathCount(fake); n != 0 {
+			t.Fatalf("%d paths originated after WithdrawAllLocal cancelled the delay", n)
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// subscriberBuffer is the default channel capacity given to each subscriber
+const subscriberBuffer = 1024
+
+// DropPolicy chooses which update a full subscriber queue loses
+type DropPolicy string
+
+const (
+	DropNewest DropPolicy = "drop-newest" // Keep the queue, discard the incoming update
+	DropOldest DropPolicy = "drop-oldest" // Discard the oldest queued update to make room
+)
+
+// validateDropPolicy rejects policies other than the known ones or empty
+func validateDropPolicy(policy DropPolicy) error {
+	switch policy {
+	case "", DropNewest, DropOldest:
+		return nil
+	}
+	return fmt.Errorf("unknown subscriber drop policy %q, want %s or %s", policy, DropNewest, DropOldest)
+}
+
+// subscriber is a single channel consumer registered through Subscribe
+type subscriber struct {
+	ch         chan BGPUpdateMessage
+	filter     UpdateFilter
+	sampler    *sampler   // nil delivers every update
+	dropPolicy DropPolicy // Applied when ch is full
+}
+
+// SubscribeOptions tunes what a single subscriber receives
+type SubscribeOptions struct {
+	// SampleRate, when above 1, delivers only 1 in SampleRate updates while
+	// the service-wide update rate exceeds SampleThreshold updates/second
+	// Service counters keep counting every update regardless
+	SampleRate      int
+	SampleThreshold float64
+
+	// QueueSize bounds the updates waiting for this subscriber and DropPolicy
+	// picks what is lost when it overflows; zero values fall back to the
+	// subscribers section of the config, then to 1024 and drop-newest
+	QueueSize  int
+	DropPolicy DropPolicy
+
+	// Filter limits the subscriber to matching updates, e.g. only IPv6
+	// RPKI invalids, while other subscribers keep receiving everything
+	// It is matched before output.fields trims the update
+	Filter UpdateFilter
+}
+
+// Subscribe registers a new consumer of parsed updates and returns its
+// channel together with a function that unsubscribes and closes it
+// All subscribers share a single GoBGP watch, started on the first
+// subscription and stopped again when the last one unsubscribes
+// A subscriber whose queue fills up misses updates rather than stalling the
+// others; every miss is counted in Stats.DroppedUpdates
+func (s *BGPService) Subscribe() (<-chan BGPUpdateMessage, func()) {
+	return s.SubscribeWithOptions(SubscribeOptions{})
+}
+
+// SubscribeWithOptions is Subscribe with per-subscriber options such as
+// sampling, so a slow dashboard can be thinned without affecting others
+func (s *BGPService) SubscribeWithOptions(opts SubscribeOptions) (<-chan BGPUpdateMessage, func()) {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = s.config.Subscribers.QueueSize
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = subscriberBuffer
+	}
+	if opts.DropPolicy == "" {
+		opts.DropPolicy = s.config.Subscribers.DropPolicy
+	}
+	if opts.DropPolicy == "" {
+		opts.DropPolicy = DropNewest
+	}
+	sub := &subscriber{
+		ch:         make(chan BGPUpdateMessage, opts.QueueSize),
+		filter:     opts.Filter,
+		dropPolicy: opts.DropPolicy,
+	}
+	if opts.SampleRate > 1 {
+		sub.sampler = &sampler{rate: opts.SampleRate, threshold: opts.SampleThreshold}
+	}
+
+	// The gauge is set under s.mu so concurrent changes cannot store a stale count
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	count := len(s.subscribers)
+	s.metrics.subscribers.Set(float64(count))
+	s.mu.Unlock()
+	s.debugf("Subscriber added, %d active", count)
+	s.acquireWatch()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.subscribers, sub)
+			close(sub.ch)
+			count := len(s.subscribers)
+			s.metrics.subscribers.Set(float64(count))
+			s.mu.Unlock()
+			s.debugf("Subscriber removed, %d active", count)
+			s.releaseWatch()
+		})
+	}
+	return sub.ch, unsubscribe
+}
+
+// publish fans an update out to every subscriber without blocking
+// Filters match the whole update, while subscribers receive selected, the
+// update trimmed to output.fields
+func (s *BGPService) publish(update, selected BGPUpdateMessage) {
+	// Holding the read lock keeps unsubscribe from closing a channel mid-send
+	rate := s.updateRate.mark()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for sub := range s.subscribers {
+		if !sub.filter.match(update) {
+			continue
+		}
+		if !sub.sampler.keep(rate) {
+			s.metrics.sampled.Add(1)
+			continue
+		}
+		select {
+		case sub.ch <- selected:
+			continue
+		default:
+		}
+
+		s.metrics.dropped.Add(1)
+		if sub.dropPolicy == DropOldest {
+			// The subscriber may drain the queue in between, so neither step blocks
+			select {
+			case <-sub.ch:
+			default:
+			}
+			select {
+			case sub.ch <- selected:
+			default:
+			}
+			continue
+		}
+		log.Printf("Subscriber is %d updates behind, dropping update from %s", cap(sub.ch), update.FromPeer)
+	}
+}
+
+// acquireWatch registers interest in the shared watch, starting it if needed
+func (s *BGPService) acquireWatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchRefs++
+	if s.watchRefs == 1 && s.runCtx != nil {
+		s.startWatchLocked()
+	}
+}
+
+// releaseWatch drops interest in the shared watch, stopping it with the last reference
+func (s *BGPService) releaseWatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watchRefs--
+	if s.watchRefs == 0 && s.watchCancel != nil {
+		s.watchCancel()
+		s.watchCancel = nil
+	}
+}
+
+// startWatchLocked launches the shared watch; s.mu must be held and the service started
+func (s *BGPService) startWatchLocked() {
+	var ctx context.Context
+	ctx, s.watchCancel = context.WithCancel(s.runCtx)
+	s.watchStarts++
+	go s.runWatch(ctx)
+}
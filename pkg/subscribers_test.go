@@ -0,0 +1,199 @@
+package pkg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// receive waits briefly for one update on ch
+func receive(t *testing.T, ch <-chan BGPUpdateMessage) BGPUpdateMessage {
+	t.Helper()
+	select {
+	case update := <-ch:
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for update")
+		return BGPUpdateMessage{}
+	}
+}
+
+// TestSubscribeFanOut verifies two subscribers share one watch and both receive an update
+func TestSubscribeFanOut(t *testing.T) {
+	bgpService := newTestService(t, &Config{})
+
+	first, unsubscribeFirst := bgpService.Subscribe()
+	second, unsubscribeSecond := bgpService.Subscribe()
+
+	bgpService.dispatch(BGPUpdateMessage{FromPeer: "192.0.2.1"})
+
+	if got := receive(t, first); got.FromPeer != "192.0.2.1" {
+		t.Errorf("first subscriber got update from %q", got.FromPeer)
+	}
+	if got := receive(t, second); got.FromPeer != "192.0.2.1" {
+		t.Errorf("second subscriber got update from %q", got.FromPeer)
+	}
+
+	bgpService.mu.RLock()
+	starts, running := bgpService.watchStarts, bgpService.watchCancel != nil
+	bgpService.mu.RUnlock()
+	if starts != 1 || !running {
+		t.Errorf("watch started %d times (running=%v), want exactly one running watch", starts, running)
+	}
+
+	unsubscribeFirst()
+	unsubscribeSecond()
+	// Unsubscribing twice must be harmless
+	unsubscribeSecond()
+
+	bgpService.mu.RLock()
+	running = bgpService.watchCancel != nil
+	bgpService.mu.RUnlock()
+	if running {
+		t.Error("watch still running after the last subscriber left")
+	}
+	if _, ok := <-first; ok {
+		t.Error("channel should be closed after unsubscribe")
+	}
+}
+
+// TestSubscribeBeforeStart verifies an early subscriber gets the watch once the service starts
+func TestSubscribeBeforeStart(t *testing.T) {
+	bgpService := NewBGPServiceWithConfig(&Config{})
+	bgpService.listenPort = -1
+
+	_, unsubscribe := bgpService.Subscribe()
+	defer unsubscribe()
+
+	if err := bgpService.Start("192.0.2.254", 65001); err != nil {
+		t.Fatalf("Failed to start BGP service: %v", err)
+	}
+	defer bgpService.Stop()
+
+	bgpService.mu.RLock()
+	defer bgpService.mu.RUnlock()
+	if bgpService.watchStarts != 1 {
+		t.Errorf("watch started %d times, want 1", bgpService.watchStarts)
+	}
+}
+
+// TestSubscribeQueueOverflow verifies a full queue counts drops and keeps the update the policy chooses
+func TestSubscribeQueueOverflow(t *testing.T) {
+	tests := []struct {
+		policy DropPolicy
+		want   []string // Peers left in the queue, oldest first
+	}{
+		{policy: DropNewest, want: []string{"192.0.2.1", "192.0.2.2"}},
+		{policy: DropOldest, want: []string{"192.0.2.3", "192.0.2.4"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			bgpService := newTestService(t, &Config{})
+			updates, unsubscribe := bgpService.SubscribeWithOptions(SubscribeOptions{QueueSize: 2, DropPolicy: tt.policy})
+			defer unsubscribe()
+
+			for _, peer := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"} {
+				bgpService.dispatch(BGPUpdateMessage{FromPeer: peer})
+			}
+
+			if got := bgpService.Stats().DroppedUpdates; got != 2 {
+				t.Errorf("DroppedUpdates = %d, want 2", got)
+			}
+			for _, want := range tt.want {
+				if got := receive(t, updates); got.FromPeer != want {
+					t.Errorf("received update from %q, want %q", got.FromPeer, want)
+				}
+			}
+		})
+	}
+}
+
+// TestSubscribeFilters verifies each subscriber receives only the updates
+// matching its own filter out of one batch
+func TestSubscribeFilters(t *testing.T) {
+	bgpService := NewBGPService()
+	invalid, valid := "invalid", "valid"
+	tagged := func(peer, prefix string, state *string, communities ...string) BGPUpdateMessage {
+		u := testUpdate(peer, prefix, false)
+		u.RPKIValidationState = state
+		u.CommunityStrings = communities
+		return u
+	}
+
+	v6Invalids, unsubscribeV6 := bgpService.SubscribeWithOptions(SubscribeOptions{
+		Filter: UpdateFilter{RPKIStates: []string{"invalid"}, Families: []string{"ipv6-unicast"}},
+	})
+	defer unsubscribeV6()
+	filter, err := ParseUpdateFilter([]string{"10.0.0.0/8"}, []string{"65000:100"}, nil, nil)
+	if err != nil {
+		t.Fatalf("ParseUpdateFilter() error = %v", err)
+	}
+	tagged10, unsubscribeTagged := bgpService.SubscribeWithOptions(SubscribeOptions{Filter: filter})
+	defer unsubscribeTagged()
+	everything, unsubscribeAll := bgpService.Subscribe()
+	defer unsubscribeAll()
+
+	batch := []BGPUpdateMessage{
+		tagged("192.0.2.1", "2001:db8::/32", &invalid),
+		tagged("192.0.2.2", "2001:db8:1::/48", &valid),
+		tagged("192.0.2.3", "10.1.0.0/16", &invalid, "65000:100"),
+		tagged("192.0.2.4", "10.2.0.0/16", nil, "65000:200"),
+		tagged("192.0.2.5", "192.0.2.0/24", nil, "65000:100"),
+	}
+	for _, u := range batch {
+		bgpService.dispatch(u)
+	}
+
+	drain := func(ch <-chan BGPUpdateMessage) []string {
+		var peers []string
+		for {
+			select {
+			case u := <-ch:
+				peers = append(peers, u.FromPeer)
+			default:
+				return peers
+			}
+		}
+	}
+	if got := drain(v6Invalids); !reflect.DeepEqual(got, []string{"192.0.2.1"}) {
+		t.Errorf("IPv6 invalids subscriber got updates from %v, want only 192.0.2.1", got)
+	}
+	if got := drain(tagged10); !reflect.DeepEqual(got, []string{"192.0.2.3"}) {
+		t.Errorf("10/8 with 65000:100 subscriber got updates from %v, want only 192.0.2.3", got)
+	}
+	if got := drain(everything); len(got) != len(batch) {
+		t.Errorf("unfiltered subscriber got %d updates, want %d", len(got), len(batch))
+	}
+
+	if _, err := ParseUpdateFilter([]string{"10.0.0.0/33"}, nil, nil, nil); !errors.Is(err, ErrInvalidPrefix) {
+		t.Errorf("ParseUpdateFilter(10.0.0.0/33) error = %v, want ErrInvalidPrefix", err)
+	}
+	if _, err := ParseUpdateFilter(nil, nil, []string{"notfound"}, nil); err == nil {
+		t.Error("ParseUpdateFilter() accepted RPKI state notfound")
+	}
+	if _, err := ParseUpdateFilter(nil, nil, nil, []string{"ipv4"}); err == nil {
+		t.Error("ParseUpdateFilter() accepted family ipv4")
+	}
+}
+
+// TestSubscribeFilterWithdrawals verifies withdrawals, which carry no
+// attributes, reach subscribers filtering on RPKI state or communities as
+// long as their prefixes and families match
+func TestSubscribeFilterWithdrawals(t *testing.T) {
+	bgpService := NewBGPService()
+	updates, unsubscribe := bgpService.SubscribeWithOptions(SubscribeOptions{
+		Filter: UpdateFilter{
+			RPKIStates:  []string{"invalid"},
+			Communities: []string{"65000:100"},
+			Families:    []string{"ipv4-unicast"},
+		},
+	})
+	defer unsubscribe()
+
+	bgpService.dispatch(testUpdate("192.0.2.1", "2001:db8::/32", true))
+	bgpService.dispatch(testUpdate("192.0.2.2", "10.1.0.0/16", true))
+	if got := <-updates; got.FromPeer != "192.0.2.2" || len(updates) != 0 {
+		t.Errorf("received withdrawal from %s with %d queued, want only the IPv4 one", got.FromPeer, len(updates))
+	}
+}
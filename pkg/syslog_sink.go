@@ -0,0 +1,242 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults applied to syslog settings left empty
+const (
+	defaultSyslogNetwork  = "udp"
+	defaultSyslogFacility = 16 // local0
+	defaultSyslogAppName  = "bgpdash"
+	defaultSyslogQueue    = 1000
+	syslogSeverityInfo    = 6
+
+	// syslogTimeout bounds each dial and write, so a dead collector costs
+	// the writer goroutine seconds, never the dispatch path
+	syslogTimeout = 5 * time.Second
+)
+
+// syslogTimeLayout is RFC 5424's TIMESTAMP, whose TIME-SECFRAC takes at
+// most 6 digits, unlike time.RFC3339Nano
+const syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// syslogSDID names the structured-data element carrying the update; the
+// enterprise number is the one RFC 5612 reserves for documentation
+const syslogSDID = "update@32473"
+
+// SyslogConfig selects the endpoint the syslog sink sends updates to
+// The sink is disabled when Address is empty
+type SyslogConfig struct {
+	Address  string `yaml:"address"`  // e.g. "siem.example.net:514"
+	Network  string `yaml:"network"`  // udp (default) or tcp
+	Facility int    `yaml:"facility"` // 0-23, local0 (16) when 0
+	AppName  string `yaml:"appName"`  // APP-NAME of each message, "bgpdash" when empty
+	Hostname string `yaml:"hostname"` // HOSTNAME of each message, the local host name when empty
+	Queue    int    `yaml:"queue"`    // Messages waiting to be sent, 1000 when 0; more are dropped
+}
+
+// SyslogSink is an UpdateHandler that sends each update as an RFC 5424
+// message whose structured data holds the prefixes, peer and withdraw flag
+// and whose MSG is the update as JSON
+// Over UDP each message is one datagram (RFC 5426); over TCP messages are
+// framed by octet counting (RFC 6587)
+// Messages are queued and sent by a single goroutine; while the collector
+// is slow or down the queue fills and further updates are dropped and
+// counted rather than holding up dispatch
+type SyslogSink struct {
+	cfg     SyslogConfig
+	conn    net.Conn // Only used by the writer goroutine, nil until redialed
+	dropped atomic.Uint64
+
+	mu     sync.RWMutex // Guards closed against sends on the closed queue
+	closed bool
+	queue  chan []byte
+	done   chan struct{} // Closed when the writer goroutine has drained the queue
+}
+
+// NewSyslogSink returns a SyslogSink sending to cfg.Address
+// The endpoint is dialed here so a bad address fails at startup
+func NewSyslogSink(cfg SyslogConfig) (*SyslogSink, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("syslog: address is required")
+	}
+	if cfg.Network == "" {
+		cfg.Network = defaultSyslogNetwork
+	}
+	if cfg.Network != "udp" && cfg.Network != "tcp" {
+		return nil, fmt.Errorf("syslog: invalid network %q, expected udp or tcp", cfg.Network)
+	}
+	if cfg.Facility == 0 {
+		cfg.Facility = defaultSyslogFacility
+	}
+	if cfg.Facility < 0 || cfg.Facility > 23 {
+		return nil, fmt.Errorf("syslog: facility %d out of range 0-23", cfg.Facility)
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = defaultSyslogAppName
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+
+	if cfg.Queue <= 0 {
+		cfg.Queue = defaultSyslogQueue
+	}
+
+	s := &SyslogSink{cfg: cfg, queue: make(chan []byte, cfg.Queue), done: make(chan struct{})}
+	if err := s.dial(); err != nil {
+		return nil, fmt.Errorf("syslog: %w", err)
+	}
+	go s.run()
+	return s, nil
+}
+
+// dial connects to the endpoint; only the writer goroutine calls it once started
+func (s *SyslogSink) dial() error {
+	conn, err := net.DialTimeout(s.cfg.Network, s.cfg.Address, syslogTimeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+// HandleUpdate queues the update without waiting for the collector; when
+// the queue is full the update is dropped and counted
+func (s *SyslogSink) HandleUpdate(update BGPUpdateMessage) {
+	msg, err := s.format(update)
+	if err != nil {
+		log.Printf("Error encoding update for syslog: %v", err)
+		return
+	}
+	if s.cfg.Network == "tcp" {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		// Logged on the first drop and then every 1000th so a dead
+		// collector does not flood the log
+		if n := s.dropped.Add(1); n%1000 == 1 {
+			log.Printf("Syslog queue full, %d updates dropped so far", n)
+		}
+	}
+}
+
+// Dropped returns the number of updates dropped because the queue was full
+// or the collector could not be reached
+func (s *SyslogSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// run sends queued messages until the queue is closed
+func (s *SyslogSink) run() {
+	defer close(s.done)
+	for msg := range s.queue {
+		s.send(msg)
+	}
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// send writes msg, redialing once if the connection failed
+// A message that still cannot be sent is logged and dropped
+func (s *SyslogSink) send(msg []byte) {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if err = s.dial(); err != nil {
+				continue
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout))
+		if _, err = s.conn.Write(msg); err == nil {
+			return
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	s.dropped.Add(1)
+	log.Printf("Dropping update, cannot send to syslog at %s: %v", s.cfg.Address, err)
+}
+
+// format renders update as an RFC 5424 message without transport framing
+func (s *SyslogSink) format(update BGPUpdateMessage) ([]byte, error) {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+	at := update.ReceivedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d - [%s", s.cfg.Facility*8+syslogSeverityInfo,
+		at.UTC().Format(syslogTimeLayout), syslogHeaderField(s.cfg.Hostname, 255),
+		syslogHeaderField(s.cfg.AppName, 48), os.Getpid(), syslogSDID)
+	for _, prefix := range updatePrefixes(update) {
+		writeSDParam(&b, "prefix", prefix.String())
+	}
+	writeSDParam(&b, "peer", update.FromPeer)
+	writeSDParam(&b, "withdraw", strconv.FormatBool(update.IsWithdraw))
+	b.WriteString("] ")
+	b.Write(body)
+	return []byte(b.String()), nil
+}
+
+// syslogHeaderField makes v a valid header field: printable ASCII without
+// spaces, at most max characters, and "-" when empty
+func syslogHeaderField(v string, max int) string {
+	v = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, v)
+	if len(v) > max {
+		v = v[:max]
+	}
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// writeSDParam appends name="value", escaping the characters RFC 5424
+// reserves inside a parameter value
+func writeSDParam(b *strings.Builder, name, value string) {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+	fmt.Fprintf(b, ` %s="%s"`, name, value)
+}
+
+// Close sends the messages still queued and closes the connection; later
+// updates are dropped
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
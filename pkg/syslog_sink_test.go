@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syslogMessage matches the RFC 5424 grammar as the sink fills it in: PRI,
+// VERSION, TIMESTAMP with at most 6 fractional digits, HOSTNAME, APP-NAME,
+// PROCID, no MSGID, one SD-ELEMENT and the update as MSG
+var syslogMessage = regexp.MustCompile(`^<134>1 ` +
+	`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d{1,6})?(?:Z|[+-]\d{2}:\d{2}) ` +
+	`router1 bgpdash \d{1,128} - \[update@32473 (.*)\] (\{.*\})$`)
+
+// checkSyslogMessage verifies msg carries the withdrawal of 10.1.0.0/16 from 192.0.2.1
+func checkSyslogMessage(t *testing.T, msg string) {
+	t.Helper()
+	m := syslogMessage.FindStringSubmatch(msg)
+	if m == nil {
+		t.Fatalf("message %q is not a framed RFC 5424 message", msg)
+	}
+	if want := `prefix="10.1.0.0/16" peer="192.0.2.1" withdraw="true"`; m[1] != want {
+		t.Errorf("structured data = %q, want %q", m[1], want)
+	}
+	var update BGPUpdateMessage
+	if err := json.Unmarshal([]byte(m[2]), &update); err != nil || update.FromPeer != "192.0.2.1" {
+		t.Errorf("MSG = %s, want the update as JSON (%v)", m[2], err)
+	}
+}
+
+// syslogTestUpdate is the withdrawal checkSyslogMessage expects, received
+// at a time with nanoseconds RFC 5424 cannot carry
+func syslogTestUpdate() BGPUpdateMessage {
+	update := testUpdate("192.0.2.1", "10.1.0.0/16", true)
+	update.ReceivedAt = time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	return update
+}
+
+// TestSyslogSinkUDP verifies an injected update arrives as one datagram
+func TestSyslogSinkUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Address: conn.LocalAddr().String(), Hostname: "router1"})
+	if err != nil {
+		t.Fatalf("NewSyslogSink() error = %v", err)
+	}
+	defer sink.Close()
+	bgpService := NewBGPService()
+	bgpService.AddUpdateHandler(sink)
+	bgpService.dispatch(syslogTestUpdate())
+
+	buf := make([]byte, 64*1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("no datagram received: %v", err)
+	}
+	checkSyslogMessage(t, string(buf[:n]))
+}
+
+// TestSyslogSinkTCP verifies messages over TCP are octet-counted
+func TestSyslogSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sink, err := NewSyslogSink(SyslogConfig{Address: ln.Addr().String(), Network: "tcp", Hostname: "router1"})
+	if err != nil {
+		t.Fatalf("NewSyslogSink() error = %v", err)
+	}
+	defer sink.Close()
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	bgpService := NewBGPService()
+	bgpService.AddUpdateHandler(sink)
+	bgpService.dispatch(syslogTestUpdate())
+	bgpService.dispatch(syslogTestUpdate())
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	for i := 0; i < 2; i++ {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("reading frame length: %v", err)
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		if err != nil {
+			t.Fatalf("frame length %q: %v", length, err)
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		checkSyslogMessage(t, string(msg))
+	}
+}
+
+// TestSyslogSinkCollectorDown verifies updates are dropped and counted, not
+// waited on, once the collector is gone
+func TestSyslogSinkCollectorDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink, err := NewSyslogSink(SyslogConfig{Address: ln.Addr().String(), Network: "tcp", Queue: 1})
+	if err != nil {
+		t.Fatalf("NewSyslogSink() error = %v", err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	ln.Close()
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		sink.HandleUpdate(syslogTestUpdate())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("HandleUpdate took %v for 100 updates with the collector down", elapsed)
+	}
+	sink.Close()
+	if sink.Dropped() == 0 {
+		t.Error("Dropped() = 0, want the undeliverable updates counted")
+	}
+}
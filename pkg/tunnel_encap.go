@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	api "github.com/osrg/gobgp/v3/api"
+	"net"
+)
+
+// TunnelEncap is one tunnel TLV of the Tunnel Encapsulation attribute
+// (RFC 9012), telling overlay peers how to reach the route's egress
+type TunnelEncap struct {
+	Type uint16 // Tunnel type, e.g. 8 for VXLAN or 2 for GRE
+
+	// Key and Cookie split the encapsulation sub-TLV as GoBGP reads it: the
+	// first four bytes, then the rest; for VXLAN the key holds the V and M
+	// flags in its top byte and the VNI below, and the cookie the MAC
+	Key    *uint32
+	Cookie []byte
+
+	Color          *uint32 // Color sub-TLV, ties the route to an SR policy
+	EgressEndpoint net.IP  // Tunnel egress endpoint sub-TLV
+	UDPDestPort    uint16  // UDP destination port sub-TLV, 0 when not sent
+}
+
+// parseTunnelEncap converts the Tunnel Encapsulation attribute
+// Sub-TLVs other than the ones TunnelEncap holds are skipped
+func parseTunnelEncap(a *api.TunnelEncapAttribute) ([]TunnelEncap, error) {
+	var tunnels []TunnelEncap
+	for _, tlv := range a.GetTlvs() {
+		tunnel := TunnelEncap{Type: uint16(tlv.GetType())}
+		for _, sub := range tlv.GetTlvs() {
+			msg, err := sub.UnmarshalNew()
+			if err != nil {
+				return nil, err
+			}
+			switch t := msg.(type) {
+			case *api.TunnelEncapSubTLVEncapsulation:
+				key := t.Key
+				tunnel.Key, tunnel.Cookie = &key, t.Cookie
+			case *api.TunnelEncapSubTLVColor:
+				color := t.Color
+				tunnel.Color = &color
+			case *api.TunnelEncapSubTLVEgressEndpoint:
+				tunnel.EgressEndpoint = net.ParseIP(t.Address)
+			case *api.TunnelEncapSubTLVUDPDestPort:
+				tunnel.UDPDestPort = uint16(t.Port)
+			}
+		}
+		tunnels = append(tunnels, tunnel)
+	}
+	return tunnels, nil
+}
@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// UpdateFilter selects the updates a single subscriber receives
+// Every non-empty criterion must match, and within one criterion any
+// listed value is enough; the zero filter matches every update
+// Withdrawals carry no attributes, so they are matched on prefixes and
+// families alone and reach every subscriber that may have the route
+type UpdateFilter struct {
+	// Prefixes matches updates announcing or withdrawing a prefix equal to
+	// or more specific than one of these
+	Prefixes []netip.Prefix
+
+	// Communities matches updates carrying one of these, e.g. "65000:100"
+	Communities []string
+
+	// RPKIStates matches the origin validation state: valid, invalid or
+	// not-found; updates that were not validated never match
+	RPKIStates []string
+
+	// Families matches the address family of the update's prefixes, named as
+	// in neighbor config, e.g. ipv6-unicast or l2vpn-evpn
+	Families []string
+}
+
+// rpkiStateNames are the origin validation states as parsePath names them
+var rpkiStateNames = []string{"valid", "invalid", "not-found"}
+
+// ParseUpdateFilter builds an UpdateFilter from CIDR strings and the other
+// criteria as they are, e.g. from a request, rejecting RPKI states and
+// families that no update could ever match
+func ParseUpdateFilter(prefixes, communities, rpkiStates, families []string) (UpdateFilter, error) {
+	for _, state := range rpkiStates {
+		if !slices.Contains(rpkiStateNames, state) {
+			return UpdateFilter{}, fmt.Errorf("invalid RPKI state %q, expected valid, invalid or not-found", state)
+		}
+	}
+	for _, family := range families {
+		if _, err := parseFamily(family); err != nil {
+			return UpdateFilter{}, err
+		}
+	}
+	filter := UpdateFilter{Communities: communities, RPKIStates: rpkiStates, Families: families}
+	for _, cidr := range prefixes {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return UpdateFilter{}, fmt.Errorf("%w %q: %w", ErrInvalidPrefix, cidr, err)
+		}
+		filter.Prefixes = append(filter.Prefixes, prefix.Masked())
+	}
+	return filter, nil
+}
+
+// match reports whether update passes every criterion of the filter
+func (f UpdateFilter) match(update BGPUpdateMessage) bool {
+	if update.IsWithdraw {
+		f.RPKIStates, f.Communities = nil, nil
+	}
+	if len(f.RPKIStates) > 0 && (update.RPKIValidationState == nil || !slices.Contains(f.RPKIStates, *update.RPKIValidationState)) {
+		return false
+	}
+	if len(f.Communities) > 0 && !slices.ContainsFunc(update.CommunityStrings, func(c string) bool { return slices.Contains(f.Communities, c) }) {
+		return false
+	}
+	if len(f.Families) > 0 && !slices.ContainsFunc(updateFamilies(update), func(family string) bool { return slices.Contains(f.Families, family) }) {
+		return false
+	}
+	if len(f.Prefixes) > 0 && !slices.ContainsFunc(updatePrefixes(update), f.covers) {
+		return false
+	}
+	return true
+}
+
+// covers reports whether prefix is within one of the filter's prefixes
+func (f UpdateFilter) covers(prefix netip.Prefix) bool {
+	for _, want := range f.Prefixes {
+		if prefix.Bits() >= want.Bits() && want.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePrefixes returns every prefix an update announces or withdraws
+func updatePrefixes(update BGPUpdateMessage) []netip.Prefix {
+	var prefixes []netip.Prefix
+	add := func(length uint8, ip net.IP) {
+		if addr, ok := netip.AddrFromSlice(ip); ok {
+			if prefix := netip.PrefixFrom(addr.Unmap(), int(length)); prefix.IsValid() {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+	for _, n := range update.NLRI {
+		add(n.PrefixLength, n.Prefix)
+	}
+	for _, n := range update.WithdrawnRoutes {
+		add(n.PrefixLength, n.Prefix)
+	}
+	return prefixes
+}
+
+// updateFamilies names the address families of an update as in familyNames
+func updateFamilies(update BGPUpdateMessage) []string {
+	switch {
+	case update.EVPN != nil:
+		return []string{"l2vpn-evpn"}
+	case update.FlowSpec != nil:
+		if strings.Contains(update.FlowSpec.DestinationPrefix+update.FlowSpec.SourcePrefix, ":") {
+			return []string{"ipv6-flowspec"}
+		}
+		return []string{"ipv4-flowspec"}
+	}
+	var families []string
+	for _, prefix := range updatePrefixes(update) {
+		if family := routeFamily(prefix, update); !slices.Contains(families, family) {
+			families = append(families, family)
+		}
+	}
+	return families
+}
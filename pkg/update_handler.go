@@ -0,0 +1,45 @@
+package pkg
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+)
+
+// UpdateHandler is implemented by anything that consumes parsed BGP updates
+// Handlers are registered with BGPService.AddUpdateHandler
+type UpdateHandler interface {
+	HandleUpdate(update BGPUpdateMessage)
+}
+
+// UpdateHandlerFunc adapts an ordinary function to the UpdateHandler interface
+type UpdateHandlerFunc func(update BGPUpdateMessage)
+
+// HandleUpdate calls f(update)
+func (f UpdateHandlerFunc) HandleUpdate(update BGPUpdateMessage) {
+	f(update)
+}
+
+// NDJSONWriter is an UpdateHandler that writes each update as one JSON
+// document per line, the format consumed by BGPService.ReplayUpdates
+type NDJSONWriter struct {
+	mu  sync.Mutex // Serializes writes so lines never interleave
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter returns an NDJSONWriter writing to w
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{enc: json.NewEncoder(w)}
+}
+
+// HandleUpdate encodes the update followed by a newline
+func (w *NDJSONWriter) HandleUpdate(update BGPUpdateMessage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// json.Encoder terminates every value with '\n', which gives us NDJSON
+	if err := w.enc.Encode(update); err != nil {
+		log.Printf("Error writing update as NDJSON: %v", err)
+	}
+}
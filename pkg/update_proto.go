@@ -0,0 +1,351 @@
+package pkg
+
+import (
+	"bgp_dashboard/pkg/bgpdashpb"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"time"
+)
+
+// UpdateFormat selects how sinks serialize updates
+type UpdateFormat string
+
+const (
+	// FormatJSON encodes each update as a JSON document, the default
+	FormatJSON UpdateFormat = "json"
+	// FormatProtobuf encodes each update as a bgpdash.Update message from
+	// proto/bgpdash.proto, which is smaller and cheaper to produce
+	FormatProtobuf UpdateFormat = "protobuf"
+)
+
+// validateUpdateFormat rejects formats other than json and protobuf; empty means json
+func validateUpdateFormat(format UpdateFormat) error {
+	switch format {
+	case "", FormatJSON, FormatProtobuf:
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, expected json or protobuf", format)
+}
+
+// encodeUpdate serializes update in format
+func encodeUpdate(update BGPUpdateMessage, format UpdateFormat) ([]byte, error) {
+	if format == FormatProtobuf {
+		return proto.Marshal(updateToProto(update))
+	}
+	return json.Marshal(update)
+}
+
+// updateToProto converts an update to its wire representation
+func updateToProto(u BGPUpdateMessage) *bgpdashpb.Update {
+	pb := &bgpdashpb.Update{
+		FromPeer:            u.FromPeer,
+		Timestamp:           u.Timestamp,
+		IsWithdraw:          u.IsWithdraw,
+		Med:                 u.MED,
+		LocalPref:           u.LocalPref,
+		Communities:         u.CommunityStrings,
+		RpkiValidationState: u.RPKIValidationState,
+		AsLoop:              u.ASLoop,
+		ParseErrors:         u.ParseErrors,
+
+		WithdrawnRoutesLength:    uint32(u.WithdrawnRoutesLength),
+		WithdrawnRoutes:          prefixesToProto(u.WithdrawnRoutes),
+		TotalPathAttributeLength: uint32(u.TotalPathAttributeLength),
+		OriginAs:                 u.OriginAS,
+		AtomicAggregate:          u.AtomicAggregate,
+		AggregatorAs:             u.AggregatorAS,
+		AggregatorAddress:        ipString(u.AggregatorAddress),
+		Aigp:                     u.AIGP,
+		OnlyToCustomer:           u.OnlyToCustomer,
+		AsPathTooLong:            u.ASPathTooLong,
+		CommunityValues:          u.Communities,
+		ExtendedCommunities:      u.ExtendedCommunities,
+		RouteDistinguisher:       u.RouteDistinguisher,
+		Labels:                   u.Labels,
+		Sequence:                 u.Sequence,
+		Ebgp:                     u.EBGP,
+	}
+	pb.NextHop = ipString(u.NextHop)
+	pb.OriginatorId = ipString(u.OriginatorID)
+	if u.Origin != nil {
+		origin := uint32(*u.Origin)
+		pb.Origin = &origin
+	}
+	pb.Nlri = prefixesToProto(u.NLRI)
+	for _, segment := range u.ASPath {
+		pb.AsPath = append(pb.AsPath, &bgpdashpb.AsSegment{Asns: segment})
+	}
+	for _, c := range u.LargeCommunities {
+		pb.LargeCommunities = append(pb.LargeCommunities, &bgpdashpb.LargeCommunity{GlobalAdmin: c[0], LocalData1: c[1], LocalData2: c[2]})
+	}
+	for _, id := range u.ClusterList {
+		pb.ClusterList = append(pb.ClusterList, id.String())
+	}
+	for _, a := range u.UnknownAttributes {
+		pb.UnknownAttributes = append(pb.UnknownAttributes, &bgpdashpb.UnknownAttribute{Type: uint32(a.Type), Flags: uint32(a.Flags), Value: a.Value})
+	}
+
+	if reach := u.MPReachNLRI; reach.AFI != 0 || len(reach.NLRIs) > 0 {
+		pb.MpReach = &bgpdashpb.MpReach{
+			Afi:              uint32(reach.AFI),
+			Safi:             uint32(reach.SAFI),
+			NextHop:          ipString(reach.NextHop),
+			NextHopGlobal:    ipString(reach.NextHopGlobal),
+			NextHopLinkLocal: ipString(reach.NextHopLinkLocal),
+			Nlris:            prefixesToProto(reach.NLRIs),
+		}
+	}
+	if unreach := u.MPUnreachNLRI; unreach.AFI != 0 || len(unreach.NLRIs) > 0 {
+		pb.MpUnreach = &bgpdashpb.MpUnreach{
+			Afi:   uint32(unreach.AFI),
+			Safi:  uint32(unreach.SAFI),
+			Nlris: prefixesToProto(unreach.NLRIs),
+		}
+	}
+	if fs := u.FlowSpec; fs != nil {
+		pb.Flowspec = &bgpdashpb.FlowSpecRule{
+			DestinationPrefix: fs.DestinationPrefix,
+			SourcePrefix:      fs.SourcePrefix,
+			Protocols:         fs.Protocols,
+			Ports:             fs.Ports,
+			DestinationPorts:  fs.DestinationPorts,
+			SourcePorts:       fs.SourcePorts,
+			Other:             fs.Other,
+		}
+	}
+	if e := u.EVPN; e != nil {
+		pb.Evpn = &bgpdashpb.EvpnRoute{
+			RouteType:          uint32(e.RouteType),
+			RouteDistinguisher: e.RouteDistinguisher,
+			EthernetTag:        e.EthernetTag,
+			Mac:                e.MAC,
+			Ip:                 ipString(e.IP),
+			PrefixLength:       uint32(e.PrefixLength),
+			Gateway:            ipString(e.Gateway),
+			Labels:             e.Labels,
+		}
+	}
+	if t := u.PMSITunnel; t != nil {
+		pb.PmsiTunnel = &bgpdashpb.PmsiTunnel{Type: t.Type, Label: t.Label, TunnelId: t.TunnelID}
+	}
+	if p := u.PrefixSID; p != nil {
+		pb.PrefixSid = &bgpdashpb.PrefixSid{
+			Srv6L3Service: srv6SIDsToProto(p.SRv6L3Service),
+			Srv6L2Service: srv6SIDsToProto(p.SRv6L2Service),
+		}
+	}
+	for _, t := range u.TunnelEncap {
+		pb.TunnelEncap = append(pb.TunnelEncap, &bgpdashpb.TunnelEncap{
+			Type:           uint32(t.Type),
+			Key:            t.Key,
+			Cookie:         t.Cookie,
+			Color:          t.Color,
+			EgressEndpoint: ipString(t.EgressEndpoint),
+			UdpDestPort:    uint32(t.UDPDestPort),
+		})
+	}
+	if !u.ReceivedAt.IsZero() {
+		pb.ReceivedAtUnixNano = u.ReceivedAt.UnixNano()
+	}
+	return pb
+}
+
+// updateFromProto is the inverse of updateToProto, for consumers of
+// protobuf-encoded sinks
+// Protobuf does not tell an empty list from a missing one, so empty lists
+// come back nil
+func updateFromProto(pb *bgpdashpb.Update) BGPUpdateMessage {
+	u := BGPUpdateMessage{
+		FromPeer:            pb.GetFromPeer(),
+		Timestamp:           pb.GetTimestamp(),
+		IsWithdraw:          pb.GetIsWithdraw(),
+		MED:                 pb.Med,
+		LocalPref:           pb.LocalPref,
+		CommunityStrings:    pb.GetCommunities(),
+		RPKIValidationState: pb.RpkiValidationState,
+		ASLoop:              pb.GetAsLoop(),
+		ParseErrors:         pb.GetParseErrors(),
+
+		WithdrawnRoutesLength:    uint16(pb.GetWithdrawnRoutesLength()),
+		WithdrawnRoutes:          prefixesFromProto(pb.GetWithdrawnRoutes()),
+		TotalPathAttributeLength: uint16(pb.GetTotalPathAttributeLength()),
+		OriginAS:                 pb.GetOriginAs(),
+		AtomicAggregate:          pb.GetAtomicAggregate(),
+		AggregatorAS:             pb.AggregatorAs,
+		AggregatorAddress:        parseIP(pb.GetAggregatorAddress()),
+		AIGP:                     pb.Aigp,
+		OnlyToCustomer:           pb.OnlyToCustomer,
+		ASPathTooLong:            pb.GetAsPathTooLong(),
+		Communities:              pb.GetCommunityValues(),
+		ExtendedCommunities:      pb.GetExtendedCommunities(),
+		RouteDistinguisher:       pb.GetRouteDistinguisher(),
+		Labels:                   pb.GetLabels(),
+		Sequence:                 pb.GetSequence(),
+		EBGP:                     pb.GetEbgp(),
+
+		NextHop:      parseIP(pb.GetNextHop()),
+		OriginatorID: parseIP(pb.GetOriginatorId()),
+		NLRI:         prefixesFromProto(pb.GetNlri()),
+	}
+	if pb.Origin != nil {
+		origin := uint8(pb.GetOrigin())
+		u.Origin = &origin
+	}
+	for _, segment := range pb.GetAsPath() {
+		u.ASPath = append(u.ASPath, segment.GetAsns())
+	}
+	for _, c := range pb.GetLargeCommunities() {
+		u.LargeCommunities = append(u.LargeCommunities, [3]uint32{c.GetGlobalAdmin(), c.GetLocalData1(), c.GetLocalData2()})
+	}
+	for _, id := range pb.GetClusterList() {
+		u.ClusterList = append(u.ClusterList, parseIP(id))
+	}
+	for _, a := range pb.GetUnknownAttributes() {
+		u.UnknownAttributes = append(u.UnknownAttributes, struct {
+			Type  uint8
+			Flags uint8
+			Value []byte
+		}{Type: uint8(a.GetType()), Flags: uint8(a.GetFlags()), Value: a.GetValue()})
+	}
+
+	if reach := pb.GetMpReach(); reach != nil {
+		u.MPReachNLRI.AFI = uint16(reach.GetAfi())
+		u.MPReachNLRI.SAFI = uint8(reach.GetSafi())
+		u.MPReachNLRI.NextHop = parseIP(reach.GetNextHop())
+		u.MPReachNLRI.NextHopGlobal = parseIP(reach.GetNextHopGlobal())
+		u.MPReachNLRI.NextHopLinkLocal = parseIP(reach.GetNextHopLinkLocal())
+		u.MPReachNLRI.NLRIs = prefixesFromProto(reach.GetNlris())
+	}
+	if unreach := pb.GetMpUnreach(); unreach != nil {
+		u.MPUnreachNLRI.AFI = uint16(unreach.GetAfi())
+		u.MPUnreachNLRI.SAFI = uint8(unreach.GetSafi())
+		u.MPUnreachNLRI.NLRIs = prefixesFromProto(unreach.GetNlris())
+	}
+	if fs := pb.GetFlowspec(); fs != nil {
+		u.FlowSpec = &FlowSpecRule{
+			DestinationPrefix: fs.GetDestinationPrefix(),
+			SourcePrefix:      fs.GetSourcePrefix(),
+			Protocols:         fs.GetProtocols(),
+			Ports:             fs.GetPorts(),
+			DestinationPorts:  fs.GetDestinationPorts(),
+			SourcePorts:       fs.GetSourcePorts(),
+			Other:             fs.GetOther(),
+		}
+	}
+	if e := pb.GetEvpn(); e != nil {
+		u.EVPN = &EVPNRoute{
+			RouteType:          uint8(e.GetRouteType()),
+			RouteDistinguisher: e.GetRouteDistinguisher(),
+			EthernetTag:        e.GetEthernetTag(),
+			MAC:                e.GetMac(),
+			IP:                 parseIP(e.GetIp()),
+			PrefixLength:       uint8(e.GetPrefixLength()),
+			Gateway:            parseIP(e.GetGateway()),
+			Labels:             e.GetLabels(),
+		}
+	}
+	if t := pb.GetPmsiTunnel(); t != nil {
+		u.PMSITunnel = &PMSITunnel{Type: t.GetType(), Label: t.GetLabel(), TunnelID: t.GetTunnelId()}
+	}
+	if p := pb.GetPrefixSid(); p != nil {
+		u.PrefixSID = &PrefixSID{
+			SRv6L3Service: srv6SIDsFromProto(p.GetSrv6L3Service()),
+			SRv6L2Service: srv6SIDsFromProto(p.GetSrv6L2Service()),
+		}
+	}
+	for _, t := range pb.GetTunnelEncap() {
+		u.TunnelEncap = append(u.TunnelEncap, TunnelEncap{
+			Type:           uint16(t.GetType()),
+			Key:            t.Key,
+			Cookie:         t.GetCookie(),
+			Color:          t.Color,
+			EgressEndpoint: parseIP(t.GetEgressEndpoint()),
+			UDPDestPort:    uint16(t.GetUdpDestPort()),
+		})
+	}
+	if ns := pb.GetReceivedAtUnixNano(); ns != 0 {
+		u.ReceivedAt = time.Unix(0, ns)
+	}
+	return u
+}
+
+// prefixesToProto converts NLRI entries to their wire form
+func prefixesToProto(prefixes []struct {
+	PrefixLength uint8
+	Prefix       net.IP
+}) []*bgpdashpb.Prefix {
+	var pbs []*bgpdashpb.Prefix
+	for _, p := range prefixes {
+		pbs = append(pbs, &bgpdashpb.Prefix{Prefix: p.Prefix.String(), Length: uint32(p.PrefixLength)})
+	}
+	return pbs
+}
+
+// prefixesFromProto is the inverse of prefixesToProto
+func prefixesFromProto(pbs []*bgpdashpb.Prefix) []struct {
+	PrefixLength uint8
+	Prefix       net.IP
+} {
+	var prefixes []struct {
+		PrefixLength uint8
+		Prefix       net.IP
+	}
+	for _, p := range pbs {
+		prefixes = append(prefixes, struct {
+			PrefixLength uint8
+			Prefix       net.IP
+		}{PrefixLength: uint8(p.GetLength()), Prefix: parseIP(p.GetPrefix())})
+	}
+	return prefixes
+}
+
+// srv6SIDsToProto converts SRv6 SIDs to their protobuf form
+func srv6SIDsToProto(sids []SRv6SID) []*bgpdashpb.Srv6Sid {
+	var pbs []*bgpdashpb.Srv6Sid
+	for _, sid := range sids {
+		pb := &bgpdashpb.Srv6Sid{Sid: ipString(sid.SID), EndpointBehavior: sid.EndpointBehavior}
+		if st := sid.Structure; st != nil {
+			pb.Structure = &bgpdashpb.Srv6SidStructure{
+				LocatorBlockLength:  uint32(st.LocatorBlockLength),
+				LocatorNodeLength:   uint32(st.LocatorNodeLength),
+				FunctionLength:      uint32(st.FunctionLength),
+				ArgumentLength:      uint32(st.ArgumentLength),
+				TranspositionLength: uint32(st.TranspositionLength),
+				TranspositionOffset: uint32(st.TranspositionOffset),
+			}
+		}
+		pbs = append(pbs, pb)
+	}
+	return pbs
+}
+
+// srv6SIDsFromProto is the inverse of srv6SIDsToProto
+// SIDs are IPv6 addresses, so they keep their 16-byte form
+func srv6SIDsFromProto(pbs []*bgpdashpb.Srv6Sid) []SRv6SID {
+	var sids []SRv6SID
+	for _, pb := range pbs {
+		sid := SRv6SID{SID: net.ParseIP(pb.GetSid()), EndpointBehavior: pb.GetEndpointBehavior()}
+		if st := pb.GetStructure(); st != nil {
+			sid.Structure = &SRv6SIDStructure{
+				LocatorBlockLength:  uint8(st.GetLocatorBlockLength()),
+				LocatorNodeLength:   uint8(st.GetLocatorNodeLength()),
+				FunctionLength:      uint8(st.GetFunctionLength()),
+				ArgumentLength:      uint8(st.GetArgumentLength()),
+				TranspositionLength: uint8(st.GetTranspositionLength()),
+				TranspositionOffset: uint8(st.GetTranspositionOffset()),
+			}
+		}
+		sids = append(sids, sid)
+	}
+	return sids
+}
+
+// ipString formats ip, leaving a missing address empty rather than "<nil>"
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build details, set at link time, e.g.
+//
+//	go build -ldflags "-X bgp_dashboard/pkg.Version=v1.2.0 -X bgp_dashboard/pkg.Commit=$(git rev-parse HEAD) -X bgp_dashboard/pkg.Date=$(date -u +%FT%TZ)" ./cmd
+var (
+	Version = "dev"
+	Commit  = "dev"
+	Date    = "dev"
+)
+
+// BuildInfo describes the running binary, as served on GET /version
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// GetBuildInfo returns the link-time build details
+// A commit or date not set through -ldflags falls back to the VCS stamp the
+// go command embeds when building from a checkout
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version()}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "dev":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "dev":
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}